@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// listenFDsStart is the first file descriptor passed by socket activation.
+const listenFDsStart = 3
+
+// activationListeners returns the listeners passed by systemd socket
+// activation, or none when the process was not socket activated. See
+// sd_listen_fds(3). A unit starting the daemon on demand and stopping it
+// after a minute without connections, as an unprivileged user only members
+// of the gomagic group can connect to:
+//
+//	# gomagic.socket
+//	[Socket]
+//	ListenStream=/run/gomagic.sock
+//	SocketMode=0660
+//	SocketGroup=gomagic
+//
+//	[Install]
+//	WantedBy=sockets.target
+//
+//	# gomagic.service
+//	[Service]
+//	ExecStart=/usr/bin/gomagic daemon -idle 1m
+//	DynamicUser=yes
+//
+// Adding -paths lets them detect any file that user can read.
+func activationListeners() ([]net.Listener, error) {
+	return listenersFrom(listenFDsStart)
+}
+
+// listenersFrom implements activationListeners for descriptors starting at
+// start. The activation variables are unset, so child processes do not
+// take the descriptors for theirs.
+func listenersFrom(start int) ([]net.Listener, error) {
+	pid, fds, names := os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS"), os.Getenv("LISTEN_FDNAMES")
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+	if pid == "" || fds == "" || pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(fds)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS %q", fds)
+	}
+	nameList := strings.Split(names, ":")
+	listeners := make([]net.Listener, 0, n)
+	for fd := start; fd < start+n; fd++ {
+		syscall.CloseOnExec(fd)
+		name := "LISTEN_FD_" + strconv.Itoa(fd)
+		if i := fd - start; i < len(nameList) && nameList[i] != "" {
+			name = nameList[i]
+		}
+		f := os.NewFile(uintptr(fd), name)
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("socket %s: %w", name, err)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
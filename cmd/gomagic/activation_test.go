@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/nitrocao/gomagic/libmagic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *CLITestSuite) TestListenersFrom() {
+	t := s.T()
+	socket := filepath.Join(s.dir, "a.sock")
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	defer l.Close()
+	f, err := l.(*net.UnixListener).File()
+	require.NoError(t, err)
+	defer f.Close()
+
+	// Not for this process.
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "1")
+	listeners, err := listenersFrom(int(f.Fd()))
+	require.NoError(t, err)
+	assert.Empty(t, listeners)
+	_, set := os.LookupEnv("LISTEN_FDS")
+	assert.False(t, set)
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_FDNAMES", "gomagic.socket")
+	listeners, err = listenersFrom(int(f.Fd()))
+	require.NoError(t, err)
+	require.Len(t, listeners, 1)
+	defer listeners[0].Close()
+	assert.Equal(t, socket, listeners[0].Addr().String())
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "x")
+	_, err = listenersFrom(int(f.Fd()))
+	assert.ErrorContains(t, err, "invalid LISTEN_FDS")
+}
+
+func (s *CLITestSuite) TestDaemonIdle() {
+	t := s.T()
+	d, err := newDetector("", libmagic.MagicError)
+	require.NoError(t, err)
+	defer d.Close()
+	socket := filepath.Join(s.dir, "d.sock")
+	l, err := listenUnix(socket)
+	require.NoError(t, err)
+	daemon := newDaemon(d)
+	daemon.idle = 100 * time.Millisecond
+	done := make(chan error, 1)
+	go func() { done <- daemon.serve(context.Background(), l) }()
+
+	// An open connection keeps the daemon running.
+	conn, err := net.Dial("unix", socket)
+	require.NoError(t, err)
+	time.Sleep(300 * time.Millisecond)
+	result := request(t, conn, bufio.NewReader(conn), []byte("Chello\n"))
+	assert.Equal(t, "text/plain", result["mime"])
+	select {
+	case <-done:
+		t.Fatal("daemon stopped with a connection open")
+	default:
+	}
+
+	conn.Close()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("idle daemon did not stop")
+	}
+	_, err = os.Stat(socket)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+
+	"github.com/nitrocao/gomagic/libmagic"
+)
+
+// wireResult is the part of the JSON Result a client reads back.
+type wireResult struct {
+	Path        string   `json:"path"`
+	MIME        string   `json:"mime"`
+	Encoding    string   `json:"encoding"`
+	Description string   `json:"description"`
+	Extensions  []string `json:"extensions"`
+	Error       *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// client sends requests to a daemon over one connection.
+type client struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// dialDaemon connects to the daemon listening on socket.
+func dialDaemon(socket string) (*client, error) {
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	return &client{conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+// detectPath asks the daemon to detect the file at path. The error is only
+// set when the request failed; the detection error is in Result.Err.
+func (c *client) detectPath(path string) (libmagic.Result, error) {
+	return c.roundTrip(append([]byte{requestPath}, path...))
+}
+
+// detectContent is like detectPath but detects content.
+func (c *client) detectContent(content []byte) (libmagic.Result, error) {
+	return c.roundTrip(append([]byte{requestContent}, content...))
+}
+
+func (c *client) roundTrip(req []byte) (libmagic.Result, error) {
+	if err := writeFrame(c.conn, req); err != nil {
+		return libmagic.Result{}, err
+	}
+	resp, err := readFrame(c.r)
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return libmagic.Result{}, err
+	}
+	var w wireResult
+	if err := json.Unmarshal(resp, &w); err != nil {
+		return libmagic.Result{}, fmt.Errorf("invalid response: %w", err)
+	}
+	r := libmagic.Result{
+		Path:        w.Path,
+		MIME:        w.MIME,
+		Encoding:    w.Encoding,
+		Description: w.Description,
+		Extensions:  w.Extensions,
+	}
+	if w.Error != nil {
+		r.Err = errors.New(w.Error.Message)
+	}
+	return r, nil
+}
+
+func (c *client) Close() {
+	c.conn.Close()
+}
+
+// daemonBackend detects with a daemon. The daemon's flags select how files
+// are detected, the local ones only what is printed.
+type daemonBackend struct {
+	c     *client
+	flags libmagic.Flags
+	stdin io.Reader
+}
+
+func (b *daemonBackend) describe(name string) (string, error) {
+	r, err := b.result(name)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case b.flags&libmagic.MagicMime == libmagic.MagicMime:
+		return r.MIME + "; charset=" + r.Encoding, nil
+	case b.flags&libmagic.MagicMimeType != 0:
+		return r.MIME, nil
+	case b.flags&libmagic.MagicMimeEncoding != 0:
+		return r.Encoding, nil
+	case b.flags&libmagic.MagicExtension != 0:
+		if len(r.Extensions) == 0 {
+			return "???", nil
+		}
+		return strings.Join(r.Extensions, "/"), nil
+	}
+	return r.Description, nil
+}
+
+// result returns the Result of name. Failed detections, like failed
+// requests, are returned as errors.
+func (b *daemonBackend) result(name string) (libmagic.Result, error) {
+	var (
+		r   libmagic.Result
+		err error
+	)
+	if name == "-" {
+		var content []byte
+		if content, err = readHead(b.stdin); err == nil {
+			r, err = b.c.detectContent(content)
+		}
+	} else {
+		// The daemon resolves relative paths against its own directory.
+		var path string
+		if path, err = filepath.Abs(name); err == nil {
+			r, err = b.c.detectPath(path)
+		}
+	}
+	if err == nil {
+		err = r.Err
+	}
+	r.Path, r.Err = displayName(name), err
+	return r, err
+}
+
+func (b *daemonBackend) Close() {
+	b.c.Close()
+}
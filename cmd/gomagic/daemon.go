@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/nitrocao/gomagic/libmagic"
+)
+
+// The daemon protocol runs over a Unix stream socket. Every message is a
+// frame: a 4-byte big-endian length followed by that many bytes. A client
+// sends request frames and reads one response frame per request, on as many
+// connections as it likes. The first byte of a request selects what the rest
+// is:
+//
+//	'P'  the path of a file, which the daemon opens itself, when -paths
+//	     is set
+//	'C'  content to detect, at most libmagic.DefaultHeadSize bytes
+//
+// A response is the JSON Result, with the MIME type, the encoding and the
+// description all set, or the error key when the detection failed.
+const (
+	requestPath    = 'P'
+	requestContent = 'C'
+)
+
+// maxFrame is the largest frame read; a connection sending a larger one is
+// closed.
+const maxFrame = 1 + libmagic.DefaultHeadSize
+
+// writeTimeout is how long the daemon waits for a client to take a
+// response, so a client that stops reading cannot hold up its exit.
+const writeTimeout = 10 * time.Second
+
+// socketEnv names the environment variable holding the default socket.
+const socketEnv = "GOMAGIC_SOCKET"
+
+var errFrameTooLarge = errors.New("frame too large")
+
+// writeFrame writes payload as a frame.
+func writeFrame(w io.Writer, payload []byte) error {
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(payload)))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// readFrame reads a frame and returns its payload. It returns io.EOF when r
+// ends before a frame starts.
+func readFrame(r io.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(header[:])
+	if n > maxFrame {
+		return nil, errFrameTooLarge
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return payload, nil
+}
+
+// runDaemon serves detections over a Unix socket until interrupted, or
+// until idle for the -idle duration. A socket passed by systemd socket
+// activation takes precedence over -socket.
+func runDaemon(args []string, _ io.Reader, _, stderr io.Writer) int {
+	fs := newCommandFlags("daemon", "[-socket path] [-idle duration] [-paths] [-m magicfiles] [-pool n] [-zL]", stderr)
+	socket := fs.String("socket", os.Getenv(socketEnv), "path of the Unix socket, $"+socketEnv+" by default")
+	idle := fs.Duration("idle", 0, "exit after this long without connections, 0 to never exit")
+	paths := fs.Bool("paths", false, "enable path requests, detecting local files by path")
+	detector := detectorFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return usageCode(err)
+	}
+	activated, err := activationListeners()
+	if err != nil {
+		fmt.Fprintf(stderr, "gomagic: %v\n", err)
+		return 1
+	}
+	if len(activated) > 1 {
+		for _, l := range activated {
+			l.Close()
+		}
+		fmt.Fprintf(stderr, "gomagic: %d sockets passed, want one\n", len(activated))
+		return 1
+	}
+	if *socket == "" && len(activated) == 0 || fs.NArg() > 0 {
+		fs.Usage()
+		return 1
+	}
+	d, err := detector()
+	if err != nil {
+		fmt.Fprintf(stderr, "gomagic: %v\n", err)
+		return 1
+	}
+	defer d.Close()
+	var l net.Listener
+	if len(activated) == 1 {
+		l = activated[0]
+	} else if l, err = listenUnix(*socket); err != nil {
+		fmt.Fprintf(stderr, "gomagic: %v\n", err)
+		return 1
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	s := newDaemon(d)
+	s.idle = *idle
+	s.paths = *paths
+	if err := s.serve(ctx, l); err != nil {
+		fmt.Fprintf(stderr, "gomagic: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// detectorFlags registers the flags configuring the detector of a server on
+// fs and returns a function creating that detector once fs is parsed.
+// Servers report failures as errors, so the detector sets MagicError.
+func detectorFlags(fs *flag.FlagSet) func() (*libmagic.MagicDetector, error) {
+	magic := fs.String("m", "", "colon separated list of magic files")
+	pool := fs.Int("pool", 0, "number of libmagic handles, GOMAXPROCS by default")
+	uncompress := fs.Bool("z", false, "look inside compressed files")
+	dereference := fs.Bool("L", false, "follow symlinks")
+	return func() (*libmagic.MagicDetector, error) {
+		flags := libmagic.MagicError
+		if *uncompress {
+			flags |= libmagic.MagicCompress
+		}
+		if *dereference {
+			flags |= libmagic.MagicSymlink
+		}
+		return newDetector(*magic, flags, libmagic.WithPoolSize(*pool))
+	}
+}
+
+// listenUnix listens on the Unix socket path, replacing a stale socket left
+// behind by a daemon that did not exit cleanly.
+func listenUnix(path string) (net.Listener, error) {
+	l, err := net.Listen("unix", path)
+	if err == nil || !errors.Is(err, syscall.EADDRINUSE) {
+		return l, err
+	}
+	if conn, dialErr := net.Dial("unix", path); dialErr == nil {
+		conn.Close()
+		return nil, fmt.Errorf("a daemon is already listening on %s", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return nil, err
+	}
+	return net.Listen("unix", path)
+}
+
+// daemon answers the requests of the daemon protocol.
+type daemon struct {
+	d *libmagic.MagicDetector
+	// idle is how long serve waits without open connections before
+	// stopping; zero means forever.
+	idle time.Duration
+	// paths enables path requests.
+	paths bool
+	// writeTimeout is the write deadline of every response.
+	writeTimeout time.Duration
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	// idleTimer stops serve, see idle.
+	idleTimer *time.Timer
+	wg        sync.WaitGroup
+}
+
+func newDaemon(d *libmagic.MagicDetector) *daemon {
+	return &daemon{d: d, writeTimeout: writeTimeout, conns: map[net.Conn]struct{}{}}
+}
+
+// serve accepts connections on l until ctx is done or the daemon is idle,
+// then closes l and the connections, letting requests in progress finish
+// within writeTimeout.
+func (s *daemon) serve(ctx context.Context, l net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if s.idle > 0 {
+		s.mu.Lock()
+		s.idleTimer = time.AfterFunc(s.idle, cancel)
+		s.mu.Unlock()
+	}
+	stop := context.AfterFunc(ctx, func() {
+		l.Close()
+		s.closeConns()
+	})
+	defer stop()
+	defer s.wg.Wait()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			l.Close()
+			return err
+		}
+		if !s.track(conn) {
+			conn.Close()
+			continue
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.untrack(conn)
+			s.handle(conn)
+		}()
+	}
+}
+
+// track adds conn to the open connections, unless serve is stopping.
+func (s *daemon) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conns == nil {
+		return false
+	}
+	// Stop fails when the timer already fired, and serve is stopping.
+	if s.idleTimer != nil && len(s.conns) == 0 && !s.idleTimer.Stop() {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	return true
+}
+
+func (s *daemon) untrack(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conns, conn)
+	if s.idleTimer != nil && len(s.conns) == 0 {
+		s.idleTimer.Reset(s.idle)
+	}
+}
+
+// closeConns stops reading requests from the open connections and makes
+// track refuse new ones.
+func (s *daemon) closeConns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		// Only the read side, so a request in progress gets its response.
+		if c, ok := conn.(interface{ CloseRead() error }); ok {
+			c.CloseRead()
+		} else {
+			conn.Close()
+		}
+	}
+	s.conns = nil
+}
+
+// handle answers the requests of conn until it is closed.
+func (s *daemon) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		req, err := readFrame(r)
+		if err != nil {
+			return
+		}
+		resp, err := json.Marshal(s.detect(req))
+		if err != nil || conn.SetWriteDeadline(time.Now().Add(s.writeTimeout)) != nil ||
+			writeFrame(conn, resp) != nil {
+			return
+		}
+	}
+}
+
+// detect answers the request req.
+func (s *daemon) detect(req []byte) libmagic.Result {
+	if len(req) == 0 {
+		return libmagic.Result{Err: errors.New("empty request")}
+	}
+	switch req[0] {
+	case requestPath:
+		if !s.paths {
+			return libmagic.Result{Path: string(req[1:]), Err: errors.New("path detection is disabled")}
+		}
+		r, _ := s.d.DetectAll(string(req[1:]))
+		return r
+	case requestContent:
+		r, _ := s.d.DetectAllBuffer(req[1:])
+		return r
+	}
+	return libmagic.Result{Err: fmt.Errorf("unknown request %q", req[0])}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nitrocao/gomagic/libmagic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startDaemon serves a daemon on a socket in the test directory until the
+// test ends and returns the socket path. paths enables path requests.
+func (s *CLITestSuite) startDaemon(paths bool) string {
+	t := s.T()
+	d, err := newDetector("", libmagic.MagicError, libmagic.WithPoolSize(2))
+	require.NoError(t, err)
+	socket := filepath.Join(s.dir, "d.sock")
+	l, err := listenUnix(socket)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	daemon := newDaemon(d)
+	daemon.paths = paths
+	go func() { done <- daemon.serve(ctx, l) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+		d.Close()
+	})
+	return socket
+}
+
+// request sends req over conn and decodes the response.
+func request(t require.TestingT, conn net.Conn, r *bufio.Reader, req []byte) map[string]any {
+	require.NoError(t, writeFrame(conn, req))
+	resp, err := readFrame(r)
+	require.NoError(t, err)
+	var result map[string]any
+	require.NoError(t, json.Unmarshal(resp, &result))
+	return result
+}
+
+func (s *CLITestSuite) TestDaemonProtocol() {
+	t := s.T()
+	socket := s.startDaemon(true)
+	png := s.write("image.png", pngHeader)
+	conn, err := net.Dial("unix", socket)
+	require.NoError(t, err)
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	result := request(t, conn, r, append([]byte("P"), png...))
+	assert.Equal(t, png, result["path"])
+	assert.Equal(t, "image/png", result["mime"])
+	assert.Equal(t, "binary", result["encoding"])
+	assert.Contains(t, result["description"], "PNG image data")
+
+	result = request(t, conn, r, []byte("Chello\n"))
+	assert.Equal(t, "text/plain", result["mime"])
+	assert.Equal(t, "ASCII text", result["description"])
+
+	result = request(t, conn, r, append([]byte("P"), filepath.Join(s.dir, "missing")...))
+	assert.Contains(t, result, "error")
+	result = request(t, conn, r, []byte("X"))
+	assert.Contains(t, result["error"], "message")
+	result = request(t, conn, r, nil)
+	assert.Equal(t, "empty request", result["error"].(map[string]any)["message"])
+
+	// A frame over the limit closes the connection.
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], maxFrame+1)
+	_, err = conn.Write(header[:])
+	require.NoError(t, err)
+	_, err = readFrame(r)
+	assert.Error(t, err)
+}
+
+func (s *CLITestSuite) TestDaemonClient() {
+	t := s.T()
+	socket := s.startDaemon(true)
+	png := s.write("image.png", pngHeader)
+	text := s.write("a.txt", []byte("hello\n"))
+	missing := filepath.Join(s.dir, "missing")
+
+	out, _, code := s.run("", "--socket", socket, "-N", png, text)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.HasPrefix(out, png+": PNG image data"), out)
+	assert.True(t, strings.HasSuffix(out, text+": ASCII text\n"), out)
+
+	out, _, _ = s.run("", "--socket", socket, "-bi", png)
+	assert.Equal(t, "image/png; charset=binary\n", out)
+	out, _, _ = s.run("", "--socket", socket, "-b", "--mime-type", text)
+	assert.Equal(t, "text/plain\n", out)
+	out, _, _ = s.run("", "--socket", socket, "-b", "--extension", png)
+	assert.Equal(t, "png\n", out)
+	out, _, _ = s.run("hello\n", "--socket", socket, "-")
+	assert.Equal(t, "/dev/stdin: ASCII text\n", out)
+
+	out, _, code = s.run("", "--socket", socket, "-b", missing)
+	assert.Equal(t, 1, code)
+	assert.True(t, strings.HasPrefix(out, "ERROR: "), out)
+
+	out, _, code = s.run("", "--socket", socket, "--output", "ndjson", text, missing)
+	assert.Equal(t, 1, code)
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"path":"`+text+`"`)
+	assert.Contains(t, lines[1], `"error":`)
+
+	// Relative paths are resolved by the client.
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	rel, err := filepath.Rel(wd, text)
+	require.NoError(t, err)
+	out, _, _ = s.run("", "--socket", socket, rel)
+	assert.Equal(t, rel+": ASCII text\n", out)
+}
+
+func (s *CLITestSuite) TestDaemonStalledClient() {
+	t := s.T()
+	d, err := newDetector("", libmagic.MagicError, libmagic.WithPoolSize(1))
+	require.NoError(t, err)
+	defer d.Close()
+	l, err := listenUnix(filepath.Join(s.dir, "d.sock"))
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	daemon := newDaemon(d)
+	daemon.writeTimeout = 100 * time.Millisecond
+	done := make(chan error, 1)
+	go func() { done <- daemon.serve(ctx, l) }()
+
+	// The client sends requests but never reads a response.
+	conn, err := net.Dial("unix", l.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	go func() {
+		for writeFrame(conn, []byte("Chello\n")) == nil {
+		}
+	}()
+	time.Sleep(500 * time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve waited for the stalled client")
+	}
+}
+
+func (s *CLITestSuite) TestDaemonPathsDisabled() {
+	t := s.T()
+	socket := s.startDaemon(false)
+	png := s.write("image.png", pngHeader)
+	conn, err := net.Dial("unix", socket)
+	require.NoError(t, err)
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	result := request(t, conn, r, append([]byte("P"), png...))
+	assert.Equal(t, png, result["path"])
+	assert.Equal(t, "path detection is disabled", result["error"].(map[string]any)["message"])
+	assert.NotContains(t, result["description"], "PNG")
+	result = request(t, conn, r, append([]byte("C"), pngHeader...))
+	assert.Equal(t, "image/png", result["mime"])
+
+	out, _, code := s.run("", "--socket", socket, "-b", png)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "ERROR: path detection is disabled\n", out)
+}
+
+func (s *CLITestSuite) TestDaemonSocket() {
+	t := s.T()
+	socket := s.startDaemon(false)
+
+	_, err := listenUnix(socket)
+	assert.ErrorContains(t, err, "already listening")
+
+	// A socket nobody listens on any more is replaced.
+	stale := filepath.Join(s.dir, "stale.sock")
+	l, err := net.Listen("unix", stale)
+	require.NoError(t, err)
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	l, err = listenUnix(stale)
+	require.NoError(t, err)
+	l.Close()
+
+	_, stderr, code := s.run("", "--socket", filepath.Join(s.dir, "none.sock"), "x")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "gomagic: ")
+	_, stderr, code = s.run("", "daemon")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "usage: gomagic daemon")
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/nitrocao/gomagic/libmagic"
+)
+
+// newCommandFlags returns the flag set of the subcommand name.
+func newCommandFlags(name, usage string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("gomagic "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: gomagic %s %s\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// writeWarnings writes warnings to w the way file(1) prints them.
+func writeWarnings(w io.Writer, warnings []libmagic.CheckWarning) {
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "%s, %d: Warning: %s\n", warning.File, warning.Line, warning.Message)
+	}
+}
+
+// runCompile compiles magic source files into .mgc databases, like file -C,
+// and prints their paths. Without files, it compiles the default databases.
+func runCompile(args []string, _ io.Reader, stdout, stderr io.Writer) int {
+	fs := newCommandFlags("compile", "[-o dir] [file ...]", stderr)
+	dir := fs.String("o", ".", "directory to write the databases into")
+	if err := fs.Parse(args); err != nil {
+		return usageCode(err)
+	}
+	m, err := libmagic.NewMagic(libmagic.MagicNone)
+	if err != nil {
+		fmt.Fprintf(stderr, "gomagic: %v\n", err)
+		return 1
+	}
+	defer m.Close()
+	outputs, warnings, err := m.MagicCompileWarnings(*dir, fs.Args())
+	writeWarnings(stderr, warnings)
+	if err != nil {
+		fmt.Fprintf(stderr, "gomagic: %v\n", err)
+		return 1
+	}
+	for _, output := range outputs {
+		fmt.Fprintln(stdout, output)
+	}
+	return 0
+}
+
+// runCheck checks magic source files, like file -c, printing the warnings.
+// Without files, it checks the default databases.
+func runCheck(args []string, _ io.Reader, _, stderr io.Writer) int {
+	fs := newCommandFlags("check", "[file ...]", stderr)
+	if err := fs.Parse(args); err != nil {
+		return usageCode(err)
+	}
+	m, err := libmagic.NewMagic(libmagic.MagicNone)
+	if err != nil {
+		fmt.Fprintf(stderr, "gomagic: %v\n", err)
+		return 1
+	}
+	defer m.Close()
+	warnings, err := m.MagicCheckWarnings(fs.Args())
+	writeWarnings(stderr, warnings)
+	if err != nil {
+		fmt.Fprintf(stderr, "gomagic: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runList prints the rules of magic files with their strength, like
+// file -l. Without files, it lists the default databases.
+func runList(args []string, _ io.Reader, stdout, stderr io.Writer) int {
+	fs := newCommandFlags("list", "[file ...]", stderr)
+	if err := fs.Parse(args); err != nil {
+		return usageCode(err)
+	}
+	m, err := libmagic.NewMagic(libmagic.MagicNone)
+	if err != nil {
+		fmt.Fprintf(stderr, "gomagic: %v\n", err)
+		return 1
+	}
+	defer m.Close()
+	// Collected first: the listing is captured from the process stdout,
+	// which stdout may well be.
+	listing, err := m.MagicListString(fs.Args())
+	io.WriteString(stdout, listing)
+	if err != nil {
+		fmt.Fprintf(stderr, "gomagic: %v\n", err)
+		return 1
+	}
+	return 0
+}
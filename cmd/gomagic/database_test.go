@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	goodMagic = "0\tstring\tGOMAGIC\tgomagic test data\n!:mime\tapplication/x-gomagic-test\n"
+	badMagic  = "0\tbogus\tX\tbroken\n0\tstring\tAB\tok\n"
+)
+
+func (s *CLITestSuite) TestCompile() {
+	t := s.T()
+	if runtime.GOOS != "linux" {
+		t.Skip("compiling into a directory is only supported on Linux")
+	}
+	source := s.write("test.magic", []byte(goodMagic))
+	out := filepath.Join(s.dir, "out")
+	s.write("out/.keep", nil)
+
+	stdout, stderr, code := s.run("", "compile", "-o", out, source)
+	assert.Equal(t, 0, code, stderr)
+	compiled := filepath.Join(out, "test.magic.mgc")
+	assert.Equal(t, compiled+"\n", stdout)
+	assert.FileExists(t, compiled)
+
+	data := s.write("data", []byte("GOMAGIC payload"))
+	stdout, _, code = s.run("", "-b", "--mime-type", "-m", compiled, data)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "application/x-gomagic-test\n", stdout)
+
+	bad := s.write("bad", []byte(badMagic))
+	_, stderr, code = s.run("", "compile", "-o", out, bad)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, bad+", 1: Warning: ")
+	assert.Contains(t, stderr, "bogus")
+}
+
+func (s *CLITestSuite) TestCheck() {
+	t := s.T()
+	good := s.write("good", []byte(goodMagic))
+	bad := s.write("bad", []byte(badMagic))
+
+	stdout, stderr, code := s.run("", "check", good)
+	assert.Equal(t, 0, code)
+	assert.Empty(t, stdout)
+	assert.Empty(t, stderr)
+
+	_, stderr, code = s.run("", "check", bad)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, bad+", 1: Warning: ")
+	assert.Contains(t, stderr, "gomagic: ")
+
+	_, _, code = s.run("", "check", "-x")
+	assert.Equal(t, 1, code)
+	_, stderr, code = s.run("", "check", "-h")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stderr, "usage: gomagic check")
+}
+
+func (s *CLITestSuite) TestList() {
+	t := s.T()
+	source := s.write("test.magic", []byte(goodMagic))
+
+	stdout, _, code := s.run("", "list", source)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "gomagic test data")
+	assert.Contains(t, stdout, "application/x-gomagic-test")
+
+	_, stderr, code := s.run("", "list", filepath.Join(s.dir, "missing"))
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "gomagic: ")
+}
+
+func (s *CLITestSuite) TestListToStdout() {
+	t := s.T()
+	// A listing larger than a pipe buffer.
+	var source strings.Builder
+	for i := range 4096 {
+		fmt.Fprintf(&source, "0\tstring\tGOMAGIC%d\tgomagic test data %d\n", i, i)
+	}
+	path := s.write("test.magic", []byte(source.String()))
+
+	// The listing goes to the real process stdout, redirected to a file.
+	out, err := os.Create(filepath.Join(s.dir, "out"))
+	require.NoError(t, err)
+	defer out.Close()
+	saved, err := syscall.Dup(1)
+	require.NoError(t, err)
+	defer syscall.Close(saved)
+	require.NoError(t, syscall.Dup2(int(out.Fd()), 1))
+	code := run([]string{"list", path}, nil, os.Stdout, os.Stderr)
+	require.NoError(t, syscall.Dup2(saved, 1))
+
+	assert.Equal(t, 0, code)
+	data, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "gomagic test data 0")
+	assert.Contains(t, string(data), "gomagic test data 4095")
+}
+
+func (s *CLITestSuite) TestCommandName() {
+	t := s.T()
+	stdout, _, code := s.run("", "--", "list")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "list: cannot open `list' (No such file or directory)\n", stdout)
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nitrocao/gomagic/libmagic"
+)
+
+// stdinName is how file(1) names the standard input, given as "-".
+const stdinName = "/dev/stdin"
+
+// fileUsage is the usage of the file mode.
+const fileUsage = `usage: gomagic [-0bEhikLNprsvzZ] [-e testname] [-F separator] [-f namefile]
+               [-m magicfiles] [--output text|json|ndjson|csv]
+               [--include glob] [--exclude glob] [--hidden] [--socket path]
+               file ...
+`
+
+// testFlags map the names taken by -e to the tests they disable, as in
+// file(1).
+var testFlags = map[string]libmagic.Flags{
+	"apptype":  libmagic.MagicNoCheckAppType,
+	"ascii":    libmagic.MagicNoCheckText,
+	"cdf":      libmagic.MagicNoCheckCdf,
+	"compress": libmagic.MagicNoCheckCompress,
+	"csv":      libmagic.MagicNoCheckCsv,
+	"elf":      libmagic.MagicNoCheckElf,
+	"encoding": libmagic.MagicNoCheckEncoding,
+	"json":     libmagic.MagicNoCheckJSON,
+	"simh":     libmagic.MagicNoCheckSimh,
+	"soft":     libmagic.MagicNoCheckSoft,
+	"tar":      libmagic.MagicNoCheckTar,
+	"text":     libmagic.MagicNoCheckText,
+	"tokens":   libmagic.MagicNoCheckTokens,
+}
+
+// fileOptions are the file(1) options.
+type fileOptions struct {
+	flags     libmagic.Flags
+	brief     bool
+	noPad     bool
+	separator string
+	magic     string
+	version   bool
+	output    string
+	walk      walkOptions
+	// namefiles list names to detect, see readNames.
+	namefiles []string
+	print0    bool
+	// socket is the socket of the daemon to detect with, if any.
+	socket string
+}
+
+// newFileFlags returns the flag set of the file mode, storing into o.
+func newFileFlags(o *fileOptions, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("gomagic", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	setFlag := func(flags libmagic.Flags) func(string) error {
+		return func(string) error {
+			o.flags |= flags
+			return nil
+		}
+	}
+	both := func(short, long string, fn func(string) error, usage string) {
+		if short != "" {
+			fs.BoolFunc(short, usage, fn)
+		}
+		fs.BoolFunc(long, usage, fn)
+	}
+	both("b", "brief", func(string) error { o.brief = true; return nil }, "do not prepend file names")
+	both("i", "mime", setFlag(libmagic.MagicMime), "output MIME type and encoding")
+	both("", "mime-type", setFlag(libmagic.MagicMimeType), "output the MIME type")
+	both("", "mime-encoding", setFlag(libmagic.MagicMimeEncoding), "output the MIME encoding")
+	both("", "extension", setFlag(libmagic.MagicExtension), "output the valid extensions")
+	both("", "apple", setFlag(libmagic.MagicApple), "output the Apple creator and type")
+	both("z", "uncompress", setFlag(libmagic.MagicCompress), "look inside compressed files")
+	both("Z", "uncompress-noreport", setFlag(libmagic.MagicCompress|libmagic.MagicCompressTransp),
+		"look inside compressed files, reporting only the contents")
+	both("L", "dereference", func(string) error { o.flags |= libmagic.MagicSymlink; return nil }, "follow symlinks")
+	both("h", "no-dereference", func(string) error { o.flags &^= libmagic.MagicSymlink; return nil },
+		"do not follow symlinks (default)")
+	both("k", "keep-going", setFlag(libmagic.MagicContinue), "do not stop at the first match")
+	both("", "raw", setFlag(libmagic.MagicRaw), "do not translate unprintable characters")
+	both("s", "special-files", setFlag(libmagic.MagicDevices), "read block and character devices")
+	both("p", "preserve-date", setFlag(libmagic.MagicPreserveAtime), "preserve access times")
+	fs.BoolFunc("E", "report filesystem errors as errors", setFlag(libmagic.MagicError))
+	both("N", "no-pad", func(string) error { o.noPad = true; return nil }, "do not pad file names")
+	both("v", "version", func(string) error { o.version = true; return nil }, "print the version and exit")
+	exclude := func(name string) error {
+		flags, ok := testFlags[name]
+		if !ok {
+			return fmt.Errorf("unknown test %q", name)
+		}
+		o.flags |= flags
+		return nil
+	}
+	fs.Func("e", "exclude the named test", exclude)
+	fs.Func("exclude-test", "exclude the named test", exclude)
+	fs.Func("f", "read the names to detect from namefile, - for stdin", addNamefile(o))
+	fs.Func("files-from", "read the names to detect from namefile, - for stdin", addNamefile(o))
+	fs.BoolVar(&o.print0, "0", false, "read NUL terminated names with -f and NUL terminate the output")
+	fs.BoolVar(&o.print0, "print0", false, "read NUL terminated names with -f and NUL terminate the output")
+	fs.BoolVar(&o.walk.recursive, "r", false, "detect the files below directories")
+	fs.BoolVar(&o.walk.recursive, "recursive", false, "detect the files below directories")
+	fs.BoolVar(&o.walk.hidden, "hidden", false, "with -r, include hidden files and directories")
+	fs.Func("include", "with -r, only detect files matching the glob pattern", addPattern(&o.walk.include))
+	fs.Func("exclude", "with -r, skip files and directories matching the glob pattern", addPattern(&o.walk.exclude))
+	fs.StringVar(&o.separator, "F", ":", "separator after file names")
+	fs.StringVar(&o.separator, "separator", ":", "separator after file names")
+	fs.StringVar(&o.magic, "m", "", "colon separated list of magic files")
+	fs.StringVar(&o.magic, "magic-file", "", "colon separated list of magic files")
+	fs.StringVar(&o.socket, "socket", "",
+		"detect with the daemon listening on the Unix socket, which needs -paths, reporting failures like -E")
+	fs.Func("output", "output format: text, json, ndjson or csv", func(format string) error {
+		switch format {
+		case outputText, outputJSON, outputNDJSON, outputCSV:
+			o.output = format
+			return nil
+		}
+		return fmt.Errorf("unknown output format %q", format)
+	})
+	return fs
+}
+
+// runFile runs the file(1) compatible mode.
+func runFile(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	o := fileOptions{output: outputText}
+	if os.Getenv("POSIXLY_CORRECT") != "" {
+		o.flags |= libmagic.MagicSymlink
+	}
+	fs := newFileFlags(&o, stderr)
+	if err := fs.Parse(expandShort(fs, args)); err != nil {
+		return usageCode(err)
+	}
+	if o.version {
+		fmt.Fprintf(stdout, "gomagic (libmagic %d.%02d)\n", libmagic.Version()/100, libmagic.Version()%100)
+		fmt.Fprintf(stdout, "magic file from %s\n", databasePath(o.magic))
+		return 0
+	}
+	var names []string
+	for _, namefile := range o.namefiles {
+		listed, err := readNames(namefile, o.print0, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "gomagic: %v\n", err)
+			return 1
+		}
+		names = append(names, listed...)
+	}
+	names = append(names, fs.Args()...)
+	if len(names) == 0 && len(o.namefiles) == 0 {
+		fmt.Fprint(stderr, fileUsage)
+		return 1
+	}
+	names, walked := o.walk.expand(names, stderr)
+	if o.output != outputText {
+		// Failures go to the error field rather than the description.
+		o.flags |= libmagic.MagicError
+	}
+	b, err := o.backend(stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "gomagic: %v\n", err)
+		return 1
+	}
+	defer b.Close()
+	if o.output != outputText {
+		return max(writeResults(b, o.output, names, stdout, stderr), exitCode(walked))
+	}
+
+	width := 0
+	for _, name := range names {
+		width = max(width, utf8.RuneCountInString(displayName(name)))
+	}
+	code := 0
+	for _, name := range names {
+		desc, err := b.describe(name)
+		if err != nil {
+			desc = "ERROR: " + errorMessage(err)
+			code = 1
+		}
+		if o.print0 {
+			writeNUL(stdout, displayName(name), desc, o.brief)
+			continue
+		}
+		if !o.brief {
+			shown := displayName(name)
+			pad := 0
+			if !o.noPad {
+				pad = width - utf8.RuneCountInString(shown)
+			}
+			fmt.Fprintf(stdout, "%s%s%*s ", shown, o.separator, pad, "")
+		}
+		fmt.Fprintln(stdout, desc)
+	}
+	return max(code, exitCode(walked))
+}
+
+// exitCode returns the exit code for ok.
+func exitCode(ok bool) int {
+	if ok {
+		return 0
+	}
+	return 1
+}
+
+// writeResults detects names and writes their Results in format. The exit
+// code is 1 if any detection failed.
+func writeResults(b backend, format string, names []string, stdout, stderr io.Writer) int {
+	w, err := newResultWriter(format, stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, "gomagic: %v\n", err)
+		return 1
+	}
+	code := 0
+	for _, name := range names {
+		r, err := b.result(name)
+		if err != nil {
+			code = 1
+		}
+		if err := w.write(r); err != nil {
+			fmt.Fprintf(stderr, "gomagic: %v\n", err)
+			return 1
+		}
+	}
+	if err := w.close(); err != nil {
+		fmt.Fprintf(stderr, "gomagic: %v\n", err)
+		return 1
+	}
+	return code
+}
+
+// backend detects the files named on the command line.
+type backend interface {
+	// describe returns what file(1) prints for name.
+	describe(name string) (string, error)
+	// result returns the Result of name with the MIME type, the encoding
+	// and the description all set.
+	result(name string) (libmagic.Result, error)
+	Close()
+}
+
+// backend returns the backend selected by o, reading "-" from stdin.
+func (o *fileOptions) backend(stdin io.Reader) (backend, error) {
+	if o.socket != "" {
+		c, err := dialDaemon(o.socket)
+		if err != nil {
+			return nil, err
+		}
+		return &daemonBackend{c: c, flags: o.flags, stdin: stdin}, nil
+	}
+	d, err := newDetector(o.magic, o.flags)
+	if err != nil {
+		return nil, err
+	}
+	return &localBackend{d: d, stdin: stdin}, nil
+}
+
+// localBackend detects with a MagicDetector.
+type localBackend struct {
+	d     *libmagic.MagicDetector
+	stdin io.Reader
+}
+
+func (l *localBackend) describe(name string) (string, error) {
+	if name == "-" {
+		return l.d.DetectReader(l.stdin)
+	}
+	return l.d.DetectFile(name)
+}
+
+func (l *localBackend) result(name string) (libmagic.Result, error) {
+	if name != "-" {
+		return l.d.DetectAll(name)
+	}
+	content, err := readHead(l.stdin)
+	if err != nil {
+		return libmagic.Result{Path: stdinName, Err: err}, err
+	}
+	r, err := l.d.DetectAllBuffer(content)
+	r.Path = stdinName
+	return r, err
+}
+
+func (l *localBackend) Close() {
+	l.d.Close()
+}
+
+// readHead reads the first libmagic.DefaultHeadSize bytes of r, what
+// detecting a reader looks at.
+func readHead(r io.Reader) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(r, libmagic.DefaultHeadSize))
+}
+
+// newDetector returns a single handle detector loading the colon separated
+// databases in magic, or the default ones when it is empty. extra options
+// are applied last.
+func newDetector(magic string, flags libmagic.Flags, extra ...libmagic.DetectorOption) (*libmagic.MagicDetector, error) {
+	opts := []libmagic.DetectorOption{libmagic.WithPoolSize(1), libmagic.WithFlags(flags)}
+	opts = append(opts, extra...)
+	if magic != "" {
+		opts = append(opts, libmagic.WithDatabases(strings.Split(magic, ":")...))
+	}
+	return libmagic.NewDetector(opts...)
+}
+
+// displayName returns the name printed for name.
+func displayName(name string) string {
+	if name == "-" {
+		return stdinName
+	}
+	return name
+}
+
+// errorMessage returns the libmagic message of err, as file(1) prints it.
+func errorMessage(err error) string {
+	var e *libmagic.Error
+	if errors.As(err, &e) && e.Message != "" {
+		return e.Message
+	}
+	return err.Error()
+}
+
+// databasePath returns the databases loaded for magic.
+func databasePath(magic string) string {
+	if magic != "" {
+		return magic
+	}
+	return libmagic.DefaultDatabasePath()
+}
+
+// expandShort splits combined short flags such as -bi into -b -i, as file(1)
+// accepts them. A flag taking a value ends the group, and the rest of the
+// argument becomes its value, so -mfoo.mgc is -m foo.mgc.
+func expandShort(fs *flag.FlagSet, args []string) []string {
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || arg == "-" || !strings.HasPrefix(arg, "-") {
+			// Flags end at the first file name, as for the flag package.
+			return append(out, args[i:]...)
+		}
+		expanded := []string{arg}
+		if len(arg) > 2 && arg[1] != '-' && !strings.Contains(arg, "=") && fs.Lookup(arg[1:]) == nil {
+			expanded = splitShort(fs, arg)
+		}
+		out = append(out, expanded...)
+		if takesValue(fs, expanded[len(expanded)-1]) && i+1 < len(args) {
+			i++
+			out = append(out, args[i])
+		}
+	}
+	return out
+}
+
+// takesValue reports whether arg is a flag taking its value from the next
+// argument.
+func takesValue(fs *flag.FlagSet, arg string) bool {
+	name := strings.TrimLeft(arg, "-")
+	if name == arg || strings.Contains(name, "=") {
+		return false
+	}
+	f := fs.Lookup(name)
+	return f != nil && !isBoolFlag(f)
+}
+
+// isBoolFlag reports whether f takes no value.
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// splitShort splits the combined short flags in arg, or returns arg as is
+// when it names an unknown flag.
+func splitShort(fs *flag.FlagSet, arg string) []string {
+	var out []string
+	for i := 1; i < len(arg); i++ {
+		f := fs.Lookup(arg[i : i+1])
+		if f == nil {
+			return []string{arg}
+		}
+		out = append(out, "-"+f.Name)
+		if !isBoolFlag(f) {
+			if i+1 < len(arg) {
+				out = append(out, arg[i+1:])
+			}
+			break
+		}
+	}
+	return out
+}
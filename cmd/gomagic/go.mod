@@ -0,0 +1,28 @@
+module github.com/nitrocao/gomagic/cmd/gomagic
+
+go 1.24
+
+require (
+	github.com/nitrocao/gomagic v0.0.0
+	github.com/nitrocao/gomagic/gomagicpb v0.0.0
+	github.com/stretchr/testify v1.7.2-0.20220513111209-285adcc5ced0
+	google.golang.org/grpc v1.75.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/klauspost/compress v1.17.11 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/ulikunitz/xz v0.5.12 // indirect
+	golang.org/x/net v0.41.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
+	golang.org/x/text v0.26.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250707201910-8d1bb00bc6a7 // indirect
+	google.golang.org/protobuf v1.36.11 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)
+
+replace (
+	github.com/nitrocao/gomagic => ../..
+	github.com/nitrocao/gomagic/gomagicpb => ../../gomagicpb
+)
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/nitrocao/gomagic/gomagicpb"
+	"github.com/nitrocao/gomagic/libmagic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// runGRPC serves the gRPC Detector service until interrupted.
+func runGRPC(args []string, _ io.Reader, _, stderr io.Writer) int {
+	fs := newCommandFlags("grpc", "[-addr address] [-paths] [-m magicfiles] [-pool n] [-zL]", stderr)
+	addr := fs.String("addr", "localhost:50051", "address to listen on")
+	paths := fs.Bool("paths", false, "enable DetectPaths, detecting local files by path")
+	detector := detectorFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return usageCode(err)
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 1
+	}
+	d, err := detector()
+	if err != nil {
+		fmt.Fprintf(stderr, "gomagic: %v\n", err)
+		return 1
+	}
+	defer d.Close()
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintf(stderr, "gomagic: %v\n", err)
+		return 1
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := serveGRPC(ctx, l, &grpcServer{d: d, paths: *paths}); err != nil {
+		fmt.Fprintf(stderr, "gomagic: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// serveGRPC serves srv on l until ctx is done, then stops gracefully.
+func serveGRPC(ctx context.Context, l net.Listener, srv gomagicpb.DetectorServer) error {
+	s := grpc.NewServer()
+	gomagicpb.RegisterDetectorServer(s, srv)
+	stop := context.AfterFunc(ctx, s.GracefulStop)
+	defer stop()
+	return s.Serve(l)
+}
+
+// grpcServer implements the Detector service. Failed detections are
+// returned in Result.error, so a batch goes on past them; gRPC errors are
+// reserved for failed requests.
+type grpcServer struct {
+	gomagicpb.UnimplementedDetectorServer
+	d *libmagic.MagicDetector
+	// paths enables DetectPaths.
+	paths bool
+}
+
+func (s *grpcServer) DetectBuffer(_ context.Context, req *gomagicpb.DetectBufferRequest) (*gomagicpb.Result, error) {
+	r, _ := s.d.DetectAllBuffer(req.GetContent())
+	r.Path = req.GetName()
+	return resultProto(r), nil
+}
+
+func (s *grpcServer) DetectUpload(stream grpc.ClientStreamingServer[gomagicpb.Chunk, gomagicpb.Result]) error {
+	var (
+		content []byte
+		name    string
+	)
+	for first := true; ; first = false {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if first {
+			name = chunk.GetName()
+		}
+		n := min(len(chunk.GetData()), libmagic.DefaultHeadSize-len(content))
+		content = append(content, chunk.GetData()[:n]...)
+	}
+	r, _ := s.d.DetectAllBuffer(content)
+	r.Path = name
+	return stream.SendAndClose(resultProto(r))
+}
+
+func (s *grpcServer) DetectPaths(req *gomagicpb.DetectPathsRequest, stream grpc.ServerStreamingServer[gomagicpb.Result]) error {
+	if !s.paths {
+		return status.Error(codes.PermissionDenied, "path detection is disabled")
+	}
+	for _, path := range req.GetPaths() {
+		if err := stream.Context().Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		r := libmagic.Result{Path: path, Err: errors.New("path must be absolute")}
+		if filepath.IsAbs(path) {
+			r, _ = s.d.DetectAll(path)
+		}
+		if err := stream.Send(resultProto(r)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resultProto converts r to its protocol buffer message.
+func resultProto(r libmagic.Result) *gomagicpb.Result {
+	p := &gomagicpb.Result{
+		Path:        r.Path,
+		Mime:        r.MIME,
+		Encoding:    r.Encoding,
+		Description: r.Description,
+		Extensions:  r.Extensions,
+		MatchedDb:   r.Database,
+	}
+	if r.Kind != libmagic.KindContent {
+		p.Kind = r.Kind.String()
+	}
+	if r.Err != nil {
+		p.Error = &gomagicpb.Error{Message: r.Err.Error()}
+		var e *libmagic.Error
+		if errors.As(r.Err, &e) {
+			p.Error.Op, p.Error.Errno = e.Op, int32(e.Errno)
+		}
+	}
+	return p
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+
+	"github.com/nitrocao/gomagic/gomagicpb"
+	"github.com/nitrocao/gomagic/libmagic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// startGRPC serves the Detector service until the test ends and returns a
+// client for it.
+func (s *CLITestSuite) startGRPC(paths bool) gomagicpb.DetectorClient {
+	t := s.T()
+	d, err := newDetector("", libmagic.MagicError, libmagic.WithPoolSize(2))
+	require.NoError(t, err)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- serveGRPC(ctx, l, &grpcServer{d: d, paths: paths}) }()
+	conn, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+		assert.NoError(t, <-done)
+		d.Close()
+	})
+	return gomagicpb.NewDetectorClient(conn)
+}
+
+func (s *CLITestSuite) TestGRPCDetectBuffer() {
+	t := s.T()
+	client := s.startGRPC(false)
+
+	r, err := client.DetectBuffer(context.Background(), &gomagicpb.DetectBufferRequest{Content: pngHeader, Name: "x.png"})
+	require.NoError(t, err)
+	assert.Equal(t, "x.png", r.GetPath())
+	assert.Equal(t, "image/png", r.GetMime())
+	assert.Equal(t, "binary", r.GetEncoding())
+	assert.Contains(t, r.GetDescription(), "PNG image data")
+	assert.Equal(t, []string{"png"}, r.GetExtensions())
+	assert.NotEmpty(t, r.GetMatchedDb())
+	assert.Nil(t, r.GetError())
+
+	r, err = client.DetectBuffer(context.Background(), &gomagicpb.DetectBufferRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "empty", r.GetKind())
+}
+
+func (s *CLITestSuite) TestGRPCDetectUpload() {
+	t := s.T()
+	client := s.startGRPC(false)
+
+	stream, err := client.DetectUpload(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&gomagicpb.Chunk{Data: pngHeader[:4], Name: "image.png"}))
+	require.NoError(t, stream.Send(&gomagicpb.Chunk{Data: pngHeader[4:], Name: "ignored"}))
+	// Data past the head is discarded.
+	require.NoError(t, stream.Send(&gomagicpb.Chunk{Data: make([]byte, libmagic.DefaultHeadSize)}))
+	r, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	assert.Equal(t, "image.png", r.GetPath())
+	assert.Equal(t, "image/png", r.GetMime())
+}
+
+func (s *CLITestSuite) TestGRPCDetectPaths() {
+	t := s.T()
+	png := s.write("image.png", pngHeader)
+	text := s.write("a.txt", []byte("hello\n"))
+	missing := filepath.Join(s.dir, "missing")
+
+	stream, err := s.startGRPC(false).DetectPaths(context.Background(), &gomagicpb.DetectPathsRequest{Paths: []string{png}})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	stream, err = s.startGRPC(true).DetectPaths(context.Background(), &gomagicpb.DetectPathsRequest{
+		Paths: []string{png, missing, "a.txt", text, "/dev/null"},
+	})
+	require.NoError(t, err)
+	var results []*gomagicpb.Result
+	for {
+		r, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		results = append(results, r)
+	}
+	require.Len(t, results, 5)
+	assert.Equal(t, "image/png", results[0].GetMime())
+	assert.Equal(t, missing, results[1].GetPath())
+	assert.Equal(t, "magic_file", results[1].GetError().GetOp())
+	assert.Equal(t, int32(2), results[1].GetError().GetErrno())
+	assert.Equal(t, "path must be absolute", results[2].GetError().GetMessage())
+	assert.Equal(t, "ASCII text", results[3].GetDescription())
+	assert.Equal(t, "chardevice", results[4].GetKind())
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/nitrocao/gomagic/libmagic"
+)
+
+// shutdownTimeout is how long the HTTP server waits for requests in progress
+// when stopping.
+const shutdownTimeout = 10 * time.Second
+
+// runServe serves detections over HTTP until interrupted.
+func runServe(args []string, _ io.Reader, _, stderr io.Writer) int {
+	fs := newCommandFlags("serve", "[-addr address] [-paths] [-m magicfiles] [-pool n] [-zL]", stderr)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	paths := fs.Bool("paths", false, "enable /detect/path, detecting local files by path")
+	detector := detectorFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return usageCode(err)
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 1
+	}
+	d, err := detector()
+	if err != nil {
+		fmt.Fprintf(stderr, "gomagic: %v\n", err)
+		return 1
+	}
+	defer d.Close()
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintf(stderr, "gomagic: %v\n", err)
+		return 1
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := serveHTTP(ctx, l, newHandler(d, *paths)); err != nil {
+		fmt.Fprintf(stderr, "gomagic: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// serveHTTP serves h on l until ctx is done, then shuts down gracefully.
+func serveHTTP(ctx context.Context, l net.Listener, h http.Handler) error {
+	srv := &http.Server{Handler: h, ReadHeaderTimeout: 10 * time.Second}
+	stop := context.AfterFunc(ctx, func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	})
+	defer stop()
+	if err := srv.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// newHandler returns the handler of the HTTP API:
+//
+//	POST /detect       detects the request body or, for a multipart/form-data
+//	                   request, the first file in it
+//	GET  /detect/path  detects the local file at the absolute path given by
+//	                   the path query parameter, when paths is set
+//
+// Responses are the JSON Result, with the MIME type, the encoding and the
+// description all set, or the error key and an error status when the
+// detection failed.
+func newHandler(d *libmagic.MagicDetector, paths bool) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /detect", func(w http.ResponseWriter, r *http.Request) {
+		writeResult(w, detectUpload(d, r))
+	})
+	if paths {
+		mux.HandleFunc("GET /detect/path", func(w http.ResponseWriter, r *http.Request) {
+			writeResult(w, detectPath(d, r.URL.Query().Get("path")))
+		})
+	}
+	return mux
+}
+
+// badRequest marks errors caused by the request rather than the detection.
+type badRequest struct {
+	err error
+}
+
+func (e badRequest) Error() string { return e.err.Error() }
+func (e badRequest) Unwrap() error { return e.err }
+
+// detectUpload detects the body of r, or its first file if it is a
+// multipart form. The Result path is the file name of the upload, if any.
+func detectUpload(d *libmagic.MagicDetector, r *http.Request) libmagic.Result {
+	body, name := io.Reader(r.Body), ""
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		mr, err := r.MultipartReader()
+		if err != nil {
+			return libmagic.Result{Err: badRequest{err}}
+		}
+		for {
+			part, err := mr.NextPart()
+			if errors.Is(err, io.EOF) {
+				return libmagic.Result{Err: badRequest{errors.New("no file in multipart request")}}
+			}
+			if err != nil {
+				return libmagic.Result{Err: badRequest{err}}
+			}
+			if part.FileName() != "" {
+				body, name = part, part.FileName()
+				break
+			}
+		}
+	}
+	content, err := readHead(body)
+	if err != nil {
+		return libmagic.Result{Path: name, Err: badRequest{err}}
+	}
+	result, _ := d.DetectAllBuffer(content)
+	result.Path = name
+	return result
+}
+
+// detectPath detects the local file at path, which must be absolute.
+func detectPath(d *libmagic.MagicDetector, path string) libmagic.Result {
+	if !filepath.IsAbs(path) {
+		return libmagic.Result{Path: path, Err: badRequest{errors.New("path must be absolute")}}
+	}
+	result, _ := d.DetectAll(path)
+	return result
+}
+
+// writeResult writes r as JSON, with a status derived from its error.
+func writeResult(w http.ResponseWriter, r libmagic.Result) {
+	body, err := json.Marshal(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resultStatus(r.Err))
+	w.Write(append(body, '\n'))
+}
+
+// resultStatus returns the HTTP status for a Result with the error err.
+func resultStatus(err error) int {
+	var bad badRequest
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.As(err, &bad):
+		return http.StatusBadRequest
+	case errors.Is(err, fs.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return http.StatusForbidden
+	}
+	return http.StatusUnprocessableEntity
+}
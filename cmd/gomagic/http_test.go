@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/nitrocao/gomagic/libmagic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestHandler returns the HTTP API handler on a detector closed when the
+// test ends.
+func (s *CLITestSuite) newTestHandler(paths bool) http.Handler {
+	d, err := newDetector("", libmagic.MagicError)
+	require.NoError(s.T(), err)
+	s.T().Cleanup(d.Close)
+	return newHandler(d, paths)
+}
+
+// serveRequest serves req and returns the status and the decoded response.
+func serveRequest(t require.TestingT, h http.Handler, req *http.Request) (int, map[string]any) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var result map[string]any
+	if rec.Header().Get("Content-Type") == "application/json" {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	}
+	return rec.Code, result
+}
+
+func (s *CLITestSuite) TestHTTPDetect() {
+	t := s.T()
+	h := s.newTestHandler(false)
+
+	code, result := serveRequest(t, h, httptest.NewRequest(http.MethodPost, "/detect", bytes.NewReader(pngHeader)))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "image/png", result["mime"])
+	assert.Equal(t, "binary", result["encoding"])
+	assert.Contains(t, result["description"], "PNG image data")
+	assert.NotContains(t, result, "path")
+
+	code, _ = serveRequest(t, h, httptest.NewRequest(http.MethodGet, "/detect", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+	code, _ = serveRequest(t, h, httptest.NewRequest(http.MethodGet, "/detect/path?path=/", nil))
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func (s *CLITestSuite) TestHTTPDetectMultipart() {
+	t := s.T()
+	h := s.newTestHandler(false)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	require.NoError(t, mw.WriteField("comment", "not a file"))
+	fw, err := mw.CreateFormFile("file", "image.png")
+	require.NoError(t, err)
+	_, err = fw.Write(pngHeader)
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+	req := httptest.NewRequest(http.MethodPost, "/detect", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	code, result := serveRequest(t, h, req)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "image.png", result["path"])
+	assert.Equal(t, "image/png", result["mime"])
+
+	body.Reset()
+	mw = multipart.NewWriter(&body)
+	require.NoError(t, mw.WriteField("comment", "not a file"))
+	require.NoError(t, mw.Close())
+	req = httptest.NewRequest(http.MethodPost, "/detect", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	code, result = serveRequest(t, h, req)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "no file in multipart request", result["error"].(map[string]any)["message"])
+}
+
+func (s *CLITestSuite) TestHTTPDetectPath() {
+	t := s.T()
+	h := s.newTestHandler(true)
+	text := s.write("a.txt", []byte("hello\n"))
+	get := func(path string) (int, map[string]any) {
+		return serveRequest(t, h, httptest.NewRequest(http.MethodGet, "/detect/path?path="+url.QueryEscape(path), nil))
+	}
+
+	code, result := get(text)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, text, result["path"])
+	assert.Equal(t, "text/plain", result["mime"])
+	assert.Equal(t, "ASCII text", result["description"])
+
+	code, result = get(filepath.Join(s.dir, "missing"))
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Contains(t, result, "error")
+	code, _ = get("a.txt")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	if os.Geteuid() != 0 {
+		locked := s.write("locked", []byte("secret\n"))
+		require.NoError(t, os.Chmod(locked, 0))
+		code, _ = get(locked)
+		assert.Equal(t, http.StatusForbidden, code)
+	}
+}
+
+func (s *CLITestSuite) TestHTTPServe() {
+	t := s.T()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- serveHTTP(ctx, l, s.newTestHandler(false)) }()
+
+	resp, err := http.Post("http://"+l.Addr().String()+"/detect", "application/octet-stream", bytes.NewReader(pngHeader))
+	require.NoError(t, err)
+	var result map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	resp.Body.Close()
+	assert.Equal(t, "image/png", result["mime"])
+
+	cancel()
+	assert.NoError(t, <-done)
+	_, stderr, code := s.run("", "serve", "extra")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "usage: gomagic serve")
+}
@@ -0,0 +1,57 @@
+// Command gomagic identifies files like file(1), using the libmagic package
+// so its results match the library's exactly.
+//
+//	gomagic [-0bEhikLNprsvzZ] [-e testname] [-F separator] [-f namefile]
+//	        [-m magicfiles] [--output text|json|ndjson|csv]
+//	        [--include glob] [--exclude glob] [--hidden] [--socket path]
+//	        file ...
+//	gomagic compile [-o dir] [file ...]
+//	gomagic check [file ...]
+//	gomagic list [file ...]
+//	gomagic daemon [-socket path] [-idle duration] [-paths] [-m magicfiles] [-pool n] [-zL]
+//	gomagic serve [-addr address] [-paths] [-m magicfiles] [-pool n] [-zL]
+//	gomagic grpc [-addr address] [-paths] [-m magicfiles] [-pool n] [-zL]
+//
+// A first argument naming a subcommand runs it; use ./name or -- name to
+// detect a file with that name.
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+)
+
+// commands are the subcommands, run with the arguments following their name.
+var commands = map[string]func(args []string, stdin io.Reader, stdout, stderr io.Writer) int{
+	"compile": runCompile,
+	"check":   runCheck,
+	"list":    runList,
+	"daemon":  runDaemon,
+	"serve":   runServe,
+	"grpc":    runGRPC,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs gomagic with args and returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		if command, ok := commands[args[0]]; ok {
+			return command(args[1:], stdin, stdout, stderr)
+		}
+	}
+	return runFile(args, stdin, stdout, stderr)
+}
+
+// usageCode returns the exit code for the flag parsing error err: 0 when
+// help was requested, 1 otherwise.
+func usageCode(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89")
+
+type CLITestSuite struct {
+	suite.Suite
+	dir string
+}
+
+func (s *CLITestSuite) SetupTest() {
+	s.dir = s.T().TempDir()
+}
+
+// write writes content to name in the test directory and returns its path.
+func (s *CLITestSuite) write(name string, content []byte) string {
+	path := filepath.Join(s.dir, name)
+	require.NoError(s.T(), os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(s.T(), os.WriteFile(path, content, 0o644))
+	return path
+}
+
+// run runs gomagic with args and stdin, returning stdout, stderr and the
+// exit code.
+func (s *CLITestSuite) run(stdin string, args ...string) (string, string, int) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return stdout.String(), stderr.String(), code
+}
+
+func (s *CLITestSuite) TestFile() {
+	t := s.T()
+	png := s.write("image.png", pngHeader)
+	text := s.write("a.txt", []byte("hello\n"))
+
+	out, _, code := s.run("", png, text)
+	assert.Equal(t, 0, code)
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], png+": PNG image data"), lines[0])
+	pad := strings.Repeat(" ", len(png)-len(text))
+	assert.Equal(t, text+":"+pad+" ASCII text", lines[1])
+
+	out, _, _ = s.run("", "-N", png, text)
+	assert.Contains(t, out, text+": ASCII text\n")
+	out, _, _ = s.run("", "-F", " =>", "-N", text)
+	assert.Equal(t, text+" => ASCII text\n", out)
+}
+
+func (s *CLITestSuite) TestMIME() {
+	t := s.T()
+	png := s.write("image.png", pngHeader)
+	text := s.write("a.txt", []byte("hello\n"))
+
+	out, _, _ := s.run("", "-bi", png, text)
+	assert.Equal(t, "image/png; charset=binary\ntext/plain; charset=us-ascii\n", out)
+	out, _, _ = s.run("", "-b", "--mime-type", png)
+	assert.Equal(t, "image/png\n", out)
+	out, _, _ = s.run("", "--brief", "--mime-encoding", text)
+	assert.Equal(t, "us-ascii\n", out)
+}
+
+func (s *CLITestSuite) TestStdin() {
+	t := s.T()
+	out, _, code := s.run("hello\n", "-")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "/dev/stdin: ASCII text\n", out)
+}
+
+func (s *CLITestSuite) TestExclude() {
+	t := s.T()
+	png := s.write("image.png", pngHeader)
+
+	out, _, _ := s.run("", "-b", "-e", "soft", png)
+	assert.Equal(t, "data\n", out)
+	out, _, _ = s.run("", "-besoft", png)
+	assert.Equal(t, "data\n", out)
+	_, stderr, code := s.run("", "-e", "bogus", png)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, `unknown test "bogus"`)
+}
+
+func (s *CLITestSuite) TestSymlink() {
+	t := s.T()
+	png := s.write("image.png", pngHeader)
+	link := filepath.Join(s.dir, "link")
+	require.NoError(t, os.Symlink(png, link))
+
+	out, _, _ := s.run("", "-b", link)
+	assert.Equal(t, "symbolic link to "+png+"\n", out)
+	out, _, _ = s.run("", "-bL", link)
+	assert.True(t, strings.HasPrefix(out, "PNG image data"), out)
+	out, _, _ = s.run("", "-bLh", link)
+	assert.True(t, strings.HasPrefix(out, "symbolic link"), out)
+}
+
+func (s *CLITestSuite) TestUncompress() {
+	t := s.T()
+	gz := s.write("a.gz", []byte{
+		0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0xcb, 0x48, 0xcd, 0xc9, 0xc9, 0xe7,
+		0x02, 0x00, 0x20, 0x30, 0x3a, 0x36, 0x06, 0x00, 0x00, 0x00,
+	})
+
+	out, _, _ := s.run("", "-b", "--mime-type", gz)
+	assert.Equal(t, "application/gzip\n", out)
+	out, _, _ = s.run("", "-bz", gz)
+	assert.True(t, strings.HasPrefix(out, "ASCII text (gzip compressed data"), out)
+	out, _, _ = s.run("", "-bZ", "--mime-type", gz)
+	assert.Equal(t, "text/plain\n", out)
+}
+
+func (s *CLITestSuite) TestErrors() {
+	t := s.T()
+	missing := filepath.Join(s.dir, "missing")
+
+	out, _, code := s.run("", missing)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, missing+": cannot open `"+missing+"' (No such file or directory)\n", out)
+	out, _, code = s.run("", "-E", missing)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, missing+": ERROR: cannot stat `"+missing+"' (No such file or directory)\n", out)
+
+	_, stderr, code := s.run("")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "usage: gomagic")
+	_, stderr, code = s.run("", "-m", missing, missing)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "gomagic: ")
+}
+
+func (s *CLITestSuite) TestVersion() {
+	t := s.T()
+	out, _, code := s.run("", "-v")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, out, "gomagic (libmagic ")
+	assert.Contains(t, out, "magic file from ")
+}
+
+func (s *CLITestSuite) TestExpandShort() {
+	t := s.T()
+	fs := newFileFlags(&fileOptions{}, &bytes.Buffer{})
+	assert.Equal(t, []string{"-b", "-i", "x"}, expandShort(fs, []string{"-bi", "x"}))
+	assert.Equal(t, []string{"-b", "-m", "a.mgc", "x"}, expandShort(fs, []string{"-bma.mgc", "x"}))
+	assert.Equal(t, []string{"--mime-type", "-", "-b"}, expandShort(fs, []string{"--mime-type", "-", "-b"}))
+	assert.Equal(t, []string{"-bogus"}, expandShort(fs, []string{"-bogus"}))
+	assert.Equal(t, []string{"--socket", "s", "-b", "-i", "x"}, expandShort(fs, []string{"--socket", "s", "-bi", "x"}))
+	assert.Equal(t, []string{"-e", "-bi", "-b"}, expandShort(fs, []string{"-e", "-bi", "-b"}))
+}
+
+func TestCLITestSuite(t *testing.T) {
+	suite.Run(t, new(CLITestSuite))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// addNamefile returns a flag function appending namefiles to o.
+func addNamefile(o *fileOptions) func(string) error {
+	return func(namefile string) error {
+		o.namefiles = append(o.namefiles, namefile)
+		return nil
+	}
+}
+
+// readNames returns the names listed in namefile, or stdin when it is "-",
+// one per line or, when nul, NUL terminated as printed by find -print0.
+// Empty names are skipped.
+func readNames(namefile string, nul bool, stdin io.Reader) ([]string, error) {
+	r := stdin
+	if namefile != "-" {
+		f, err := os.Open(namefile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	delim := byte('\n')
+	if nul {
+		delim = 0
+	}
+	var names []string
+	br := bufio.NewReader(r)
+	for {
+		name, err := br.ReadString(delim)
+		name = strings.TrimSuffix(name, string(delim))
+		if !nul {
+			name = strings.TrimSuffix(name, "\r")
+		}
+		if name != "" {
+			names = append(names, name)
+		}
+		if errors.Is(err, io.EOF) {
+			return names, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", namefile, err)
+		}
+	}
+}
+
+// writeNUL writes name and desc each followed by a NUL, or only desc when
+// brief, so names and descriptions with newlines can be told apart.
+func writeNUL(w io.Writer, name, desc string, brief bool) {
+	if !brief {
+		fmt.Fprintf(w, "%s\x00", name)
+	}
+	fmt.Fprintf(w, "%s\x00", desc)
+}
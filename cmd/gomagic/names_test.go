@@ -0,0 +1,75 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *CLITestSuite) TestNamefile() {
+	t := s.T()
+	png := s.write("image.png", pngHeader)
+	text := s.write("a.txt", []byte("hello\n"))
+	list := s.write("list", []byte(png+"\n\n"+text+"\r\n"))
+
+	out, _, code := s.run("", "-N", "-f", list)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.HasPrefix(out, png+": PNG image data"), out)
+	assert.True(t, strings.HasSuffix(out, "\n"+text+": ASCII text\n"), out)
+
+	// Listed names come before the arguments.
+	out, _, _ = s.run("", "-b", "--mime-type", "--files-from", list, list)
+	assert.Equal(t, "image/png\ntext/plain\ntext/plain\n", out)
+
+	_, stderr, code := s.run("", "-f", filepath.Join(s.dir, "missing"))
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "no such file or directory")
+
+	// An empty list is not a usage error.
+	out, stderr, code = s.run("", "-f", "-")
+	assert.Equal(t, 0, code)
+	assert.Empty(t, out)
+	assert.Empty(t, stderr)
+}
+
+func (s *CLITestSuite) TestNamefileStdin() {
+	t := s.T()
+	text := s.write("a.txt", []byte("hello\n"))
+
+	out, _, code := s.run(text+"\n", "-f", "-")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, text+": ASCII text\n", out)
+}
+
+func (s *CLITestSuite) TestPrint0() {
+	t := s.T()
+	png := s.write("image.png", pngHeader)
+	odd := s.write("new\nline.txt", []byte("hello\n"))
+
+	out, _, code := s.run(png+"\x00"+odd+"\x00", "-0", "-f", "-")
+	assert.Equal(t, 0, code)
+	fields := strings.Split(out, "\x00")
+	require.Len(t, fields, 5)
+	assert.Equal(t, png, fields[0])
+	assert.True(t, strings.HasPrefix(fields[1], "PNG image data"), fields[1])
+	assert.Equal(t, odd, fields[2])
+	assert.Equal(t, "ASCII text", fields[3])
+	assert.Empty(t, fields[4])
+
+	out, _, _ = s.run(odd+"\x00", "--print0", "-b", "-f", "-")
+	assert.Equal(t, "ASCII text\x00", out)
+	out, _, _ = s.run("", "-0", "--mime-type", odd)
+	assert.Equal(t, odd+"\x00text/plain\x00", out)
+}
+
+func (s *CLITestSuite) TestReadNames() {
+	t := s.T()
+	names, err := readNames("-", false, strings.NewReader("a\nb c\r\n\nd"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b c", "d"}, names)
+	names, err = readNames("-", true, strings.NewReader("a\nb\x00\x00c\r\x00"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a\nb", "c\r"}, names)
+}
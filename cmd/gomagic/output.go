@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/nitrocao/gomagic/libmagic"
+)
+
+// Output formats taken by --output.
+const (
+	outputText   = "text"
+	outputJSON   = "json"
+	outputNDJSON = "ndjson"
+	outputCSV    = "csv"
+)
+
+// csvHeader is the header of the csv output.
+var csvHeader = []string{"path", "mime", "encoding", "description", "error"}
+
+// resultWriter writes Results in a structured output format.
+type resultWriter interface {
+	write(r libmagic.Result) error
+	// close ends the output.
+	close() error
+}
+
+// newResultWriter returns a resultWriter for format writing to w.
+func newResultWriter(format string, w io.Writer) (resultWriter, error) {
+	switch format {
+	case outputJSON:
+		return &jsonWriter{w: w}, nil
+	case outputNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	case outputCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: cw}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
+
+// jsonWriter writes a JSON array of Results, one element per line, so it
+// can stream instead of buffering all Results.
+type jsonWriter struct {
+	w io.Writer
+	n int
+}
+
+func (j *jsonWriter) write(r libmagic.Result) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if j.n == 0 {
+		sep = "[\n"
+	}
+	j.n++
+	_, err = fmt.Fprintf(j.w, "%s%s", sep, data)
+	return err
+}
+
+func (j *jsonWriter) close() error {
+	if j.n == 0 {
+		_, err := io.WriteString(j.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(j.w, "\n]\n")
+	return err
+}
+
+// ndjsonWriter writes one JSON Result per line.
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) write(r libmagic.Result) error {
+	return n.enc.Encode(r)
+}
+
+func (n *ndjsonWriter) close() error {
+	return nil
+}
+
+// csvWriter writes a header and then one record per Result with the columns
+// in csvHeader.
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) write(r libmagic.Result) error {
+	var msg string
+	if r.Err != nil {
+		msg = r.Err.Error()
+	}
+	return c.w.Write([]string{r.Path, r.MIME, r.Encoding, r.Description, msg})
+}
+
+func (c *csvWriter) close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
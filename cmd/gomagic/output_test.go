@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *CLITestSuite) TestOutputJSON() {
+	t := s.T()
+	png := s.write("image.png", pngHeader)
+	missing := filepath.Join(s.dir, "missing")
+
+	out, _, code := s.run("hello\n", "--output", "json", png, missing, "-")
+	assert.Equal(t, 1, code)
+	var results []map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &results), out)
+	require.Len(t, results, 3)
+	assert.Equal(t, png, results[0]["path"])
+	assert.Equal(t, "image/png", results[0]["mime"])
+	assert.Equal(t, "binary", results[0]["encoding"])
+	assert.Contains(t, results[0]["description"], "PNG image data")
+	assert.NotContains(t, results[0], "error")
+	assert.Equal(t, missing, results[1]["path"])
+	assert.Contains(t, results[1], "error")
+	assert.Equal(t, "/dev/stdin", results[2]["path"])
+	assert.Equal(t, "text/plain", results[2]["mime"])
+
+	out, _, code = s.run("", "--output=json", png)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.HasPrefix(out, "[\n{"), out)
+	assert.True(t, strings.HasSuffix(out, "}\n]\n"), out)
+}
+
+func (s *CLITestSuite) TestOutputNDJSON() {
+	t := s.T()
+	png := s.write("image.png", pngHeader)
+	text := s.write("a.txt", []byte("hello\n"))
+
+	out, _, code := s.run("", "--output", "ndjson", png, text)
+	assert.Equal(t, 0, code)
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	require.Len(t, lines, 2)
+	var r map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &r))
+	assert.Equal(t, text, r["path"])
+	assert.Equal(t, "text/plain", r["mime"])
+	assert.Equal(t, "us-ascii", r["encoding"])
+	assert.Equal(t, "ASCII text", r["description"])
+}
+
+func (s *CLITestSuite) TestOutputCSV() {
+	t := s.T()
+	text := s.write("a,b.txt", []byte("hello\n"))
+	missing := filepath.Join(s.dir, "missing")
+
+	out, _, code := s.run("", "--output", "csv", text, missing)
+	assert.Equal(t, 1, code)
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, csvHeader, records[0])
+	assert.Equal(t, []string{text, "text/plain", "us-ascii", "ASCII text", ""}, records[1])
+	assert.Equal(t, missing, records[2][0])
+	assert.Contains(t, records[2][4], "No such file or directory")
+
+	_, stderr, code := s.run("", "--output", "xml", text)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, `unknown output format "xml"`)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// walkOptions select the files -r detects below the directories given on
+// the command line.
+type walkOptions struct {
+	recursive bool
+	// hidden includes files and directories whose name starts with a dot.
+	hidden bool
+	// include and exclude are glob patterns, see match.
+	include, exclude []string
+}
+
+// addPattern returns a flag function appending valid glob patterns to
+// patterns.
+func addPattern(patterns *[]string) func(string) error {
+	return func(pattern string) error {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		*patterns = append(*patterns, pattern)
+		return nil
+	}
+}
+
+// expand returns names with the directories replaced by the files below
+// them, in lexical order, when recursive. Other names are kept as is, so
+// include, exclude and hidden only apply to the files found walking. Errors
+// reading directories are written to stderr and reported by ok being false.
+func (w walkOptions) expand(names []string, stderr io.Writer) (expanded []string, ok bool) {
+	if !w.recursive {
+		return names, true
+	}
+	ok = true
+	for _, name := range names {
+		if info, err := os.Stat(name); name == "-" || err != nil || !info.IsDir() {
+			expanded = append(expanded, name)
+			continue
+		}
+		err := filepath.WalkDir(name, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				fmt.Fprintf(stderr, "gomagic: %v\n", err)
+				ok = false
+				return nil
+			}
+			if path == name {
+				return nil
+			}
+			rel, _ := filepath.Rel(name, path)
+			if !w.hidden && strings.HasPrefix(entry.Name(), ".") || match(w.exclude, rel) {
+				if entry.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if !entry.IsDir() && (len(w.include) == 0 || match(w.include, rel)) {
+				expanded = append(expanded, path)
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(stderr, "gomagic: %v\n", err)
+			ok = false
+		}
+	}
+	return expanded, ok
+}
+
+// match reports whether any of patterns matches rel, a path relative to a
+// walked directory. Patterns with a slash match the whole slash separated
+// path and others the base name, so "*.go" matches Go files at any depth and
+// "vendor/*" only the files directly in vendor.
+func match(patterns []string, rel string) bool {
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range patterns {
+		name := rel
+		if !strings.Contains(pattern, "/") {
+			name = rel[strings.LastIndexByte(rel, '/')+1:]
+		}
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
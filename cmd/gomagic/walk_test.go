@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTree writes a small tree to the test directory.
+func (s *CLITestSuite) writeTree() {
+	s.write("a.txt", []byte("hello\n"))
+	s.write("image.png", pngHeader)
+	s.write("sub/b.txt", []byte("world\n"))
+	s.write("sub/deep/c.png", pngHeader)
+	s.write("vendor/d.txt", []byte("vendored\n"))
+	s.write(".hidden", []byte("secret\n"))
+	s.write(".git/config", []byte("[core]\n"))
+}
+
+// paths returns the paths of the lines of out, relative to the test
+// directory.
+func (s *CLITestSuite) paths(out string) []string {
+	var paths []string
+	for line := range strings.Lines(out) {
+		path, _, _ := strings.Cut(line, ":")
+		rel, err := filepath.Rel(s.dir, path)
+		require.NoError(s.T(), err)
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	return paths
+}
+
+func (s *CLITestSuite) TestRecursive() {
+	t := s.T()
+	s.writeTree()
+
+	out, _, code := s.run("", s.dir)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, s.dir+": directory\n", out)
+
+	out, _, code = s.run("", "-r", s.dir)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, []string{"a.txt", "image.png", "sub/b.txt", "sub/deep/c.png", "vendor/d.txt"}, s.paths(out))
+	assert.Contains(t, out, filepath.Join(s.dir, "sub", "deep", "c.png")+": PNG image data")
+
+	out, _, _ = s.run("", "--recursive", "--hidden", s.dir)
+	assert.Equal(t, []string{".git/config", ".hidden", "a.txt", "image.png", "sub/b.txt", "sub/deep/c.png",
+		"vendor/d.txt"}, s.paths(out))
+
+	// Files named on the command line are never filtered.
+	out, _, _ = s.run("", "-r", "--include", "*.png", s.dir, filepath.Join(s.dir, ".hidden"))
+	assert.Equal(t, []string{"image.png", "sub/deep/c.png", ".hidden"}, s.paths(out))
+}
+
+func (s *CLITestSuite) TestRecursivePatterns() {
+	t := s.T()
+	s.writeTree()
+
+	out, _, _ := s.run("", "-r", "--exclude", "vendor", "--exclude", "*.png", s.dir)
+	assert.Equal(t, []string{"a.txt", "sub/b.txt"}, s.paths(out))
+	out, _, _ = s.run("", "-r", "--exclude", "sub/*", s.dir)
+	assert.Equal(t, []string{"a.txt", "image.png", "vendor/d.txt"}, s.paths(out))
+	out, _, _ = s.run("", "-r", "--include", "*.txt", "--exclude", "sub", s.dir)
+	assert.Equal(t, []string{"a.txt", "vendor/d.txt"}, s.paths(out))
+	out, _, _ = s.run("", "-r", "--include", "sub/deep/*.png", s.dir)
+	assert.Equal(t, []string{"sub/deep/c.png"}, s.paths(out))
+
+	_, stderr, code := s.run("", "-r", "--include", "[", s.dir)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, `invalid pattern "["`)
+}
+
+func (s *CLITestSuite) TestRecursiveErrors() {
+	t := s.T()
+	if os.Geteuid() == 0 {
+		t.Skip("root can read any directory")
+	}
+	s.writeTree()
+	locked := filepath.Join(s.dir, "sub")
+	require.NoError(t, os.Chmod(locked, 0))
+	defer os.Chmod(locked, 0o755)
+
+	out, stderr, code := s.run("", "-r", s.dir)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "permission denied")
+	assert.Equal(t, []string{"a.txt", "image.png", "vendor/d.txt"}, s.paths(out))
+}
+
+func (s *CLITestSuite) TestRecursiveOutput() {
+	t := s.T()
+	s.writeTree()
+
+	out, _, code := s.run("", "-r", "--output", "csv", "--include", "*.png", s.dir)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, 3, strings.Count(out, "\n"))
+	assert.Contains(t, out, filepath.Join(s.dir, "image.png")+",image/png,binary,")
+}
@@ -1,5 +1,16 @@
 module github.com/nitrocao/gomagic
 
-go 1.16
+go 1.24
 
-require github.com/stretchr/testify v1.7.2-0.20220513111209-285adcc5ced0
+require (
+	github.com/klauspost/compress v1.17.11
+	github.com/stretchr/testify v1.7.2-0.20220513111209-285adcc5ced0
+	github.com/ulikunitz/xz v0.5.12
+	golang.org/x/sys v0.33.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)
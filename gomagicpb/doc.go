@@ -0,0 +1,9 @@
+// Package gomagicpb holds the protocol buffer messages and the gRPC service
+// of the gomagic detection server, so clients in any language can use it
+// without cgo. gomagic.proto is the source of the generated code.
+//
+// The package is a module of its own, as is cmd/gomagic, so that importing
+// the libmagic package does not pull in gRPC and protobuf.
+package gomagicpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative gomagic.proto
@@ -0,0 +1,433 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: gomagic.proto
+
+package gomagicpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type DetectBufferRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Content []byte                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	// name is returned as the path of the Result.
+	Name          string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DetectBufferRequest) Reset() {
+	*x = DetectBufferRequest{}
+	mi := &file_gomagic_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DetectBufferRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DetectBufferRequest) ProtoMessage() {}
+
+func (x *DetectBufferRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gomagic_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DetectBufferRequest.ProtoReflect.Descriptor instead.
+func (*DetectBufferRequest) Descriptor() ([]byte, []int) {
+	return file_gomagic_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *DetectBufferRequest) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *DetectBufferRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type Chunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Data  []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	// name is returned as the path of the Result; only read from the first
+	// chunk.
+	Name          string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Chunk) Reset() {
+	*x = Chunk{}
+	mi := &file_gomagic_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Chunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Chunk) ProtoMessage() {}
+
+func (x *Chunk) ProtoReflect() protoreflect.Message {
+	mi := &file_gomagic_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Chunk.ProtoReflect.Descriptor instead.
+func (*Chunk) Descriptor() ([]byte, []int) {
+	return file_gomagic_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Chunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Chunk) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type DetectPathsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// paths must be absolute.
+	Paths         []string `protobuf:"bytes,1,rep,name=paths,proto3" json:"paths,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DetectPathsRequest) Reset() {
+	*x = DetectPathsRequest{}
+	mi := &file_gomagic_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DetectPathsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DetectPathsRequest) ProtoMessage() {}
+
+func (x *DetectPathsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gomagic_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DetectPathsRequest.ProtoReflect.Descriptor instead.
+func (*DetectPathsRequest) Descriptor() ([]byte, []int) {
+	return file_gomagic_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DetectPathsRequest) GetPaths() []string {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+// Result mirrors the JSON Result of the libmagic package.
+type Result struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Path        string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Mime        string                 `protobuf:"bytes,2,opt,name=mime,proto3" json:"mime,omitempty"`
+	Encoding    string                 `protobuf:"bytes,3,opt,name=encoding,proto3" json:"encoding,omitempty"`
+	Description string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	Extensions  []string               `protobuf:"bytes,5,rep,name=extensions,proto3" json:"extensions,omitempty"`
+	MatchedDb   string                 `protobuf:"bytes,6,opt,name=matched_db,json=matchedDb,proto3" json:"matched_db,omitempty"`
+	// kind is empty for content and otherwise names what the path is, such as
+	// "symlink" or "directory".
+	Kind string `protobuf:"bytes,7,opt,name=kind,proto3" json:"kind,omitempty"`
+	// error is set when the detection failed.
+	Error         *Error `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Result) Reset() {
+	*x = Result{}
+	mi := &file_gomagic_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Result) ProtoMessage() {}
+
+func (x *Result) ProtoReflect() protoreflect.Message {
+	mi := &file_gomagic_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Result.ProtoReflect.Descriptor instead.
+func (*Result) Descriptor() ([]byte, []int) {
+	return file_gomagic_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Result) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Result) GetMime() string {
+	if x != nil {
+		return x.Mime
+	}
+	return ""
+}
+
+func (x *Result) GetEncoding() string {
+	if x != nil {
+		return x.Encoding
+	}
+	return ""
+}
+
+func (x *Result) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Result) GetExtensions() []string {
+	if x != nil {
+		return x.Extensions
+	}
+	return nil
+}
+
+func (x *Result) GetMatchedDb() string {
+	if x != nil {
+		return x.MatchedDb
+	}
+	return ""
+}
+
+func (x *Result) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Result) GetError() *Error {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+type Error struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Message string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	// op is the failing libmagic function, such as "magic_file", if any.
+	Op            string `protobuf:"bytes,2,opt,name=op,proto3" json:"op,omitempty"`
+	Errno         int32  `protobuf:"varint,3,opt,name=errno,proto3" json:"errno,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	mi := &file_gomagic_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_gomagic_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_gomagic_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Error) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Error) GetOp() string {
+	if x != nil {
+		return x.Op
+	}
+	return ""
+}
+
+func (x *Error) GetErrno() int32 {
+	if x != nil {
+		return x.Errno
+	}
+	return 0
+}
+
+var File_gomagic_proto protoreflect.FileDescriptor
+
+const file_gomagic_proto_rawDesc = "" +
+	"\n" +
+	"\rgomagic.proto\x12\n" +
+	"gomagic.v1\"C\n" +
+	"\x13DetectBufferRequest\x12\x18\n" +
+	"\acontent\x18\x01 \x01(\fR\acontent\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"/\n" +
+	"\x05Chunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"*\n" +
+	"\x12DetectPathsRequest\x12\x14\n" +
+	"\x05paths\x18\x01 \x03(\tR\x05paths\"\xea\x01\n" +
+	"\x06Result\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04mime\x18\x02 \x01(\tR\x04mime\x12\x1a\n" +
+	"\bencoding\x18\x03 \x01(\tR\bencoding\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\x12\x1e\n" +
+	"\n" +
+	"extensions\x18\x05 \x03(\tR\n" +
+	"extensions\x12\x1d\n" +
+	"\n" +
+	"matched_db\x18\x06 \x01(\tR\tmatchedDb\x12\x12\n" +
+	"\x04kind\x18\a \x01(\tR\x04kind\x12'\n" +
+	"\x05error\x18\b \x01(\v2\x11.gomagic.v1.ErrorR\x05error\"G\n" +
+	"\x05Error\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x0e\n" +
+	"\x02op\x18\x02 \x01(\tR\x02op\x12\x14\n" +
+	"\x05errno\x18\x03 \x01(\x05R\x05errno2\xcd\x01\n" +
+	"\bDetector\x12C\n" +
+	"\fDetectBuffer\x12\x1f.gomagic.v1.DetectBufferRequest\x1a\x12.gomagic.v1.Result\x127\n" +
+	"\fDetectUpload\x12\x11.gomagic.v1.Chunk\x1a\x12.gomagic.v1.Result(\x01\x12C\n" +
+	"\vDetectPaths\x12\x1e.gomagic.v1.DetectPathsRequest\x1a\x12.gomagic.v1.Result0\x01B'Z%github.com/nitrocao/gomagic/gomagicpbb\x06proto3"
+
+var (
+	file_gomagic_proto_rawDescOnce sync.Once
+	file_gomagic_proto_rawDescData []byte
+)
+
+func file_gomagic_proto_rawDescGZIP() []byte {
+	file_gomagic_proto_rawDescOnce.Do(func() {
+		file_gomagic_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_gomagic_proto_rawDesc), len(file_gomagic_proto_rawDesc)))
+	})
+	return file_gomagic_proto_rawDescData
+}
+
+var file_gomagic_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_gomagic_proto_goTypes = []any{
+	(*DetectBufferRequest)(nil), // 0: gomagic.v1.DetectBufferRequest
+	(*Chunk)(nil),               // 1: gomagic.v1.Chunk
+	(*DetectPathsRequest)(nil),  // 2: gomagic.v1.DetectPathsRequest
+	(*Result)(nil),              // 3: gomagic.v1.Result
+	(*Error)(nil),               // 4: gomagic.v1.Error
+}
+var file_gomagic_proto_depIdxs = []int32{
+	4, // 0: gomagic.v1.Result.error:type_name -> gomagic.v1.Error
+	0, // 1: gomagic.v1.Detector.DetectBuffer:input_type -> gomagic.v1.DetectBufferRequest
+	1, // 2: gomagic.v1.Detector.DetectUpload:input_type -> gomagic.v1.Chunk
+	2, // 3: gomagic.v1.Detector.DetectPaths:input_type -> gomagic.v1.DetectPathsRequest
+	3, // 4: gomagic.v1.Detector.DetectBuffer:output_type -> gomagic.v1.Result
+	3, // 5: gomagic.v1.Detector.DetectUpload:output_type -> gomagic.v1.Result
+	3, // 6: gomagic.v1.Detector.DetectPaths:output_type -> gomagic.v1.Result
+	4, // [4:7] is the sub-list for method output_type
+	1, // [1:4] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_gomagic_proto_init() }
+func file_gomagic_proto_init() {
+	if File_gomagic_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_gomagic_proto_rawDesc), len(file_gomagic_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_gomagic_proto_goTypes,
+		DependencyIndexes: file_gomagic_proto_depIdxs,
+		MessageInfos:      file_gomagic_proto_msgTypes,
+	}.Build()
+	File_gomagic_proto = out.File
+	file_gomagic_proto_goTypes = nil
+	file_gomagic_proto_depIdxs = nil
+}
@@ -0,0 +1,210 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.1
+// - protoc             (unknown)
+// source: gomagic.proto
+
+package gomagicpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Detector_DetectBuffer_FullMethodName = "/gomagic.v1.Detector/DetectBuffer"
+	Detector_DetectUpload_FullMethodName = "/gomagic.v1.Detector/DetectUpload"
+	Detector_DetectPaths_FullMethodName  = "/gomagic.v1.Detector/DetectPaths"
+)
+
+// DetectorClient is the client API for Detector service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Detector detects the type of files and content with libmagic.
+type DetectorClient interface {
+	// DetectBuffer detects content.
+	DetectBuffer(ctx context.Context, in *DetectBufferRequest, opts ...grpc.CallOption) (*Result, error)
+	// DetectUpload detects content uploaded in chunks. Only the first
+	// DefaultHeadSize bytes (1 MiB) are examined; the rest is discarded.
+	DetectUpload(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Chunk, Result], error)
+	// DetectPaths detects files local to the server and streams one Result
+	// per path, in order. It fails with PERMISSION_DENIED unless the server
+	// enables path detection.
+	DetectPaths(ctx context.Context, in *DetectPathsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Result], error)
+}
+
+type detectorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDetectorClient(cc grpc.ClientConnInterface) DetectorClient {
+	return &detectorClient{cc}
+}
+
+func (c *detectorClient) DetectBuffer(ctx context.Context, in *DetectBufferRequest, opts ...grpc.CallOption) (*Result, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Result)
+	err := c.cc.Invoke(ctx, Detector_DetectBuffer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *detectorClient) DetectUpload(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Chunk, Result], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Detector_ServiceDesc.Streams[0], Detector_DetectUpload_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Chunk, Result]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Detector_DetectUploadClient = grpc.ClientStreamingClient[Chunk, Result]
+
+func (c *detectorClient) DetectPaths(ctx context.Context, in *DetectPathsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Result], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Detector_ServiceDesc.Streams[1], Detector_DetectPaths_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DetectPathsRequest, Result]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Detector_DetectPathsClient = grpc.ServerStreamingClient[Result]
+
+// DetectorServer is the server API for Detector service.
+// All implementations must embed UnimplementedDetectorServer
+// for forward compatibility.
+//
+// Detector detects the type of files and content with libmagic.
+type DetectorServer interface {
+	// DetectBuffer detects content.
+	DetectBuffer(context.Context, *DetectBufferRequest) (*Result, error)
+	// DetectUpload detects content uploaded in chunks. Only the first
+	// DefaultHeadSize bytes (1 MiB) are examined; the rest is discarded.
+	DetectUpload(grpc.ClientStreamingServer[Chunk, Result]) error
+	// DetectPaths detects files local to the server and streams one Result
+	// per path, in order. It fails with PERMISSION_DENIED unless the server
+	// enables path detection.
+	DetectPaths(*DetectPathsRequest, grpc.ServerStreamingServer[Result]) error
+	mustEmbedUnimplementedDetectorServer()
+}
+
+// UnimplementedDetectorServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDetectorServer struct{}
+
+func (UnimplementedDetectorServer) DetectBuffer(context.Context, *DetectBufferRequest) (*Result, error) {
+	return nil, status.Error(codes.Unimplemented, "method DetectBuffer not implemented")
+}
+func (UnimplementedDetectorServer) DetectUpload(grpc.ClientStreamingServer[Chunk, Result]) error {
+	return status.Error(codes.Unimplemented, "method DetectUpload not implemented")
+}
+func (UnimplementedDetectorServer) DetectPaths(*DetectPathsRequest, grpc.ServerStreamingServer[Result]) error {
+	return status.Error(codes.Unimplemented, "method DetectPaths not implemented")
+}
+func (UnimplementedDetectorServer) mustEmbedUnimplementedDetectorServer() {}
+func (UnimplementedDetectorServer) testEmbeddedByValue()                  {}
+
+// UnsafeDetectorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DetectorServer will
+// result in compilation errors.
+type UnsafeDetectorServer interface {
+	mustEmbedUnimplementedDetectorServer()
+}
+
+func RegisterDetectorServer(s grpc.ServiceRegistrar, srv DetectorServer) {
+	// If the following call panics, it indicates UnimplementedDetectorServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Detector_ServiceDesc, srv)
+}
+
+func _Detector_DetectBuffer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DetectBufferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DetectorServer).DetectBuffer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Detector_DetectBuffer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DetectorServer).DetectBuffer(ctx, req.(*DetectBufferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Detector_DetectUpload_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DetectorServer).DetectUpload(&grpc.GenericServerStream[Chunk, Result]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Detector_DetectUploadServer = grpc.ClientStreamingServer[Chunk, Result]
+
+func _Detector_DetectPaths_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DetectPathsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DetectorServer).DetectPaths(m, &grpc.GenericServerStream[DetectPathsRequest, Result]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Detector_DetectPathsServer = grpc.ServerStreamingServer[Result]
+
+// Detector_ServiceDesc is the grpc.ServiceDesc for Detector service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Detector_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gomagic.v1.Detector",
+	HandlerType: (*DetectorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "DetectBuffer",
+			Handler:    _Detector_DetectBuffer_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "DetectUpload",
+			Handler:       _Detector_DetectUpload_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "DetectPaths",
+			Handler:       _Detector_DetectPaths_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "gomagic.proto",
+}
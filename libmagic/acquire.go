@@ -0,0 +1,50 @@
+package libmagic
+
+import (
+	"context"
+	"errors"
+)
+
+// Acquire returns a transient handle loaded with the databases of the
+// MagicDetector, for callers that want to use the Magic API directly. Handles
+// come from a sync.Pool and are cloned on demand, so bursts can exceed the
+// size of the MagicDetector; spare handles the runtime drops from the pool
+// are closed automatically, so the extra capacity does not grow memory
+// permanently. The handle must be given back with Release and not used
+// afterwards.
+func (d *MagicDetector) Acquire(ctx context.Context) (*Magic, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for {
+		set, err := d.currentSet()
+		if err != nil {
+			return nil, err
+		}
+		if m, ok := set.spare.Get().(*Magic); ok {
+			return m, nil
+		}
+		m, err := set.template.Clone()
+		if errors.Is(err, ErrClosed) {
+			// Retired by Reload meanwhile; retry on the current set.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		m.spareOf = set
+		return m, nil
+	}
+}
+
+// Release gives back a handle obtained from Acquire. Flags changed by the
+// caller are reset. Handles of databases replaced by Reload in the meantime
+// are closed instead of being reused.
+func (d *MagicDetector) Release(m *Magic) {
+	set := m.spareOf
+	if set == nil || set != d.set.Load() || m.MagicSetFlags(d.flags) != nil {
+		m.Close()
+		return
+	}
+	set.spare.Put(m)
+}
@@ -0,0 +1,50 @@
+package libmagic
+
+import (
+	"context"
+	"sync"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectorAcquire() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType|MagicError), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m, err := detector.Acquire(context.Background())
+			require.NoError(t, err)
+			defer detector.Release(m)
+			require.NoError(t, m.MagicSetFlags(MagicNone))
+			result, err := m.MagicBuffer(pngHeader)
+			assert.NoError(t, err)
+			assert.Contains(t, result, "PNG image data")
+		}()
+	}
+	wg.Wait()
+
+	m, err := detector.Acquire(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, MagicMimeType|MagicError, m.MagicGetFlags())
+	require.NoError(t, detector.Reload([]string{"../testdata/lua"}))
+	detector.Release(m)
+	_, err = m.MagicBuffer(pngHeader)
+	assert.ErrorIs(t, err, ErrClosed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = detector.Acquire(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	detector.Close()
+	_, err = detector.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrClosed)
+}
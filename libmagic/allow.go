@@ -0,0 +1,61 @@
+package libmagic
+
+import (
+	"slices"
+	"strings"
+)
+
+// IsOneOf detects the MIME type of content and reports whether it is one of
+// allowlist, the common check on uploads. Both sides are compared in their
+// canonical form, see NormalizeMIME, so "image/jpg" in allowlist allows
+// "image/jpeg" content; an entry such as "image/*" allows a whole type.
+// The MIME type is requested for this call whatever the flags of the
+// MagicDetector. The Result is returned even when content is not allowed.
+//
+// The decision is made on the MIME type of the returned Result, which
+// libmagic reports and then, in this order, WithZipRefinement,
+// WithLanguageDetection, WithMIMENormalization and the hooks of
+// WithResultHooks may change; matchers of WithMatchersBefore and
+// WithMatchersAfter are not run. Refinements and hooks of the MagicDetector
+// can thus turn an allowed type into a denied one or the other way round:
+// use a MagicDetector without them to decide on what libmagic reports alone.
+func (d *MagicDetector) IsOneOf(content []byte, allowlist []string) (bool, Result, error) {
+	flags := d.flags&^(outputFlags|MagicContinue) | MagicMimeType
+	raw, err := d.DetectBufferWithFlags(content, flags)
+	r := d.finishContent(newResult("", raw, flags, err), content)
+	if err != nil {
+		return false, r, err
+	}
+	return allowed(r.MIME, allowlist), r, nil
+}
+
+// IsFileOneOf is like IsOneOf but detects the file at path.
+func (d *MagicDetector) IsFileOneOf(path string, allowlist []string) (bool, Result, error) {
+	flags := d.flags&^(outputFlags|MagicContinue) | MagicMimeType
+	raw, err := d.DetectFileWithFlags(path, flags)
+	r := d.finish(newResult(path, raw, flags, err))
+	if err != nil {
+		return false, r, err
+	}
+	return allowed(r.MIME, allowlist), r, nil
+}
+
+// allowed reports whether mime matches an entry of allowlist, see IsOneOf.
+func allowed(mime string, allowlist []string) bool {
+	mime = canonicalMIME(mime)
+	typ, _, _ := strings.Cut(mime, "/")
+	return slices.ContainsFunc(allowlist, func(entry string) bool {
+		entry = canonicalMIME(entry)
+		if wildcard, ok := strings.CutSuffix(entry, "/*"); ok {
+			return wildcard == typ
+		}
+		return entry == mime
+	})
+}
+
+// canonicalMIME returns the lower case canonical form of mime without
+// parameters.
+func canonicalMIME(mime string) string {
+	mime, _, _ = strings.Cut(mime, ";")
+	return NormalizeMIME(strings.ToLower(strings.TrimSpace(mime)))
+}
@@ -0,0 +1,60 @@
+package libmagic
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestAllowed() {
+	t := s.T()
+	assert.True(t, allowed("image/png", []string{"image/png", "image/jpeg"}))
+	assert.True(t, allowed("image/jpeg", []string{"image/jpg"}))
+	assert.True(t, allowed("application/x-gzip", []string{"application/gzip"}))
+	assert.True(t, allowed("text/plain; charset=us-ascii", []string{"Text/Plain"}))
+	assert.True(t, allowed("image/webp", []string{"image/*"}))
+	assert.False(t, allowed("text/html", []string{"image/*", "application/pdf"}))
+	assert.False(t, allowed("image/png", nil))
+}
+
+func (s *MagicTestSuite) TestDetectorIsOneOf() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	ok, result, err := detector.IsOneOf(pngHeader, []string{"image/png", "image/jpeg"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "image/png", result.MIME)
+
+	ok, result, err = detector.IsOneOf([]byte("<html><body>hi</body></html>\n"), []string{"image/png", "image/jpeg"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "text/html", result.MIME)
+
+	path := filepath.Join(t.TempDir(), "upload")
+	require.NoError(t, os.WriteFile(path, pngHeader, 0o600))
+	ok, result, err = detector.IsFileOneOf(path, []string{"image/*"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, path, result.Path)
+}
+
+func (s *MagicTestSuite) TestDetectorIsOneOfHooks() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithDatabases("../testdata/magic.mgc"),
+		WithResultHooks(func(r Result) Result {
+			r.MIME = "application/octet-stream"
+			return r
+		}))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	ok, result, err := detector.IsOneOf(pngHeader, []string{"image/png"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "application/octet-stream", result.MIME)
+}
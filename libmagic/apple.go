@@ -0,0 +1,84 @@
+package libmagic
+
+import (
+	"fmt"
+)
+
+// unknownAppleCode is what libmagic prints with MagicApple for each half of
+// the code when no rule provides one.
+const unknownAppleCode = "UNKN"
+
+// AppleCode is a classic Mac OS creator and type code pair, printed by
+// libmagic with MagicApple as 8 characters, e.g. "8BIMGIFf".
+type AppleCode struct {
+	// Creator identifies the application owning the file, e.g. "8BIM".
+	Creator string
+	// Type identifies the kind of file, e.g. "GIFf".
+	Type string
+}
+
+// appleCreators and appleTypes name well-known codes.
+var (
+	appleCreators = map[string]string{
+		"8BIM": "Adobe Photoshop",
+		"CARO": "Adobe Acrobat",
+		"MOSS": "Netscape Navigator",
+		"MSWD": "Microsoft Word",
+		"R*ch": "BBEdit",
+		"SIT!": "StuffIt",
+		"TVOD": "QuickTime Player",
+		"XCEL": "Microsoft Excel",
+		"ogle": "PictureViewer",
+		"prvw": "Preview",
+		"ttxt": "SimpleText",
+	}
+	appleTypes = map[string]string{
+		"8BPS": "Photoshop document",
+		"APPL": "application",
+		"GIFf": "GIF image",
+		"JPEG": "JPEG image",
+		"MooV": "QuickTime movie",
+		"PDF ": "PDF document",
+		"PNGf": "PNG image",
+		"SIT!": "StuffIt archive",
+		"TEXT": "text",
+		"TIFF": "TIFF image",
+		"W8BN": "Word document",
+		"XLS8": "Excel workbook",
+		"ZIP ": "ZIP archive",
+	}
+)
+
+// ParseAppleCode parses the output of libmagic with MagicApple. libmagic
+// drops the trailing spaces of the type, printing "????RTF" for the type
+// "RTF ", so shorter codes are padded back to 8 characters.
+func ParseAppleCode(s string) (AppleCode, error) {
+	if len(s) <= 4 || len(s) > 8 {
+		return AppleCode{}, fmt.Errorf("invalid Apple creator/type code %q", s)
+	}
+	s = fmt.Sprintf("%-8s", s)
+	return AppleCode{Creator: s[:4], Type: s[4:]}, nil
+}
+
+// String returns the code as printed by libmagic.
+func (c AppleCode) String() string {
+	return c.Creator + c.Type
+}
+
+// Known reports whether libmagic found a creator or a type. The zero
+// AppleCode is not known.
+func (c AppleCode) Known() bool {
+	return c != AppleCode{} && (c.Creator != unknownAppleCode || c.Type != unknownAppleCode)
+}
+
+// CreatorName returns the name of the creator application, or "" if the
+// creator is not well-known.
+func (c AppleCode) CreatorName() string {
+	return appleCreators[c.Creator]
+}
+
+// TypeName returns the name of the file type, or "" if the type is not
+// well-known.
+func (c AppleCode) TypeName() string {
+	return appleTypes[c.Type]
+}
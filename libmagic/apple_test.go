@@ -0,0 +1,56 @@
+package libmagic
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestParseAppleCode() {
+	t := s.T()
+	code, err := ParseAppleCode("8BIMGIFf")
+	require.NoError(t, err)
+	assert.Equal(t, AppleCode{Creator: "8BIM", Type: "GIFf"}, code)
+	assert.True(t, code.Known())
+	assert.Equal(t, "Adobe Photoshop", code.CreatorName())
+	assert.Equal(t, "GIF image", code.TypeName())
+	assert.Equal(t, "8BIMGIFf", code.String())
+
+	code, err = ParseAppleCode("UNKNUNKN")
+	require.NoError(t, err)
+	assert.False(t, code.Known())
+	assert.Empty(t, code.CreatorName())
+	assert.Empty(t, code.TypeName())
+
+	// The trailing space of the type is dropped by libmagic.
+	code, err = ParseAppleCode("CAROPDF")
+	require.NoError(t, err)
+	assert.Equal(t, AppleCode{Creator: "CARO", Type: "PDF "}, code)
+	assert.Equal(t, "PDF document", code.TypeName())
+
+	_, err = ParseAppleCode("GIFf")
+	assert.Error(t, err)
+	_, err = ParseAppleCode("8BIMGIFf ")
+	assert.Error(t, err)
+	assert.False(t, AppleCode{}.Known())
+}
+
+func (s *MagicTestSuite) TestDetectorDetectApple() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicApple),
+		WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	result, err := detector.DetectBufferResult([]byte("GIF89a\x01\x00\x01\x00"))
+	require.NoError(t, err)
+	assert.Equal(t, AppleCode{Creator: "8BIM", Type: "GIFf"}, result.Apple)
+
+	result, err = detector.DetectBufferResult([]byte("{\\rtf1\\ansi hello}"))
+	require.NoError(t, err)
+	assert.Equal(t, AppleCode{Creator: "????", Type: "RTF "}, result.Apple)
+	assert.True(t, result.Apple.Known())
+
+	result, err = detector.DetectBufferResult([]byte("hello world\n"))
+	require.NoError(t, err)
+	assert.False(t, result.Apple.Known())
+}
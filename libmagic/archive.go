@@ -0,0 +1,233 @@
+package libmagic
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrNotArchive is returned by DetectArchiveMembers for content that is not
+// a tar or zip archive.
+var ErrNotArchive = errors.New("not a tar or zip archive")
+
+// ErrTooManyMembers and ErrArchiveTooLarge are returned by
+// DetectArchiveMembers when an archive goes beyond WithMaxMembers or
+// WithMaxArchiveSize.
+var (
+	ErrTooManyMembers  = errors.New("too many archive members")
+	ErrArchiveTooLarge = errors.New("archive decompresses to too many bytes")
+)
+
+// DefaultMaxMembers is the number of members DetectArchiveMembers detects by
+// default.
+const DefaultMaxMembers = 10000
+
+// DefaultMaxArchiveSize is the number of bytes DetectArchiveMembers
+// decompresses by default.
+const DefaultMaxArchiveSize = 1 << 30
+
+// ArchiveOption configures DetectArchiveMembers.
+type ArchiveOption func(*archiveOptions)
+
+type archiveOptions struct {
+	maxMembers int
+	maxSize    int64
+}
+
+// WithMaxMembers sets how many members DetectArchiveMembers detects at most,
+// DefaultMaxMembers by default.
+func WithMaxMembers(n int) ArchiveOption {
+	return func(o *archiveOptions) {
+		o.maxMembers = n
+	}
+}
+
+// WithMaxArchiveSize sets how many bytes of a compressed tar archive
+// DetectArchiveMembers decompresses at most, DefaultMaxArchiveSize by
+// default. Tar members are stored one after the other, so reaching a member
+// means decompressing all those before it; the limit keeps compression
+// bombs from using unbounded time.
+func WithMaxArchiveSize(size int64) ArchiveOption {
+	return func(o *archiveOptions) {
+		o.maxSize = size
+	}
+}
+
+// tarMIME is the MIME type libmagic prints for tar archives.
+const tarMIME = "application/x-tar"
+
+// zipSignature starts the local file headers of zip archives.
+var zipSignature = []byte("PK\x03\x04")
+
+// archiveHeadSize is how much of an archive is read to tell its format.
+const archiveHeadSize = 64 << 10
+
+// DetectArchiveMembers detects the content of each regular file member of
+// the tar or zip archive at path, from its first DefaultHeadSize bytes, and
+// returns their Result in archive order, with Path set to the member name.
+// Tar archives may be gzip, bzip2, xz or zstd compressed, up to
+// DefaultMaxLayers times. Failures of individual members are reported in
+// their Result; an error is only returned if the archive itself cannot be
+// read or goes beyond the limits, see WithMaxMembers and WithMaxArchiveSize,
+// with the Results of the members before.
+func (d *MagicDetector) DetectArchiveMembers(path string, opts ...ArchiveOption) ([]Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return d.detectArchive(f, f, info.Size(), newArchiveOptions(opts))
+}
+
+// DetectArchiveReader is like DetectArchiveMembers but reads the archive from
+// r. Zip archives are only supported if r also implements io.ReaderAt and
+// has a Size method, like bytes.Reader and io.SectionReader, as their
+// central directory is at the end.
+func (d *MagicDetector) DetectArchiveReader(r io.Reader, opts ...ArchiveOption) ([]Result, error) {
+	o := newArchiveOptions(opts)
+	if ra, ok := r.(interface {
+		io.ReaderAt
+		Size() int64
+	}); ok {
+		return d.detectArchive(r, ra, ra.Size(), o)
+	}
+	return d.detectArchive(r, nil, 0, o)
+}
+
+func newArchiveOptions(opts []ArchiveOption) archiveOptions {
+	o := archiveOptions{maxMembers: DefaultMaxMembers, maxSize: DefaultMaxArchiveSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// detectArchive detects the members of the archive read from r, or from ra
+// of size bytes for zip archives if ra is not nil.
+func (d *MagicDetector) detectArchive(r io.Reader, ra io.ReaderAt, size int64, o archiveOptions) ([]Result, error) {
+	flags := d.flags&^(outputFlags|MagicCompress) | MagicMimeType
+	for layer := 0; ; layer++ {
+		br := bufio.NewReaderSize(r, archiveHeadSize)
+		head, err := br.Peek(archiveHeadSize)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if bytes.HasPrefix(head, zipSignature) {
+			if ra == nil || layer > 0 {
+				return nil, fmt.Errorf("zip archive without random access: %w", ErrNotArchive)
+			}
+			zr, err := zip.NewReader(ra, size)
+			if err != nil {
+				return nil, err
+			}
+			return d.detectZipMembers(zr, o.maxMembers)
+		}
+		mime, err := d.DetectBufferWithFlags(head, flags)
+		if err != nil {
+			return nil, err
+		}
+		if mime == tarMIME {
+			var tr io.Reader = br
+			if layer > 0 {
+				tr = &sizeLimitReader{r: br, n: o.maxSize}
+			}
+			return d.detectTarMembers(tar.NewReader(tr), o.maxMembers)
+		}
+		decompress, ok := decompressor(mime)
+		if !ok || layer >= DefaultMaxLayers {
+			return nil, ErrNotArchive
+		}
+		rc, err := decompress(br, DefaultMaxLayerMemory)
+		if err != nil {
+			return nil, fmt.Errorf("decompressing %s: %w", mime, err)
+		}
+		defer rc.Close()
+		r = &layerReader{r: rc, mime: mime}
+	}
+}
+
+func (d *MagicDetector) detectTarMembers(tr *tar.Reader, maxMembers int) ([]Result, error) {
+	var results []Result
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return results, nil
+		}
+		if err != nil {
+			return results, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if len(results) == maxMembers {
+			return results, ErrTooManyMembers
+		}
+		results = append(results, d.detectMember(hdr.Name, tr))
+	}
+}
+
+func (d *MagicDetector) detectZipMembers(zr *zip.Reader, maxMembers int) ([]Result, error) {
+	var results []Result
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		if len(results) == maxMembers {
+			return results, ErrTooManyMembers
+		}
+		rc, err := f.Open()
+		if err != nil {
+			results = append(results, d.result(f.Name, "", err))
+			continue
+		}
+		results = append(results, d.detectMember(f.Name, rc))
+		rc.Close()
+	}
+	return results, nil
+}
+
+// sizeLimitReader reads at most n bytes from r, failing with
+// ErrArchiveTooLarge if r has more.
+type sizeLimitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *sizeLimitReader) Read(p []byte) (int, error) {
+	if r.n <= 0 {
+		// Tell the end of r from more of it.
+		var b [1]byte
+		if n, err := r.r.Read(b[:]); n == 0 {
+			return 0, err
+		}
+		return 0, ErrArchiveTooLarge
+	}
+	if int64(len(p)) > r.n {
+		p = p[:r.n]
+	}
+	n, err := r.r.Read(p)
+	r.n -= int64(n)
+	return n, err
+}
+
+// detectMember detects the member name from the head of its content r.
+func (d *MagicDetector) detectMember(name string, r io.Reader) Result {
+	p := headBuffers.Get().(*[]byte)
+	defer headBuffers.Put(p)
+	n, err := io.ReadFull(r, *p)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return d.result(name, "", err)
+	}
+	head := (*p)[:n]
+	raw, err := d.DetectBuffer(head)
+	return d.finishContent(newResult(name, raw, d.flags, err), head)
+}
@@ -0,0 +1,135 @@
+package libmagic
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectorDetectArchiveMembers() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType),
+		WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0o755}))
+	for _, member := range []struct {
+		name    string
+		content []byte
+	}{
+		{"dir/image.png", pngHeader},
+		{"notes.txt", []byte("hello world\n")},
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: member.name, Mode: 0o600, Size: int64(len(member.content))}))
+		_, err := tw.Write(member.content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "notes.txt"}))
+	require.NoError(t, tw.Close())
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    map[string]string
+	}{
+		{
+			name:    "tar",
+			content: tarBuf.Bytes(),
+			want:    map[string]string{"dir/image.png": "image/png", "notes.txt": "text/plain"},
+		},
+		{
+			name:    "tar.gz",
+			content: gzipContent(t, tarBuf.Bytes()),
+			want:    map[string]string{"dir/image.png": "image/png", "notes.txt": "text/plain"},
+		},
+		{
+			name:    "zip",
+			content: zipContent(t, "a.txt", "b.txt"),
+			want:    map[string]string{"a.txt": "text/plain", "b.txt": "text/plain"},
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			path := filepath.Join(t.TempDir(), "archive")
+			require.NoError(t, os.WriteFile(path, tt.content, 0o600))
+			results, err := detector.DetectArchiveMembers(path)
+			require.NoError(t, err)
+			got := map[string]string{}
+			for _, r := range results {
+				assert.NoError(t, r.Err)
+				got[r.Path] = r.MIME
+			}
+			assert.Equal(t, tt.want, got)
+
+			results, err = detector.DetectArchiveReader(bytes.NewReader(tt.content))
+			require.NoError(t, err)
+			assert.Len(t, results, len(tt.want))
+		})
+	}
+
+	zipped := zipContent(t, "a.txt")
+	_, err = detector.DetectArchiveReader(io.MultiReader(bytes.NewReader(zipped)))
+	assert.ErrorIs(t, err, ErrNotArchive)
+	_, err = detector.DetectArchiveReader(bytes.NewReader(pngHeader))
+	assert.ErrorIs(t, err, ErrNotArchive)
+	_, err = detector.DetectArchiveMembers(filepath.Join(t.TempDir(), "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func (s *MagicTestSuite) TestDetectorDetectArchiveMembersLimits() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType),
+		WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	for i := range 10 {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: fmt.Sprint(i), Mode: 0o600, Size: int64(len(pngHeader))}))
+		_, err := tw.Write(pngHeader)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	results, err := detector.DetectArchiveReader(bytes.NewReader(gzipContent(t, tarBuf.Bytes())), WithMaxMembers(4))
+	assert.ErrorIs(t, err, ErrTooManyMembers)
+	assert.Len(t, results, 4)
+	results, err = detector.DetectArchiveReader(bytes.NewReader(zipContent(t, "a", "b", "c")), WithMaxMembers(2))
+	assert.ErrorIs(t, err, ErrTooManyMembers)
+	assert.Len(t, results, 2)
+
+	// Reaching the last member means decompressing the large one before.
+	var bombBuf bytes.Buffer
+	tw = tar.NewWriter(&bombBuf)
+	for _, member := range [][]byte{make([]byte, 8<<20), pngHeader} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "member", Mode: 0o600, Size: int64(len(member))}))
+		_, err := tw.Write(member)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	bomb := bombBuf.Bytes()
+	results, err = detector.DetectArchiveReader(bytes.NewReader(gzipContent(t, bomb)), WithMaxArchiveSize(1<<20))
+	assert.ErrorIs(t, err, ErrArchiveTooLarge)
+	assert.Len(t, results, 1)
+	results, err = detector.DetectArchiveReader(bytes.NewReader(gzipContent(t, bomb)))
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	// The limit is on decompressed bytes; plain tar archives are read as is.
+	results, err = detector.DetectArchiveReader(bytes.NewReader(bomb), WithMaxArchiveSize(1<<20))
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+	results, err = detector.DetectArchiveReader(bytes.NewReader(gzipContent(t, tarBuf.Bytes())),
+		WithMaxArchiveSize(int64(tarBuf.Len())))
+	require.NoError(t, err)
+	assert.Len(t, results, 10)
+}
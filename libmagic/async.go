@@ -0,0 +1,26 @@
+package libmagic
+
+// DetectFileAsync starts detecting the file at path and returns a channel
+// that receives the Result once and is then closed. The channel is buffered,
+// so abandoning it does not leak the detecting goroutine.
+func (d *MagicDetector) DetectFileAsync(path string) <-chan Result {
+	results := make(chan Result, 1)
+	go func() {
+		defer close(results)
+		raw, err := d.DetectFile(path)
+		results <- d.result(path, raw, err)
+	}()
+	return results
+}
+
+// DetectBufferAsync is like DetectFileAsync but detects content, which must
+// not be modified until the Result has been received.
+func (d *MagicDetector) DetectBufferAsync(content []byte) <-chan Result {
+	results := make(chan Result, 1)
+	go func() {
+		defer close(results)
+		raw, err := d.DetectBuffer(content)
+		results <- d.finishContent(newResult("", raw, d.flags, err), content)
+	}()
+	return results
+}
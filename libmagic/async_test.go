@@ -0,0 +1,28 @@
+package libmagic
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectAsync() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(2), WithFlags(MagicMimeType|MagicError), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	buffer := detector.DetectBufferAsync(pngHeader)
+	file := detector.DetectFileAsync("../testdata/nonexist.mgc")
+
+	result, ok := <-buffer
+	require.True(t, ok)
+	assert.NoError(t, result.Err)
+	assert.Equal(t, "image/png", result.Raw)
+	_, ok = <-buffer
+	assert.False(t, ok)
+
+	result = <-file
+	assert.Equal(t, "../testdata/nonexist.mgc", result.Path)
+	assert.Error(t, result.Err)
+}
@@ -0,0 +1,98 @@
+package libmagic
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchOption configures batch detections such as MagicDetector.DetectFiles.
+type BatchOption func(*batchOptions)
+
+type batchOptions struct {
+	concurrency int
+	iouring     bool
+	headSize    int
+	// dedup and dedupHead are set by WithDeduplication, dedupCache is the
+	// cache of the batch.
+	dedup      bool
+	dedupHead  int
+	dedupCache *dedupCache
+}
+
+// WithConcurrency sets how many detections of a batch run at the same time.
+// It defaults to the number of handles of the MagicDetector.
+func WithConcurrency(n int) BatchOption {
+	return func(o *batchOptions) {
+		o.concurrency = n
+	}
+}
+
+// WithIOUring makes DetectFiles read files with io_uring on Linux, opening
+// and reading a whole batch of files with a few system calls, and detect the
+// first headSize bytes of each as a buffer. A headSize of zero or less means
+// DefaultHeadSize; two batches of 32 such buffers are allocated. Files that
+// are not non-empty regular files, and systems without io_uring, are handled
+// as usual.
+func WithIOUring(headSize int) BatchOption {
+	return func(o *batchOptions) {
+		o.iouring = true
+		o.headSize = headSize
+	}
+}
+
+func (d *MagicDetector) batchOptions(opts []BatchOption) batchOptions {
+	o := batchOptions{concurrency: d.size}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.concurrency <= 0 {
+		o.concurrency = d.size
+	}
+	if o.headSize <= 0 {
+		o.headSize = DefaultHeadSize
+	}
+	if o.dedup && o.dedupCache == nil {
+		o.dedupCache = newDedupCache(o.dedupHead)
+	}
+	return o
+}
+
+// DetectFiles detects all paths concurrently and returns one Result per path,
+// in the same order. Failures of individual files are reported in their
+// Result; the returned error is only set when the MagicDetector is closed.
+func (d *MagicDetector) DetectFiles(paths []string, opts ...BatchOption) ([]Result, error) {
+	return d.DetectFilesCtx(context.Background(), paths, opts...)
+}
+
+// DetectFilesCtx is like DetectFiles but stops once ctx is done. Paths that
+// were not detected by then get ctx.Err() in their Result.
+func (d *MagicDetector) DetectFilesCtx(ctx context.Context, paths []string, opts ...BatchOption) ([]Result, error) {
+	if _, err := d.currentSet(); err != nil {
+		return nil, err
+	}
+	o := d.batchOptions(opts)
+	if o.iouring && o.dedupCache == nil {
+		if results, err := d.detectFilesURing(ctx, paths, o); err == nil {
+			return results, nil
+		}
+	}
+	results := make([]Result, len(paths))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < o.concurrency && i < len(paths); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				raw, err := d.detectFile(ctx, paths[i], o)
+				results[i] = d.result(paths[i], raw, err)
+			}
+		}()
+	}
+	for i := range paths {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results, nil
+}
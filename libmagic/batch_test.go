@@ -0,0 +1,42 @@
+package libmagic
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectFiles() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(2), WithFlags(MagicMimeType|MagicError), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a.png", "b.png", "c.png"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, pngHeader, 0600))
+		paths = append(paths, path)
+	}
+	paths = append(paths, filepath.Join(dir, "nonexist"))
+
+	for _, concurrency := range []int{0, 1, 8} {
+		results, err := detector.DetectFiles(paths, WithConcurrency(concurrency))
+		require.NoError(t, err)
+		require.Len(t, results, len(paths))
+		for i, result := range results[:3] {
+			assert.Equal(t, paths[i], result.Path)
+			assert.Equal(t, "image/png", result.Raw)
+			assert.NoError(t, result.Err)
+		}
+		assert.Equal(t, paths[3], results[3].Path)
+		assert.Error(t, results[3].Err)
+	}
+
+	detector.Close()
+	_, err = detector.DetectFiles(paths)
+	assert.ErrorIs(t, err, ErrClosed)
+}
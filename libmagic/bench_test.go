@@ -0,0 +1,78 @@
+package libmagic
+
+import (
+	"testing"
+)
+
+// The parallel benchmarks show how detections scale with cores, e.g.
+//
+//	go test -run '^$' -bench Parallel -cpu 1,4,16,32 ./libmagic
+
+func BenchmarkMagicBufferParallel(b *testing.B) {
+	magic, err := NewMagic(MagicMimeType)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer magic.Close()
+	if err := magic.MagicLoad([]string{"../testdata/magic.mgc"}); err != nil {
+		b.Fatal(err)
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := magic.MagicBuffer(pngHeader); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
+
+func BenchmarkMagicPoolBufferParallel(b *testing.B) {
+	pool, err := NewMagicPool(0, MagicMimeType, []string{"../testdata/magic.mgc"})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer pool.Close()
+	if err := pool.Preload(); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := pool.MagicBuffer(pngHeader); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
+
+func BenchmarkDetectBufferParallel(b *testing.B) {
+	detector, err := NewDetector(WithFlags(MagicMimeType), WithDatabases("../testdata/magic.mgc"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer detector.Close()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := detector.DetectBuffer(pngHeader); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
+
+func BenchmarkDetectFileParallel(b *testing.B) {
+	detector, err := NewDetector(WithFlags(MagicMimeType), WithDatabases("../testdata/magic.mgc"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer detector.Close()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := detector.DetectFile("../testdata/lua"); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
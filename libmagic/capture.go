@@ -0,0 +1,67 @@
+package libmagic
+
+// #include <stdio.h>
+// #include <unistd.h>
+import "C"
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// captureLock serializes redirections of the process-wide stdio descriptors.
+var captureLock sync.Mutex
+
+// captureFd redirects the process file descriptor fd (1 for stdout, 2 for
+// stderr) into a pipe while fn runs and copies everything written to it into
+// w. libmagic reports listings and diagnostics by printing to the C stdio
+// streams, so this is the only way to get hold of them. Anything else the
+// process writes to fd while fn runs is captured as well. If w is fd
+// itself, such as os.Stdout for fd 1, fn writes to it directly: copying out
+// of the pipe into the pipe would block once it is full.
+func captureFd(fd int, w io.Writer, fn func()) error {
+	captureLock.Lock()
+	defer captureLock.Unlock()
+
+	if f, ok := w.(*os.File); ok && f.Fd() == uintptr(fd) {
+		fn()
+		C.fflush(nil)
+		return nil
+	}
+
+	r, pw, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create pipe: %w", err)
+	}
+	defer r.Close()
+
+	C.fflush(nil)
+	saved, err := syscall.Dup(fd)
+	if err != nil {
+		pw.Close()
+		return fmt.Errorf("failed to duplicate fd %d: %w", fd, err)
+	}
+	defer syscall.Close(saved)
+	if C.dup2(C.int(pw.Fd()), C.int(fd)) == C.int(-1) {
+		pw.Close()
+		return fmt.Errorf("failed to redirect fd %d", fd)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(w, r)
+		done <- err
+	}()
+
+	fn()
+
+	C.fflush(nil)
+	C.dup2(C.int(saved), C.int(fd))
+	pw.Close()
+	if err := <-done; err != nil {
+		return fmt.Errorf("failed to copy captured output: %w", err)
+	}
+	return nil
+}
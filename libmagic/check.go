@@ -0,0 +1,74 @@
+package libmagic
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strconv"
+	"syscall"
+)
+
+// CheckWarning is a single diagnostic reported by libmagic while checking a
+// magic source file.
+type CheckWarning struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (w CheckWarning) String() string {
+	return w.File + ", " + strconv.Itoa(w.Line) + ": " + w.Message
+}
+
+// checkWarningPattern matches the "file, line: Warning: message" lines
+// libmagic's file_magwarn prints to stderr.
+var checkWarningPattern = regexp.MustCompile(`^(.*), (\d+): Warning: (.*)$`)
+
+// MagicCheckWarnings is like MagicCheck but captures the diagnostics libmagic
+// prints to stderr and returns them as warnings. The error is non-nil when
+// libmagic considers the files invalid; the warnings usually explain why.
+func (m *Magic) MagicCheckWarnings(files []string) ([]CheckWarning, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var (
+		buf      bytes.Buffer
+		checkErr error
+	)
+	if err := captureFd(syscall.Stderr, &buf, func() { checkErr = m.magicCheck(files) }); err != nil {
+		return nil, err
+	}
+	return parseCheckWarnings(&buf), checkErr
+}
+
+// MagicCompileWarnings is like MagicCompileTo but captures the diagnostics
+// libmagic prints to stderr and returns them as warnings, along with the
+// paths of the compiled databases. Like MagicCompileTo, it is only
+// supported on Linux.
+func (m *Magic) MagicCompileWarnings(dir string, files []string) ([]string, []CheckWarning, error) {
+	var buf bytes.Buffer
+	outputs, err := m.compileTo(dir, files, func(fn func()) error {
+		return captureFd(syscall.Stderr, &buf, fn)
+	})
+	return outputs, parseCheckWarnings(&buf), err
+}
+
+func parseCheckWarnings(buf *bytes.Buffer) []CheckWarning {
+	var warnings []CheckWarning
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		match := checkWarningPattern.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		line, err := strconv.Atoi(match[2])
+		if err != nil {
+			continue
+		}
+		warnings = append(warnings, CheckWarning{
+			File:    match[1],
+			Line:    line,
+			Message: match[3],
+		})
+	}
+	return warnings
+}
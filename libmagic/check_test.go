@@ -0,0 +1,52 @@
+package libmagic
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestMagicCheckWarnings() {
+	t := s.T()
+	magic, err := NewMagic(MagicNone)
+	require.NoError(t, err)
+	defer magic.Close()
+	bad := filepath.Join(t.TempDir(), "bad")
+	require.NoError(t, os.WriteFile(bad, []byte("0\tbogus\tX\tbroken\n0\tstring\tAB\tok\n"), 0600))
+
+	warnings, err := magic.MagicCheckWarnings([]string{bad})
+	assert.Error(t, err)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, bad, warnings[0].File)
+	assert.Equal(t, 1, warnings[0].Line)
+	assert.Contains(t, warnings[0].Message, "bogus")
+
+	warnings, err = magic.MagicCheckWarnings([]string{"../testdata/lua"})
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+}
+
+func (s *MagicTestSuite) TestMagicCompileWarnings() {
+	t := s.T()
+	skipWithoutCompileTo(t)
+	magic, err := NewMagic(MagicNone)
+	require.NoError(t, err)
+	defer magic.Close()
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad")
+	require.NoError(t, os.WriteFile(bad, []byte("0\tbogus\tX\tbroken\n0\tstring\tAB\tok\n"), 0600))
+
+	_, warnings, err := magic.MagicCompileWarnings(dir, []string{bad})
+	assert.Error(t, err)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, 1, warnings[0].Line)
+	assert.Contains(t, warnings[0].Message, "bogus")
+
+	outputs, warnings, err := magic.MagicCompileWarnings(dir, []string{"../testdata/lua"})
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+	assert.Equal(t, []string{filepath.Join(dir, "lua.mgc")}, outputs)
+	assert.FileExists(t, outputs[0])
+}
@@ -0,0 +1,70 @@
+package libmagic
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Clone opens a new handle with the flags and databases of m. Compiled
+// databases are read from disk at most once: their content is kept by m and
+// shared, read-only, by every clone through magic_load_buffers. Databases
+// that are not compiled are loaded into the clone from their source again.
+func (m *Magic) Clone() (*Magic, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.handle == nil {
+		return nil, ErrClosed
+	}
+	if m.buffers == nil && m.files != nil {
+		if buffers, err := readCompiledDatabases(m.files); err == nil {
+			m.buffers = buffers
+		}
+	}
+
+	clone, err := NewMagic(m.flags)
+	if err != nil {
+		return nil, err
+	}
+	clone.debugFunc = m.debugFunc
+	clone.scratchReserve, clone.scratchMax = m.scratchReserve, m.scratchMax
+	if m.scratchReserve > 0 {
+		clone.resizeScratch(m.scratchReserve)
+	}
+	switch {
+	case m.buffers != nil:
+		err = clone.MagicLoadBuffers(m.buffers)
+	case m.files != nil:
+		err = clone.MagicLoad(m.files)
+	}
+	if err != nil {
+		clone.Close()
+		return nil, err
+	}
+	return clone, nil
+}
+
+// readCompiledDatabases reads the compiled databases magic_load would use for
+// files, failing if any of them is not a compiled database.
+func readCompiledDatabases(files []string) ([][]byte, error) {
+	if len(files) == 0 {
+		files = strings.Split(DefaultDatabasePath(), ":")
+	}
+	buffers := make([][]byte, 0, len(files))
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".mgc") {
+			if info, err := os.Stat(file + ".mgc"); err == nil && info.Mode().IsRegular() {
+				file += ".mgc"
+			}
+		}
+		buffer, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if !isCompiledDatabase(buffer) {
+			return nil, fmt.Errorf("%s is not a compiled database", file)
+		}
+		buffers = append(buffers, buffer)
+	}
+	return buffers, nil
+}
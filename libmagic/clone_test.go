@@ -0,0 +1,46 @@
+package libmagic
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestClone() {
+	t := s.T()
+	t.Parallel()
+	tests := []struct {
+		name        string
+		load        func(m *Magic) error
+		wantBuffers bool
+	}{
+		{
+			name:        "compiled database files",
+			load:        func(m *Magic) error { return m.MagicLoad([]string{"../testdata/magic.mgc", "../testdata/magic2.mgc"}) },
+			wantBuffers: true,
+		},
+		{
+			name: "magic source files",
+			load: func(m *Magic) error { return m.MagicLoad([]string{"../testdata/magic.mgc", "../testdata/lua"}) },
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			magic, err := NewMagic(MagicMimeType | MagicError)
+			require.NoError(t, err)
+			defer magic.Close()
+			require.NoError(t, tt.load(magic))
+
+			for i := 0; i < 2; i++ {
+				clone, err := magic.Clone()
+				require.NoError(t, err)
+				assert.Equal(t, magic.MagicGetFlags(), clone.MagicGetFlags())
+				result, err := clone.MagicBuffer(pngHeader)
+				assert.NoError(t, err)
+				assert.Equal(t, "image/png", result)
+				clone.Close()
+			}
+			assert.Equal(t, tt.wantBuffers, magic.buffers != nil)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package libmagic
+
+import (
+	"slices"
+	"strings"
+)
+
+// DatabaseDiff is how the detection of a file differs between two sets of
+// databases, see CompareDatabases.
+type DatabaseDiff struct {
+	Path string
+	// A and B are the Results with the first and the second databases,
+	// each with the description, the MIME type, the encoding and the
+	// extensions set, see DetectAll.
+	A, B Result
+	// Changed names the fields that differ, among "description", "mime",
+	// "encoding" and "extensions", in that order.
+	Changed []string
+}
+
+// Equal reports whether both databases detect the file the same way.
+func (diff DatabaseDiff) Equal() bool {
+	return len(diff.Changed) == 0
+}
+
+// CompareDatabases detects the file at path with the databases dbA and with
+// dbB, nil meaning the default database, and returns how the Results
+// differ, to audit classification drift when upgrading the magic database.
+// Both databases are loaded for this call only; comparing many files is
+// better done with two MagicDetectors and DetectAll.
+func CompareDatabases(path string, dbA, dbB []string) (DatabaseDiff, error) {
+	diff := DatabaseDiff{Path: path}
+	var err error
+	if diff.A, err = detectAllWith(path, dbA); err != nil {
+		return diff, err
+	}
+	if diff.B, err = detectAllWith(path, dbB); err != nil {
+		return diff, err
+	}
+	diff.Changed = diffResults(diff.A, diff.B)
+	return diff, nil
+}
+
+// detectAllWith detects the file at path with files loaded into a fresh
+// handle, see DetectAll.
+func detectAllWith(path string, files []string) (Result, error) {
+	m, err := NewMagic(MagicNone)
+	if err != nil {
+		return Result{}, err
+	}
+	defer m.Close()
+	if err := m.MagicLoad(files); err != nil {
+		return Result{}, err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	r, err := m.detectAll(func() (string, error) { return m.magicFile(path) })
+	r.Path, r.Err = path, err
+	r.Database = strings.Join(files, ":")
+	if r.Database == "" {
+		r.Database = DefaultDatabasePath()
+	}
+	r.parseKind()
+	return r, err
+}
+
+// diffResults returns the names of the detected fields that differ between a
+// and b.
+func diffResults(a, b Result) []string {
+	var changed []string
+	if a.Description != b.Description {
+		changed = append(changed, "description")
+	}
+	if a.MIME != b.MIME {
+		changed = append(changed, "mime")
+	}
+	if a.Encoding != b.Encoding {
+		changed = append(changed, "encoding")
+	}
+	if !slices.Equal(a.Extensions, b.Extensions) {
+		changed = append(changed, "extensions")
+	}
+	return changed
+}
@@ -0,0 +1,35 @@
+package libmagic
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestCompareDatabases() {
+	t := s.T()
+	dir := t.TempDir()
+	image := filepath.Join(dir, "image.png")
+	require.NoError(t, os.WriteFile(image, pngHeader, 0o600))
+	diff, err := CompareDatabases(image, []string{"../testdata/magic.mgc"}, []string{"../testdata/magic2.mgc"})
+	require.NoError(t, err)
+	assert.True(t, diff.Equal())
+	assert.Equal(t, "image/png", diff.A.MIME)
+	assert.Equal(t, "../testdata/magic2.mgc", diff.B.Database)
+
+	source := filepath.Join(dir, "gomagic")
+	require.NoError(t, os.WriteFile(source, sourceRule, 0o600))
+	data := filepath.Join(dir, "data")
+	require.NoError(t, os.WriteFile(data, []byte("GOMAGICTEST payload"), 0o600))
+	diff, err = CompareDatabases(data, []string{"../testdata/magic.mgc"}, []string{source})
+	require.NoError(t, err)
+	assert.False(t, diff.Equal())
+	assert.Equal(t, []string{"description", "mime"}, diff.Changed)
+	assert.Equal(t, "gomagic test data", diff.B.Description)
+	assert.Equal(t, "application/x-gomagic-test", diff.B.MIME)
+
+	_, err = CompareDatabases(data, []string{filepath.Join(dir, "missing.mgc")}, nil)
+	assert.Error(t, err)
+}
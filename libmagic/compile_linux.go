@@ -0,0 +1,33 @@
+//go:build linux
+
+package libmagic
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+)
+
+// magicCompileIn runs magicCompile with dir as the working directory, where
+// magic_compile writes its output. The compile runs on an OS thread given
+// its own working directory with unshare(CLONE_FS), so the working directory
+// of the process, and of every other goroutine, stays unchanged. The caller
+// must hold m.lock.
+func (m *Magic) magicCompileIn(dir string, files []string) error {
+	done := make(chan error, 1)
+	go func() {
+		// The thread is never unlocked: it exits with the goroutine instead
+		// of running other goroutines in the wrong directory.
+		runtime.LockOSThread()
+		if err := syscall.Unshare(syscall.CLONE_FS); err != nil {
+			done <- fmt.Errorf("failed to unshare working directory: %w", err)
+			return
+		}
+		if err := syscall.Chdir(dir); err != nil {
+			done <- fmt.Errorf("failed to change into %s: %w", dir, err)
+			return
+		}
+		done <- m.magicCompile(files)
+	}()
+	return <-done
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package libmagic
+
+import (
+	"errors"
+	"fmt"
+)
+
+// magicCompileIn would run magicCompile with dir as the working directory.
+// Only Linux can give a single thread its own working directory: elsewhere
+// changing it would move every goroutine of the process, and forking the
+// multithreaded process to compile in a child is not safe, as magic_compile
+// allocates memory and uses stdio. Compiling into a directory is therefore
+// not supported.
+func (m *Magic) magicCompileIn(dir string, files []string) error {
+	return fmt.Errorf("compiling into %s: %w", dir, errors.ErrUnsupported)
+}
@@ -0,0 +1,29 @@
+package libmagic
+
+// compressedEncoding separates, in the output of libmagic with MagicCompress
+// and MagicMime, the MIME type of the decompressed content from that of
+// its compression.
+const compressedEncoding = " compressed-encoding="
+
+// DetectFileInner detects the file at path looking inside compressed
+// content, whatever the flags of the MagicDetector: the Result has the
+// compression format in Container, e.g. "application/gzip", and the MIME type
+// and encoding of the decompressed content in MIME and Encoding. Container is
+// empty for content that is not compressed.
+func (d *MagicDetector) DetectFileInner(path string) (Result, error) {
+	flags := d.innerFlags()
+	raw, err := d.DetectFileWithFlags(path, flags)
+	return d.finish(newResult(path, raw, flags, err)), err
+}
+
+// DetectBufferInner is like DetectFileInner but detects content.
+func (d *MagicDetector) DetectBufferInner(content []byte) (Result, error) {
+	flags := d.innerFlags()
+	raw, err := d.DetectBufferWithFlags(content, flags)
+	return d.finishContent(newResult("", raw, flags, err), content), err
+}
+
+// innerFlags are the flags of d with MIME output of decompressed content.
+func (d *MagicDetector) innerFlags() Flags {
+	return d.flags&^(outputFlags|MagicCompressTransp) | MagicCompress | MagicMime
+}
@@ -0,0 +1,66 @@
+package libmagic
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipContent(t require.TestingT, content []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func (s *MagicTestSuite) TestDetectorDetectInner() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType),
+		WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	csv := gzipContent(t, []byte("a,b,c\n1,2,3\n4,5,6\n"))
+	path := filepath.Join(t.TempDir(), "data.csv.gz")
+	require.NoError(t, os.WriteFile(path, csv, 0o600))
+
+	result, err := detector.DetectFileInner(path)
+	require.NoError(t, err)
+	assert.Equal(t, "application/gzip", result.Container)
+	assert.Equal(t, "text/csv", result.MIME)
+	assert.Equal(t, "us-ascii", result.Encoding)
+
+	result, err = detector.DetectBufferInner(csv)
+	require.NoError(t, err)
+	assert.Equal(t, "application/gzip", result.Container)
+	assert.Equal(t, "text/csv", result.MIME)
+
+	result, err = detector.DetectBufferInner(pngHeader)
+	require.NoError(t, err)
+	assert.Empty(t, result.Container)
+	assert.Equal(t, "image/png", result.MIME)
+	assert.Equal(t, "binary", result.Encoding)
+
+	raw, err := detector.DetectBuffer(csv)
+	require.NoError(t, err)
+	assert.Equal(t, "application/gzip", raw)
+}
+
+func (s *MagicTestSuite) TestNewResultCompressed() {
+	t := s.T()
+	r := newResult("", "text/csv; charset=us-ascii compressed-encoding=application/gzip; charset=binary",
+		MagicCompress|MagicMime, nil)
+	assert.Equal(t, "text/csv", r.MIME)
+	assert.Equal(t, "us-ascii", r.Encoding)
+	assert.Equal(t, "application/gzip", r.Container)
+
+	r = newResult("", "text/plain compressed-encoding=application/x-xz", MagicCompress|MagicMimeType, nil)
+	assert.Equal(t, "text/plain", r.MIME)
+	assert.Equal(t, "application/x-xz", r.Container)
+}
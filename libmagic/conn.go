@@ -0,0 +1,47 @@
+package libmagic
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// SniffConn reads up to n bytes from conn, waiting at most timeout for them,
+// and detects what arrived. It returns a connection that replays those bytes
+// before reading more from conn, so a server can branch on the type of an
+// upload and then hand the whole stream on. n defaults to 512 when zero or
+// less. The read deadline of conn is cleared before returning; errors other
+// than the deadline passing or conn reaching EOF are returned.
+func (d *MagicDetector) SniffConn(conn net.Conn, n int, timeout time.Duration) (string, net.Conn, error) {
+	if n <= 0 {
+		n = 512
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return "", nil, err
+	}
+	head := make([]byte, n)
+	read, err := io.ReadFull(conn, head)
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		return "", nil, err
+	}
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, os.ErrDeadlineExceeded) {
+		return "", nil, err
+	}
+	head = head[:read]
+	replay := &replayConn{Conn: conn, r: io.MultiReader(bytes.NewReader(head), conn)}
+	raw, err := d.DetectBuffer(head)
+	return raw, replay, err
+}
+
+// replayConn is a net.Conn reading from r first.
+type replayConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *replayConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
@@ -0,0 +1,47 @@
+package libmagic
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestSniffConn() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	content := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{0xAB}, 4096)...)
+	server, client := net.Pipe()
+	go func() {
+		client.Write(content)
+		client.Close()
+	}()
+	result, conn, err := detector.SniffConn(server, 0, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+	all, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, content, all)
+	conn.Close()
+
+	// Only part of n arrives before the deadline.
+	server, client = net.Pipe()
+	defer client.Close()
+	go client.Write(pngHeader)
+	result, conn, err = detector.SniffConn(server, 1024, 50*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+	defer conn.Close()
+	go client.Write([]byte("more"))
+	buf := make([]byte, len(pngHeader)+4)
+	_, err = io.ReadFull(conn, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, append(append([]byte{}, pngHeader...), "more"...), buf)
+}
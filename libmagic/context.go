@@ -0,0 +1,23 @@
+package libmagic
+
+import (
+	"context"
+)
+
+// MagicFileCtx is like MagicFile but returns ctx.Err() instead of starting
+// the detection when ctx is already done. A running libmagic call cannot be
+// interrupted; use a MagicDetector to abandon calls that take too long.
+func (m *Magic) MagicFileCtx(ctx context.Context, filename string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return m.MagicFile(filename)
+}
+
+// MagicBufferCtx is like MagicFileCtx but detects content.
+func (m *Magic) MagicBufferCtx(ctx context.Context, content []byte) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return m.MagicBuffer(content)
+}
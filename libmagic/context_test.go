@@ -0,0 +1,83 @@
+package libmagic
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestMagicCtx() {
+	t := s.T()
+	t.Parallel()
+	magic, err := NewMagic(MagicMimeType)
+	require.NoError(t, err)
+	defer magic.Close()
+	require.NoError(t, magic.MagicLoad([]string{"../testdata/magic.mgc"}))
+
+	result, err := magic.MagicBufferCtx(context.Background(), pngHeader)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = magic.MagicBufferCtx(ctx, pngHeader)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = magic.MagicFileCtx(ctx, "../testdata/magic.mgc")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func (s *MagicTestSuite) TestMagicPoolCtx() {
+	t := s.T()
+	t.Parallel()
+	pool, err := NewMagicPool(1, MagicMimeType, []string{"../testdata/magic.mgc"})
+	require.NoError(t, err)
+	defer pool.Close()
+
+	result, err := pool.MagicBufferCtx(context.Background(), pngHeader)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+
+	// Hold the only handle so the next call has to queue.
+	m := <-pool.idle
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = pool.MagicBufferCtx(ctx, pngHeader)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	pool.idle <- m
+}
+
+func (s *MagicTestSuite) TestDetectCtx() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	result, err := detector.DetectBufferCtx(context.Background(), pngHeader)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+
+	// Hold the only handle so the next call has to queue.
+	l, err := detector.acquire(context.Background())
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = detector.DetectBufferCtx(ctx, pngHeader)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	l.release()
+
+	result, err = detector.DetectFileCtx(context.Background(), "../testdata/magic.mgc")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := detector.DetectFilesCtx(canceled, []string{"../testdata/magic.mgc", "../testdata/magic2.mgc"})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for _, r := range results {
+		assert.ErrorIs(t, r.Err, context.Canceled)
+	}
+}
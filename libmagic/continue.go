@@ -0,0 +1,52 @@
+package libmagic
+
+import (
+	"strings"
+)
+
+// continueSeparator separates the matches libmagic reports with
+// MagicContinue set. Without MagicRaw, the newline is printed escaped.
+const (
+	continueSeparator        = "\n- "
+	escapedContinueSeparator = "\\012- "
+)
+
+// MagicFileAll returns every match for filename, as reported with
+// MagicContinue, instead of only the first one.
+func (m *Magic) MagicFileAll(filename string) ([]string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	restore, err := m.withFlags(m.flags | MagicContinue)
+	if err != nil {
+		return nil, err
+	}
+	defer restore()
+
+	result, err := m.magicFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return splitContinue(result), nil
+}
+
+// MagicBufferAll is like MagicFileAll but detects content.
+func (m *Magic) MagicBufferAll(content []byte) ([]string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	restore, err := m.withFlags(m.flags | MagicContinue)
+	if err != nil {
+		return nil, err
+	}
+	defer restore()
+
+	result, err := m.magicBuffer(content)
+	if err != nil {
+		return nil, err
+	}
+	return splitContinue(result), nil
+}
+
+func splitContinue(result string) []string {
+	result = strings.ReplaceAll(result, escapedContinueSeparator, continueSeparator)
+	return strings.Split(result, continueSeparator)
+}
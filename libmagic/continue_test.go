@@ -0,0 +1,33 @@
+package libmagic
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestMagicBufferAll() {
+	t := s.T()
+	t.Parallel()
+	magic, err := NewMagic(MagicError)
+	require.NoError(t, err)
+	defer magic.Close()
+	require.NoError(t, magic.MagicLoad([]string{"../testdata/magic.mgc"}))
+
+	results, err := magic.MagicBufferAll(pngHeader)
+	assert.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Contains(t, results[0], "PNG image data")
+	for _, result := range results {
+		assert.NotContains(t, result, continueSeparator)
+		assert.NotContains(t, result, escapedContinueSeparator)
+	}
+	assert.Equal(t, MagicError, magic.MagicGetFlags())
+}
+
+func (s *MagicTestSuite) TestSplitContinue() {
+	t := s.T()
+	t.Parallel()
+	assert.Equal(t, []string{"first", "second"}, splitContinue("first\n- second"))
+	assert.Equal(t, []string{"first", "second"}, splitContinue("first\\012- second"))
+	assert.Equal(t, []string{"only"}, splitContinue("only"))
+}
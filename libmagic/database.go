@@ -0,0 +1,220 @@
+package libmagic
+
+// #include <magic.h>
+import "C"
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultDatabasePath returns the database path libmagic uses when
+// MagicLoad is called with no files. It honors the MAGIC environment variable
+// and may contain several colon separated entries.
+func DefaultDatabasePath() string {
+	path := C.magic_getpath(nil, 0)
+	if path == nil {
+		return ""
+	}
+	return C.GoString(path)
+}
+
+// compiledMagicNo is the magic number at the start of every compiled .mgc
+// database, written in the byte order of the host that compiled it.
+const compiledMagicNo = 0xF11E041C
+
+func isCompiledDatabase(buffer []byte) bool {
+	if len(buffer) < 4 {
+		return false
+	}
+	return binary.LittleEndian.Uint32(buffer) == compiledMagicNo ||
+		binary.BigEndian.Uint32(buffer) == compiledMagicNo
+}
+
+// loadSourceBuffers loads buffers of which at least one holds uncompiled
+// magic source text. magic_load_buffers only understands compiled databases,
+// so the buffers are written to a private temporary directory and loaded with
+// magic_load, which parses source files on the fly. The caller must hold
+// m.lock.
+func (m *Magic) loadSourceBuffers(buffers [][]byte) error {
+	if m.handle == nil {
+		return ErrClosed
+	}
+	dir, err := os.MkdirTemp("", "gomagic")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := make([]string, 0, len(buffers))
+	for i, buffer := range buffers {
+		name := strconv.Itoa(i)
+		if isCompiledDatabase(buffer) {
+			name += ".mgc"
+		}
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, buffer, 0600); err != nil {
+			return fmt.Errorf("failed to write database buffer %d: %w", i, err)
+		}
+		files = append(files, file)
+	}
+
+	cFiles := m.cFiles(files)
+	if C.magic_load(m.handle, cFiles) == C.int(-1) {
+		return m.magicError("magic_load", ErrInvalidDatabase, "failed to load database buffers")
+	}
+	m.setDatabases(nil, buffers, nil)
+	return nil
+}
+
+// fileCompile is the magic_getpath action selecting the source path used by
+// magic_compile.
+const fileCompile = 2
+
+// MagicCompileTo compiles files like MagicCompile, but writes the resulting
+// .mgc databases into dir instead of the process working directory, and
+// returns their paths. magic_compile always writes into the working
+// directory, so the compile runs with dir as its working directory, see
+// magicCompileIn; the working directory of the process is left alone. This
+// is only supported on Linux: elsewhere the error matches
+// errors.ErrUnsupported.
+func (m *Magic) MagicCompileTo(dir string, files []string) ([]string, error) {
+	return m.compileTo(dir, files, func(fn func()) error {
+		fn()
+		return nil
+	})
+}
+
+// compileTo implements MagicCompileTo, running magic_compile through run
+// with m.lock held.
+func (m *Magic) compileTo(dir string, files []string, run func(fn func()) error) ([]string, error) {
+	if len(files) == 0 {
+		path := C.magic_getpath(nil, fileCompile)
+		if path == nil {
+			return nil, fmt.Errorf("failed to determine default database path")
+		}
+		files = strings.Split(C.GoString(path), ":")
+	}
+	absFiles := make([]string, 0, len(files))
+	outputs := make([]string, 0, len(files))
+	for _, file := range files {
+		absFile, err := filepath.Abs(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", file, err)
+		}
+		absFiles = append(absFiles, absFile)
+		outputs = append(outputs, filepath.Join(dir, compiledName(file)))
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var compileErr error
+	if err := run(func() { compileErr = m.magicCompileIn(dir, absFiles) }); err != nil {
+		return nil, err
+	}
+	if compileErr != nil {
+		return nil, compileErr
+	}
+	return outputs, nil
+}
+
+// compiledName mirrors libmagic's naming of compiled databases: the base
+// name of the source with ".mgc" appended unless it is already present.
+func compiledName(file string) string {
+	name := filepath.Base(file)
+	if strings.HasSuffix(name, ".mgc") {
+		return name
+	}
+	return name + ".mgc"
+}
+
+// MagicCompileBuffer compiles magic source text into a single database and
+// returns its content, ready for MagicLoadBuffers. The sources and the
+// compiled output only live in a temporary directory removed before
+// returning. Like MagicCompileTo, it is only supported on Linux.
+func (m *Magic) MagicCompileBuffer(sources ...[]byte) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "gomagic")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// magic_compile merges all files of a directory into one database.
+	srcDir := filepath.Join(dir, "magic")
+	if err := os.Mkdir(srcDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create source directory: %w", err)
+	}
+	for i, source := range sources {
+		if err := os.WriteFile(filepath.Join(srcDir, strconv.Itoa(i)), source, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write magic source %d: %w", i, err)
+		}
+	}
+
+	outputs, err := m.MagicCompileTo(dir, []string{srcDir})
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := os.ReadFile(outputs[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compiled database: %w", err)
+	}
+	return compiled, nil
+}
+
+func hasColon(files []string) bool {
+	for _, file := range files {
+		if strings.Contains(file, ":") {
+			return true
+		}
+	}
+	return false
+}
+
+// linkDatabases works around libmagic splitting database lists on ':' by
+// symlinking every file under a colon-free name in a temporary directory.
+// The ".mgc" suffix is kept, as libmagic relies on it to tell compiled
+// databases from sources. The caller removes dir when done.
+func linkDatabases(files []string) (dir string, links []string, err error) {
+	dir, err = os.MkdirTemp("", "gomagic")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	links = make([]string, 0, len(files))
+	for i, file := range files {
+		target, err := filepath.Abs(file)
+		if err != nil {
+			os.RemoveAll(dir)
+			return "", nil, fmt.Errorf("failed to resolve %s: %w", file, err)
+		}
+		link := filepath.Join(dir, strconv.Itoa(i))
+		if strings.HasSuffix(file, ".mgc") {
+			link += ".mgc"
+		}
+		if err := os.Symlink(target, link); err != nil {
+			os.RemoveAll(dir)
+			return "", nil, fmt.Errorf("failed to link %s: %w", file, err)
+		}
+		links = append(links, link)
+	}
+	return dir, links, nil
+}
+
+// findInvalidDatabase loads every file on its own into a scratch handle and
+// returns the first one libmagic rejects, or "" if each loads fine alone.
+func findInvalidDatabase(flags Flags, files []string) string {
+	for _, file := range files {
+		scratch, err := NewMagic(flags &^ MagicDebug)
+		if err != nil {
+			return ""
+		}
+		err = scratch.magicLoad([]string{file})
+		scratch.Close()
+		if err != nil {
+			return file
+		}
+	}
+	return ""
+}
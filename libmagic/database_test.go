@@ -0,0 +1,188 @@
+package libmagic
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDefaultDatabasePath() {
+	t := s.T()
+	assert.NotEmpty(t, DefaultDatabasePath())
+
+	old, ok := os.LookupEnv("MAGIC")
+	defer func() {
+		if ok {
+			os.Setenv("MAGIC", old)
+		} else {
+			os.Unsetenv("MAGIC")
+		}
+	}()
+	os.Setenv("MAGIC", "../testdata/magic.mgc")
+	assert.Equal(t, "../testdata/magic.mgc", DefaultDatabasePath())
+}
+
+var sourceRule = []byte("0\tstring\tGOMAGICTEST\tgomagic test data\n!:mime\tapplication/x-gomagic-test\n")
+
+func (s *MagicTestSuite) TestMagicLoadSourceBuffers() {
+	t := s.T()
+	t.Parallel()
+	compiled, err := os.ReadFile("../testdata/magic.mgc")
+	require.NoError(t, err)
+	tests := []struct {
+		name    string
+		buffers [][]byte
+		input   []byte
+		want    string
+	}{
+		{
+			name:    "source only",
+			buffers: [][]byte{sourceRule},
+			input:   []byte("GOMAGICTEST payload"),
+			want:    "application/x-gomagic-test",
+		},
+		{
+			name:    "source mixed with compiled database",
+			buffers: [][]byte{compiled, sourceRule},
+			input:   pngHeader,
+			want:    "image/png",
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			magic, err := NewMagic(MagicMimeType | MagicError)
+			require.NoError(t, err)
+			defer magic.Close()
+			require.NoError(t, magic.MagicLoadBuffers(tt.buffers))
+			result, err := magic.MagicBuffer(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
+
+func (s *MagicTestSuite) TestIsCompiledDatabase() {
+	t := s.T()
+	t.Parallel()
+	assert.True(t, isCompiledDatabase([]byte{0x1c, 0x04, 0x1e, 0xf1, 0x12}))
+	assert.True(t, isCompiledDatabase([]byte{0xf1, 0x1e, 0x04, 0x1c}))
+	assert.False(t, isCompiledDatabase(sourceRule))
+	assert.False(t, isCompiledDatabase(nil))
+}
+
+// skipWithoutCompileTo skips t where compiling into a directory is not
+// supported, see magicCompileIn.
+func skipWithoutCompileTo(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("compiling into a directory is only supported on Linux")
+	}
+}
+
+func (s *MagicTestSuite) TestMagicCompileTo() {
+	t := s.T()
+	skipWithoutCompileTo(t)
+	magic, err := NewMagic(MagicMimeType | MagicError)
+	require.NoError(t, err)
+	defer magic.Close()
+	dir := t.TempDir()
+
+	outputs, err := magic.MagicCompileTo(dir, []string{"../testdata/lua", "../testdata/rpm"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "lua.mgc"), filepath.Join(dir, "rpm.mgc")}, outputs)
+	for _, output := range outputs {
+		assert.FileExists(t, output)
+	}
+	assert.NoFileExists(t, "lua.mgc")
+	require.NoError(t, magic.MagicLoad(outputs))
+	result, err := magic.MagicBuffer([]byte("#!/usr/bin/env lua\nprint(1)\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "text/x-lua", result)
+
+	_, err = magic.MagicCompileTo(dir, []string{"../testdata/nonexist"})
+	assert.Error(t, err)
+}
+
+func (s *MagicTestSuite) TestMagicCompileToWorkingDirectory() {
+	t := s.T()
+	skipWithoutCompileTo(t)
+	magic, err := NewMagic(MagicMimeType | MagicError)
+	require.NoError(t, err)
+	defer magic.Close()
+	wd, err := syscall.Getwd()
+	require.NoError(t, err)
+
+	stop := make(chan struct{})
+	changed := make(chan string, 1)
+	go func() {
+		defer close(changed)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if cwd, err := syscall.Getwd(); err == nil && cwd != wd {
+				changed <- cwd
+				return
+			}
+		}
+	}()
+	for range 10 {
+		_, err := magic.MagicCompileTo(t.TempDir(), []string{"../testdata/lua"})
+		require.NoError(t, err)
+	}
+	close(stop)
+	assert.Empty(t, <-changed)
+	assert.NoFileExists(t, "lua.mgc")
+}
+
+func (s *MagicTestSuite) TestMagicCompileBuffer() {
+	t := s.T()
+	skipWithoutCompileTo(t)
+	magic, err := NewMagic(MagicMimeType | MagicError)
+	require.NoError(t, err)
+	defer magic.Close()
+
+	compiled, err := magic.MagicCompileBuffer(sourceRule, []byte("0\tstring\tOTHERTEST\tother test data\n!:mime\tapplication/x-other-test\n"))
+	require.NoError(t, err)
+	assert.True(t, isCompiledDatabase(compiled))
+
+	require.NoError(t, magic.MagicLoadBuffers([][]byte{compiled}))
+	result, err := magic.MagicBuffer([]byte("GOMAGICTEST payload"))
+	assert.NoError(t, err)
+	assert.Equal(t, "application/x-gomagic-test", result)
+	result, err = magic.MagicBuffer([]byte("OTHERTEST payload"))
+	assert.NoError(t, err)
+	assert.Equal(t, "application/x-other-test", result)
+
+	_, err = magic.MagicCompileBuffer([]byte("0\tbogus\tX\tbroken\n"))
+	assert.Error(t, err)
+}
+
+func (s *MagicTestSuite) TestMagicLoadColonPaths() {
+	t := s.T()
+	t.Parallel()
+	source, err := os.ReadFile("../testdata/lua")
+	require.NoError(t, err)
+	dir := filepath.Join(t.TempDir(), "with:colon")
+	require.NoError(t, os.Mkdir(dir, 0700))
+	lua := filepath.Join(dir, "lua")
+	require.NoError(t, os.WriteFile(lua, source, 0600))
+
+	magic, err := NewMagic(MagicMimeType | MagicError)
+	require.NoError(t, err)
+	defer magic.Close()
+	require.NoError(t, magic.MagicLoad([]string{"../testdata/magic.mgc", lua}))
+	result, err := magic.MagicBuffer([]byte("#!/usr/bin/env lua\nprint(1)\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "text/x-lua", result)
+
+	err = magic.MagicLoad([]string{"../testdata/nonexist.mgc", "../testdata/nonexist2.mgc"})
+	assert.ErrorContains(t, err, "failed to load database file ../testdata/nonexist.mgc")
+}
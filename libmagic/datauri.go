@@ -0,0 +1,91 @@
+package libmagic
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidDataURI is returned by ValidateDataURI for malformed data URIs.
+var ErrInvalidDataURI = errors.New("invalid data URI")
+
+// defaultDataURIType is the media type of data URIs that declare none.
+const defaultDataURIType = "text/plain"
+
+// DataURIReport compares the declared media type of a data URI with the
+// detected type of its content.
+type DataURIReport struct {
+	// Declared is the media type the URI declares, without parameters,
+	// "text/plain" if it declares none.
+	Declared string
+	// Data is the decoded content.
+	Data []byte
+	// Result is the Result of detecting Data; its MIME type is always set.
+	Result Result
+	// Match is set when the content is of the declared type, alias aware as
+	// for IsOneOf. Plain text matches any declared text type.
+	Match bool
+}
+
+// ValidateDataURI decodes uri, a "data:" URI as defined by RFC 2397, with
+// base64 or percent-encoded data, detects its content and reports whether it
+// is of the declared media type, to sanitize user-supplied inline content
+// such as "data:image/png;base64,...". The MIME type is requested for this
+// call whatever the flags of the MagicDetector. It returns
+// ErrInvalidDataURI if uri cannot be decoded.
+func (d *MagicDetector) ValidateDataURI(uri string) (DataURIReport, error) {
+	declared, data, err := decodeDataURI(uri)
+	if err != nil {
+		return DataURIReport{}, err
+	}
+	report := DataURIReport{Declared: declared, Data: data}
+	ok, r, err := d.IsOneOf(data, []string{declared})
+	report.Result = r
+	if err != nil {
+		return report, err
+	}
+	report.Match = ok || canonicalMIME(r.MIME) == "text/plain" && isTextMIME(canonicalMIME(declared))
+	return report, nil
+}
+
+// decodeDataURI returns the media type, without parameters, and the data of
+// the data URI uri.
+func decodeDataURI(uri string) (string, []byte, error) {
+	scheme, rest, ok := strings.Cut(uri, ":")
+	if !ok || !strings.EqualFold(scheme, "data") {
+		return "", nil, fmt.Errorf("%w: missing data: scheme", ErrInvalidDataURI)
+	}
+	header, payload, ok := strings.Cut(rest, ",")
+	if !ok {
+		return "", nil, fmt.Errorf("%w: missing comma", ErrInvalidDataURI)
+	}
+	params := strings.Split(header, ";")
+	isBase64 := len(params) > 1 && strings.EqualFold(strings.TrimSpace(params[len(params)-1]), "base64")
+	if isBase64 {
+		params = params[:len(params)-1]
+	}
+	declared := strings.ToLower(strings.TrimSpace(params[0]))
+	if declared == "" {
+		declared = defaultDataURIType
+	} else if typ, subtype, ok := strings.Cut(declared, "/"); !ok || typ == "" || subtype == "" {
+		return "", nil, fmt.Errorf("%w: bad media type %q", ErrInvalidDataURI, params[0])
+	}
+	payload, err := url.PathUnescape(payload)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %w", ErrInvalidDataURI, err)
+	}
+	if !isBase64 {
+		return declared, []byte(payload), nil
+	}
+	payload = strings.Join(strings.Fields(payload), "")
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(payload, "="))
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %w", ErrInvalidDataURI, err)
+	}
+	return declared, data, nil
+}
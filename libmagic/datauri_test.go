@@ -0,0 +1,64 @@
+package libmagic
+
+import (
+	"encoding/base64"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDecodeDataURI() {
+	t := s.T()
+	tests := []struct {
+		uri      string
+		declared string
+		data     string
+	}{
+		{"data:,Hello%2C%20World%21", "text/plain", "Hello, World!"},
+		{"data:text/plain;charset=US-ASCII,hi", "text/plain", "hi"},
+		{"data:Image/PNG;base64,aGk=", "image/png", "hi"},
+		{"DATA:;base64,aGk", "text/plain", "hi"},
+		{"data:text/html;base64,PGI+\n aGk8L2I+", "text/html", "<b>hi</b>"},
+	}
+	for _, tt := range tests {
+		declared, data, err := decodeDataURI(tt.uri)
+		require.NoError(t, err, tt.uri)
+		assert.Equal(t, tt.declared, declared, tt.uri)
+		assert.Equal(t, tt.data, string(data), tt.uri)
+	}
+
+	for _, uri := range []string{"http://example.com", "data:image/png;base64", "data:png;base64,aGk=", "data:;base64,!!!", "data:,%zz"} {
+		_, _, err := decodeDataURI(uri)
+		assert.ErrorIs(t, err, ErrInvalidDataURI, uri)
+	}
+}
+
+func (s *MagicTestSuite) TestDetectorValidateDataURI() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	png := base64.StdEncoding.EncodeToString(pngHeader)
+	report, err := detector.ValidateDataURI("data:image/png;base64," + png)
+	require.NoError(t, err)
+	assert.True(t, report.Match)
+	assert.Equal(t, pngHeader, report.Data)
+
+	report, err = detector.ValidateDataURI("data:image/jpeg;base64," + png)
+	require.NoError(t, err)
+	assert.False(t, report.Match)
+	assert.Equal(t, "image/jpeg", report.Declared)
+	assert.Equal(t, "image/png", report.Result.MIME)
+
+	report, err = detector.ValidateDataURI("data:text/csv,a%2Cb%0A1%2C2%0A")
+	require.NoError(t, err)
+	assert.True(t, report.Match)
+
+	report, err = detector.ValidateDataURI("data:,hello%20world%0A")
+	require.NoError(t, err)
+	assert.True(t, report.Match)
+
+	_, err = detector.ValidateDataURI("data:image/png;base64,***")
+	assert.ErrorIs(t, err, ErrInvalidDataURI)
+}
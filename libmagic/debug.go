@@ -0,0 +1,81 @@
+package libmagic
+
+import (
+	"bytes"
+	"log/slog"
+	"syscall"
+)
+
+// SetDebugFunc makes the handle forward the output libmagic prints to stderr
+// while MagicDebug is set to fn, one line per call, instead of letting it
+// reach the process stderr. A nil fn restores the default behavior.
+//
+// Capturing stderr is process wide: while a debug-enabled call runs, other
+// writes to stderr are forwarded to fn as well, and such calls are
+// serialized across all handles.
+func (m *Magic) SetDebugFunc(fn func(line string)) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.debugFunc = fn
+}
+
+// SetDebugLogger is like SetDebugFunc but logs each line at debug level to
+// logger.
+func (m *Magic) SetDebugLogger(logger *slog.Logger) {
+	if logger == nil {
+		m.SetDebugFunc(nil)
+		return
+	}
+	m.SetDebugFunc(func(line string) { logger.Debug(line) })
+}
+
+// captureDebug runs fn, forwarding libmagic's debug output to m.debugFunc if
+// one is set and MagicDebug is enabled. The caller must hold m.lock.
+func (m *Magic) captureDebug(fn func()) {
+	if m.debugFunc == nil || m.flags&MagicDebug == 0 {
+		fn()
+		return
+	}
+	var (
+		w   = &lineWriter{fn: m.debugFunc}
+		ran bool
+	)
+	err := captureFd(syscall.Stderr, w, func() {
+		ran = true
+		fn()
+	})
+	w.Flush()
+	if err != nil {
+		m.debugFunc("failed to capture debug output: " + err.Error())
+	}
+	if !ran {
+		fn()
+	}
+}
+
+// lineWriter calls fn for every complete line written to it.
+type lineWriter struct {
+	fn  func(line string)
+	buf []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.fn(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush passes any trailing partial line to fn.
+func (w *lineWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.fn(string(w.buf))
+		w.buf = nil
+	}
+}
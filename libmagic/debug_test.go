@@ -0,0 +1,43 @@
+package libmagic
+
+import (
+	"bytes"
+	"log/slog"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestSetDebugLogger() {
+	t := s.T()
+	magic, err := NewMagic(MagicMimeType | MagicError | MagicDebug)
+	require.NoError(t, err)
+	defer magic.Close()
+	require.NoError(t, magic.MagicLoad([]string{"../testdata/lua"}))
+
+	var buf bytes.Buffer
+	magic.SetDebugLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	result, err := magic.MagicBuffer([]byte("#!/usr/bin/env lua\nprint(1)\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "text/x-lua", result)
+	assert.Contains(t, buf.String(), "level=DEBUG")
+
+	buf.Reset()
+	magic.SetDebugLogger(nil)
+	require.NoError(t, magic.MagicSetFlags(MagicMimeType|MagicError))
+	_, err = magic.MagicBuffer([]byte("#!/usr/bin/env lua\nprint(1)\n"))
+	assert.NoError(t, err)
+	assert.Empty(t, buf.String())
+}
+
+func (s *MagicTestSuite) TestLineWriter() {
+	t := s.T()
+	t.Parallel()
+	var lines []string
+	w := &lineWriter{fn: func(line string) { lines = append(lines, line) }}
+	_, _ = w.Write([]byte("first\nsec"))
+	_, _ = w.Write([]byte("ond\nthird"))
+	assert.Equal(t, []string{"first", "second"}, lines)
+	w.Flush()
+	assert.Equal(t, []string{"first", "second", "third"}, lines)
+}
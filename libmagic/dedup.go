@@ -0,0 +1,122 @@
+package libmagic
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// WithDeduplication makes DetectFiles, DetectStream, DetectWalk and Scan
+// detect regular files with the same content only once per call, reusing
+// the output of libmagic for the duplicates, which abound on file shares.
+// Files are told apart by their size, their setuid, setgid and sticky bits,
+// which libmagic reports, and a SHA-256 hash of their first headSize bytes,
+// or of their whole content if headSize is zero or less.
+// Hashing heads is cheaper but files only differing further in, beyond
+// what libmagic reads, get the same Result. Deduplication replaces
+// WithIOUring.
+func WithDeduplication(headSize int) BatchOption {
+	return func(o *batchOptions) {
+		o.dedup = true
+		o.dedupHead = headSize
+	}
+}
+
+// withDedupCache makes a batch share cache with another one, so files
+// detected by either are only detected once.
+func withDedupCache(cache *dedupCache) BatchOption {
+	return func(o *batchOptions) {
+		o.dedupCache = cache
+	}
+}
+
+// dedupKey identifies the content of a file, along with the mode bits
+// libmagic prefixes its output with, such as "setuid ELF 64-bit".
+type dedupKey struct {
+	size int64
+	mode fs.FileMode
+	sum  [sha256.Size]byte
+}
+
+// dedupEntry is the output of libmagic for a content, available once done
+// is closed.
+type dedupEntry struct {
+	done chan struct{}
+	raw  string
+	err  error
+}
+
+// dedupCache holds the outputs of the contents detected by a batch.
+type dedupCache struct {
+	headSize int
+	mu       sync.Mutex
+	entries  map[dedupKey]*dedupEntry
+}
+
+func newDedupCache(headSize int) *dedupCache {
+	return &dedupCache{headSize: headSize, entries: map[dedupKey]*dedupEntry{}}
+}
+
+// detect returns the output of detect for the file at path, or that of a
+// file with the same content detected before, waiting for it if it is
+// still being detected. Files whose content cannot be hashed are detected.
+func (c *dedupCache) detect(ctx context.Context, path string, detect func() (string, error)) (string, error) {
+	key, ok := c.key(path)
+	if !ok {
+		return detect()
+	}
+	c.mu.Lock()
+	entry, found := c.entries[key]
+	if !found {
+		entry = &dedupEntry{done: make(chan struct{})}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+	if found {
+		select {
+		case <-entry.done:
+			return entry.raw, entry.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	entry.raw, entry.err = detect()
+	close(entry.done)
+	return entry.raw, entry.err
+}
+
+// key hashes the content of the file at path, if it is a regular file that
+// can be read.
+func (c *dedupCache) key(path string) (dedupKey, bool) {
+	info, err := os.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return dedupKey{}, false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return dedupKey{}, false
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if c.headSize > 0 {
+		r = io.LimitReader(f, int64(c.headSize))
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return dedupKey{}, false
+	}
+	key := dedupKey{size: info.Size(), mode: info.Mode() & (fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky)}
+	h.Sum(key.sum[:0])
+	return key, true
+}
+
+// detectFile detects the file at path, through the cache of o if any.
+func (d *MagicDetector) detectFile(ctx context.Context, path string, o batchOptions) (string, error) {
+	if o.dedupCache == nil {
+		return d.DetectFileCtx(ctx, path)
+	}
+	return o.dedupCache.detect(ctx, path, func() (string, error) { return d.DetectFileCtx(ctx, path) })
+}
@@ -0,0 +1,96 @@
+package libmagic
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDedupCache() {
+	t := s.T()
+	dir := t.TempDir()
+	write := func(name string, content []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, content, 0o600))
+		return path
+	}
+	a := write("a.png", pngHeader)
+	b := write("b.png", pngHeader)
+	c := write("c.png", append(bytes.Clone(pngHeader), 'x'))
+	link := filepath.Join(dir, "link")
+	require.NoError(t, os.Symlink(a, link))
+	setuid := write("setuid.png", pngHeader)
+	require.NoError(t, os.Chmod(setuid, 0o600|os.ModeSetuid))
+
+	var calls atomic.Int32
+	detect := func() (string, error) {
+		calls.Add(1)
+		return "PNG image data", nil
+	}
+	cache := newDedupCache(0)
+	for _, path := range []string{a, b, c, link, link, setuid} {
+		raw, err := cache.detect(context.Background(), path, detect)
+		require.NoError(t, err)
+		assert.Equal(t, "PNG image data", raw)
+	}
+	assert.EqualValues(t, 5, calls.Load(), "setuid differs in mode")
+
+	calls.Store(0)
+	cache = newDedupCache(len(pngHeader))
+	for _, path := range []string{a, b, c, filepath.Join(dir, "missing")} {
+		_, err := cache.detect(context.Background(), path, detect)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 3, calls.Load(), "c differs in size")
+}
+
+func (s *MagicTestSuite) TestDetectorWithDeduplication() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(2), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	root := t.TempDir()
+	var paths []string
+	for _, dir := range []string{"a", "b"} {
+		require.NoError(t, os.Mkdir(filepath.Join(root, dir), 0o700))
+		for _, name := range []string{"image.png", "copy.png", "notes.txt"} {
+			content := pngHeader
+			if name == "notes.txt" {
+				content = []byte("hello world\n")
+			}
+			path := filepath.Join(root, dir, name)
+			require.NoError(t, os.WriteFile(path, content, 0o600))
+			paths = append(paths, path)
+		}
+	}
+
+	// A setuid copy is reported as such.
+	setuid := filepath.Join(root, "a", "setuid.png")
+	require.NoError(t, os.WriteFile(setuid, pngHeader, 0o600))
+	require.NoError(t, os.Chmod(setuid, 0o600|os.ModeSetuid))
+	paths = append(paths, setuid)
+
+	want, err := detector.DetectFiles(paths)
+	require.NoError(t, err)
+	got, err := detector.DetectFiles(paths, WithDeduplication(0))
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.True(t, strings.HasPrefix(got[len(got)-1].Description, "setuid PNG"), got[len(got)-1].Description)
+
+	var scanned []Result
+	for _, r := range detector.Scan(root, WithDeduplication(64)) {
+		scanned = append(scanned, r)
+	}
+	require.Len(t, scanned, len(paths))
+	for _, r := range scanned {
+		require.NoError(t, r.Err)
+		assert.Equal(t, filepath.Ext(r.Path) == ".png", strings.Contains(r.Description, "PNG"), r.Path)
+	}
+}
@@ -0,0 +1,38 @@
+package libmagic
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// Detect detects v, which is one of:
+//   - a string, the path of a file, see DetectFile
+//   - a []byte, the content itself, see DetectBuffer
+//   - an *os.File or another fs.File, whose content is read from its start
+//     if it is a regular file and from its current position otherwise, see
+//     DetectFS
+//   - an io.Reader, see DetectReader
+//
+// Other types fail with an error.
+func (d *MagicDetector) Detect(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return d.DetectFile(v)
+	case []byte:
+		return d.DetectBuffer(v)
+	case *os.File:
+		return d.detectOpenFile(v, v.Name())
+	case fs.File:
+		name := ""
+		if info, err := v.Stat(); err == nil {
+			name = info.Name()
+		}
+		return d.detectOpenFile(v, name)
+	case io.Reader:
+		return d.DetectReader(v)
+	default:
+		return "", fmt.Errorf("cannot detect %T", v)
+	}
+}
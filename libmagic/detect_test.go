@@ -0,0 +1,56 @@
+package libmagic
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectorDetect() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType),
+		WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	path := filepath.Join(t.TempDir(), "image")
+	require.NoError(t, os.WriteFile(path, pngHeader, 0o600))
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	fsFile, err := fstest.MapFS{"image": {Data: pngHeader}}.Open("image")
+	require.NoError(t, err)
+	defer fsFile.Close()
+
+	tests := []struct {
+		name  string
+		input any
+	}{
+		{name: "path", input: path},
+		{name: "bytes", input: pngHeader},
+		{name: "os file", input: file},
+		{name: "fs file", input: fsFile},
+		{name: "reader", input: bytes.NewReader(pngHeader)},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			result, err := detector.Detect(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, "image/png", result)
+		})
+	}
+
+	dir, err := os.Open(t.TempDir())
+	require.NoError(t, err)
+	defer dir.Close()
+	_, err = detector.Detect(dir)
+	assert.ErrorIs(t, err, syscall.EISDIR)
+
+	_, err = detector.Detect(42)
+	assert.EqualError(t, err, "cannot detect int")
+}
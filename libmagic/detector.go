@@ -0,0 +1,508 @@
+package libmagic
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Detector detects the type of content. It is implemented by MagicDetector;
+// applications can depend on it instead to swap in fakes or other backends.
+type Detector interface {
+	DetectFile(path string) (string, error)
+	DetectBytes(content []byte) (string, error)
+	DetectReader(r io.Reader) (string, error)
+	Close()
+}
+
+var _ Detector = (*MagicDetector)(nil)
+
+// MagicDetector runs detections on a set of handles sharing the same
+// databases. Go has no goroutine identity to pin handles to, so each call
+// starts at a random slot and takes the first idle handle with a non-blocking
+// TryLock: as long as a handle is free, concurrent callers never wait on each
+// other and, apart from the handle they lock, write no shared memory. Only
+// when every handle is busy does a caller block until one is released.
+type MagicDetector struct {
+	size  int
+	flags Flags
+	set   atomic.Pointer[handleSet]
+	// inflight limits the libmagic calls running at the same time, including
+	// abandoned ones; nil means no limit.
+	inflight chan struct{}
+	// scratchSize and scratchMax are set by WithScratchSize.
+	scratchSize, scratchMax int
+	// files or buffers are the databases loaded by NewDetector.
+	files   []string
+	buffers [][]byte
+	lazy    bool
+	// deferred postpones loading to the first use, see WithLoadOnFirstUse.
+	deferred bool
+	loadOnce sync.Once
+	loadErr  error
+	closed   atomic.Bool
+	// logger receives debug events, see WithLogger.
+	logger *slog.Logger
+	// normalizeMIME is set by WithMIMENormalization.
+	normalizeMIME bool
+	// hooks are set by WithResultHooks.
+	hooks []func(Result) Result
+	// matchersBefore and matchersAfter are set by WithMatchersBefore and
+	// WithMatchersAfter.
+	matchersBefore, matchersAfter []Matcher
+	// refineZip is set by WithZipRefinement.
+	refineZip bool
+	// strength is set by WithStrength, provenance by WithProvenance.
+	strength   bool
+	provenance bool
+	// matchOffset is set by WithDebugMatchOffset, truncation by
+	// WithTruncationReport.
+	matchOffset bool
+	truncation  bool
+	// detectLanguage is set by WithLanguageDetection.
+	detectLanguage bool
+}
+
+// DetectorOption configures a MagicDetector created by NewDetector.
+type DetectorOption func(*MagicDetector)
+
+// handleSet is one generation of MagicDetector handles. Detections hold the
+// lock of the handle they use; retiring the set closes the handles, which
+// waits for those locks.
+type handleSet struct {
+	// template is loaded with the databases and only used to clone the
+	// handles doing the work, so cloning never waits for a detection.
+	template *Magic
+	slots    []atomic.Pointer[Magic]
+	// wake is signaled when a handle is released while waiters is not zero.
+	wake    chan struct{}
+	waiters atomic.Int32
+	// spare holds transient handles handed out by MagicDetector.Acquire.
+	spare   sync.Pool
+	retired atomic.Bool
+	// done is closed when the set is retired.
+	done chan struct{}
+	// lazy sets start with empty slots, filled by grow when needed.
+	lazy bool
+	// database is the colon separated list of loaded database files, empty
+	// for databases loaded from buffers.
+	database string
+	// files are the loaded database files, nil for buffers; ruleIndex is
+	// built from their listing by loadRules once rulesOnce runs, on the
+	// first Result of WithStrength or WithProvenance.
+	files     []string
+	rulesOnce sync.Once
+	ruleIndex *ruleIndex
+}
+
+// errRetired is returned internally when a set was retired by Reload while
+// leasing one of its handles.
+var errRetired = errors.New("handle set retired")
+
+// lease is a handle borrowed from a slot of a handleSet. While it is held,
+// the handle lock is taken.
+type lease struct {
+	set  *handleSet
+	slot int
+	m    *Magic
+}
+
+// NewDetector creates a MagicDetector configured by opts. By default it has
+// runtime.GOMAXPROCS(0) handles opened with MagicNone and loaded with the
+// default database; see WithPoolSize, WithFlags and WithDatabases. The
+// databases are read once and shared by all handles, see Clone.
+func NewDetector(opts ...DetectorOption) (*MagicDetector, error) {
+	d := &MagicDetector{}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if d.size <= 0 {
+		d.size = runtime.GOMAXPROCS(0)
+	}
+	if d.deferred {
+		return d, nil
+	}
+	set, err := d.newHandleSet(d.files, d.buffers)
+	if err != nil {
+		return nil, err
+	}
+	d.set.Store(set)
+	return d, nil
+}
+
+// currentSet returns the current handle set, loading it first if that was
+// deferred by WithLoadOnFirstUse.
+func (d *MagicDetector) currentSet() (*handleSet, error) {
+	if set := d.set.Load(); set != nil {
+		return set, nil
+	}
+	if !d.deferred || d.closed.Load() {
+		return nil, ErrClosed
+	}
+	d.loadOnce.Do(func() {
+		set, err := d.newHandleSet(d.files, d.buffers)
+		if err != nil {
+			d.loadErr = err
+			return
+		}
+		if !d.set.CompareAndSwap(nil, set) {
+			// Loaded by Reload meanwhile.
+			set.retire()
+			return
+		}
+		if d.closed.Load() && d.set.CompareAndSwap(set, nil) {
+			set.retire()
+		}
+	})
+	if set := d.set.Load(); set != nil {
+		return set, nil
+	}
+	if d.loadErr != nil && !d.closed.Load() {
+		return nil, d.loadErr
+	}
+	return nil, ErrClosed
+}
+
+// Size returns the number of handles of the MagicDetector.
+func (d *MagicDetector) Size() int {
+	return d.size
+}
+
+// DetectFile detects the content of the file at path.
+func (d *MagicDetector) DetectFile(path string) (string, error) {
+	return d.DetectFileCtx(context.Background(), path)
+}
+
+// DetectBuffer detects content.
+func (d *MagicDetector) DetectBuffer(content []byte) (string, error) {
+	return d.DetectBufferCtx(context.Background(), content)
+}
+
+// DetectBytes detects content, like DetectBuffer.
+func (d *MagicDetector) DetectBytes(content []byte) (string, error) {
+	return d.DetectBuffer(content)
+}
+
+// DetectReader detects the content of r from its first DefaultHeadSize bytes,
+// see DetectReaderHead.
+func (d *MagicDetector) DetectReader(r io.Reader) (string, error) {
+	return d.DetectReaderHead(r, 0)
+}
+
+// DetectFileCtx is like DetectFile but gives up when ctx is done, whether the
+// call is still waiting for an idle handle or already running. libmagic
+// calls cannot be interrupted, so a handle stuck in a call is taken out of
+// the MagicDetector and replaced by a fresh one; it is closed once the call
+// eventually returns.
+func (d *MagicDetector) DetectFileCtx(ctx context.Context, path string) (string, error) {
+	return d.detect(ctx, slog.String("path", path), func(m *Magic) (string, error) { return m.magicFile(path) })
+}
+
+// DetectBufferCtx is like DetectFileCtx but detects content. After ctx is
+// done libmagic may still read content, so it must not be modified.
+func (d *MagicDetector) DetectBufferCtx(ctx context.Context, content []byte) (string, error) {
+	return d.detect(ctx, slog.Int("len", len(content)), func(m *Magic) (string, error) { return m.magicBuffer(content) })
+}
+
+// detect runs detect on a leased handle. input identifies what is detected
+// in log events.
+func (d *MagicDetector) detect(ctx context.Context, input slog.Attr, detect func(m *Magic) (string, error)) (string, error) {
+	if d.logger != nil && d.logger.Enabled(ctx, slog.LevelDebug) {
+		return d.logDetect(ctx, input, detect)
+	}
+	return d.run(ctx, detect)
+}
+
+func (d *MagicDetector) run(ctx context.Context, detect func(m *Magic) (string, error)) (string, error) {
+	if err := d.enter(ctx); err != nil {
+		return "", err
+	}
+	l, err := d.acquire(ctx)
+	if err != nil {
+		d.leave()
+		return "", err
+	}
+	if ctx.Done() == nil {
+		defer d.leave()
+		defer l.release()
+		return detect(l.m)
+	}
+
+	var (
+		raw  string
+		done = make(chan struct{})
+	)
+	go func() {
+		defer d.leave()
+		defer close(done)
+		raw, err = detect(l.m)
+	}()
+	select {
+	case <-done:
+		l.release()
+		return raw, err
+	case <-ctx.Done():
+		l.abandon(done)
+		return "", ctx.Err()
+	}
+}
+
+// Reload loads files into a fresh set of handles and atomically swaps it in.
+// Detections keep running while the new handles are built; the ones already
+// running on the old handles finish there, after which the old handles are
+// closed. If loading fails, the MagicDetector keeps using the current
+// databases.
+func (d *MagicDetector) Reload(files []string) error {
+	set, err := d.newHandleSet(files, nil)
+	if err != nil {
+		return err
+	}
+	old := d.set.Swap(set)
+	if old != nil {
+		old.retire()
+		return nil
+	}
+	if d.closed.Load() {
+		if d.set.CompareAndSwap(set, nil) {
+			set.retire()
+		}
+		return ErrClosed
+	}
+	// Loading was deferred and has not happened yet.
+	return nil
+}
+
+// Close closes all handles, waiting for running detections to finish.
+// Detections on a closed MagicDetector fail with ErrClosed.
+func (d *MagicDetector) Close() {
+	d.closed.Store(true)
+	if set := d.set.Swap(nil); set != nil {
+		set.retire()
+	}
+}
+
+func (d *MagicDetector) newHandleSet(files []string, buffers [][]byte) (*handleSet, error) {
+	start := time.Now()
+	set, err := d.loadHandleSet(files, buffers)
+	d.logLoad(start, set, len(buffers), err)
+	return set, err
+}
+
+func (d *MagicDetector) loadHandleSet(files []string, buffers [][]byte) (*handleSet, error) {
+	template, err := NewMagic(d.flags)
+	if err != nil {
+		return nil, err
+	}
+	if buffers != nil {
+		err = template.MagicLoadBuffers(buffers)
+	} else {
+		err = template.MagicLoad(files)
+	}
+	if err != nil {
+		template.Close()
+		return nil, err
+	}
+	if d.scratchSize > 0 || d.scratchMax > 0 {
+		template.SetScratchSize(d.scratchSize, d.scratchMax)
+	}
+	set := &handleSet{
+		template: template,
+		slots:    make([]atomic.Pointer[Magic], d.size),
+		wake:     make(chan struct{}, d.size),
+		done:     make(chan struct{}),
+		lazy:     d.lazy,
+	}
+	if buffers == nil {
+		set.files = files
+		set.database = strings.Join(files, ":")
+		if set.database == "" {
+			set.database = DefaultDatabasePath()
+		}
+	}
+	if set.lazy {
+		return set, nil
+	}
+	for i := range set.slots {
+		m, err := template.Clone()
+		if err != nil {
+			set.retire()
+			return nil, err
+		}
+		set.slots[i].Store(m)
+	}
+	return set, nil
+}
+
+// acquire leases a handle of the current set, waiting until one is idle or
+// ctx is done.
+func (d *MagicDetector) acquire(ctx context.Context) (*lease, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for {
+		set, err := d.currentSet()
+		if err != nil {
+			return nil, err
+		}
+		l, err := set.acquire(ctx)
+		if err == errRetired {
+			// Swapped out by Reload; retry on the current set.
+			continue
+		}
+		return l, err
+	}
+}
+
+func (s *handleSet) acquire(ctx context.Context) (*lease, error) {
+	if l, err := s.tryAcquire(); l != nil || err != nil {
+		return l, err
+	}
+	if s.lazy {
+		if l, err := s.grow(); l != nil || err != nil {
+			return l, err
+		}
+	}
+	s.waiters.Add(1)
+	defer s.waiters.Add(-1)
+	for {
+		// Retried after registering as a waiter, so a release that saw no
+		// waiters cannot be missed.
+		if l, err := s.tryAcquire(); l != nil || err != nil {
+			return l, err
+		}
+		select {
+		case <-s.wake:
+		case <-s.done:
+			return nil, errRetired
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (s *handleSet) tryAcquire() (*lease, error) {
+	n := uint32(len(s.slots))
+	start := rand.Uint32()
+	for i := uint32(0); i < n; i++ {
+		slot := int((start + i) % n)
+		m := s.slots[slot].Load()
+		if m == nil || !m.lock.TryLock() {
+			continue
+		}
+		if m.handle == nil {
+			// Closed by retire.
+			m.lock.Unlock()
+			return nil, errRetired
+		}
+		if s.slots[slot].Load() != m {
+			// Replaced by abandon meanwhile.
+			m.lock.Unlock()
+			continue
+		}
+		return &lease{set: s, slot: slot, m: m}, nil
+	}
+	if s.retired.Load() {
+		return nil, errRetired
+	}
+	return nil, nil
+}
+
+// grow clones a handle into an empty slot and leases it, or returns nil if
+// every slot is filled.
+func (s *handleSet) grow() (*lease, error) {
+	for slot := range s.slots {
+		if s.slots[slot].Load() != nil {
+			continue
+		}
+		m, err := s.template.Clone()
+		if errors.Is(err, ErrClosed) {
+			return nil, errRetired
+		}
+		if err != nil {
+			return nil, err
+		}
+		m.lock.Lock()
+		if !s.slots[slot].CompareAndSwap(nil, m) {
+			// Filled by another caller meanwhile.
+			m.lock.Unlock()
+			m.Close()
+			continue
+		}
+		if s.retired.Load() {
+			// retire may have missed the new handle.
+			m.lock.Unlock()
+			m.Close()
+			return nil, errRetired
+		}
+		return &lease{set: s, slot: slot, m: m}, nil
+	}
+	return nil, nil
+}
+
+func (l *lease) release() {
+	l.m.lock.Unlock()
+	l.set.signal()
+}
+
+// abandon gives up on a handle stuck in a call that has not finished; done is
+// closed once it does. The handle is replaced in its slot by a fresh clone so
+// the MagicDetector keeps its capacity, and is closed in the background when
+// the call returns.
+func (l *lease) abandon(done <-chan struct{}) {
+	replacement, err := l.set.template.Clone()
+	if err != nil {
+		// Keep the handle and hand it back once the call returns.
+		go func() {
+			<-done
+			l.release()
+		}()
+		return
+	}
+	l.set.slots[l.slot].Store(replacement)
+	if l.set.retired.Load() {
+		// retire may have missed the replacement.
+		replacement.Close()
+	}
+	l.set.signal()
+	go func() {
+		<-done
+		l.m.lock.Unlock()
+		l.m.Close()
+	}()
+}
+
+func (s *handleSet) signal() {
+	if s.waiters.Load() == 0 {
+		return
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// retire waits for running detections and closes the handles.
+func (s *handleSet) retire() {
+	s.retired.Store(true)
+	close(s.done)
+	for i := range s.slots {
+		if m := s.slots[i].Load(); m != nil {
+			m.Close()
+		}
+	}
+	s.template.Close()
+	for {
+		m, ok := s.spare.Get().(*Magic)
+		if !ok {
+			break
+		}
+		m.Close()
+	}
+}
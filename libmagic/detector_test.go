@@ -0,0 +1,111 @@
+package libmagic
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetector() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(3), WithFlags(MagicMimeType|MagicError), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+	assert.Equal(t, 3, detector.Size())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := detector.DetectBuffer(pngHeader)
+			assert.NoError(t, err)
+			assert.Equal(t, "image/png", result)
+		}()
+	}
+	wg.Wait()
+
+	_, err = detector.DetectFile("../testdata/nonexist.mgc")
+	assert.Error(t, err)
+}
+
+func (s *MagicTestSuite) TestNewDetectorInvalidDatabase() {
+	t := s.T()
+	t.Parallel()
+	_, err := NewDetector(WithPoolSize(2), WithFlags(MagicNone), WithDatabases("../testdata/nonexist.mgc"))
+	assert.Error(t, err)
+}
+
+func (s *MagicTestSuite) TestDetectorReload() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(2), WithFlags(MagicMimeType|MagicError), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	var (
+		wg   sync.WaitGroup
+		stop = make(chan struct{})
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				_, err := detector.DetectBuffer(pngHeader)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	require.NoError(t, detector.Reload([]string{"../testdata/lua"}))
+	close(stop)
+	wg.Wait()
+
+	result, err := detector.DetectBuffer([]byte("#!/usr/bin/env lua\nprint(1)\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "text/x-lua", result)
+
+	assert.Error(t, detector.Reload([]string{"../testdata/nonexist.mgc"}))
+	result, err = detector.DetectBuffer([]byte("#!/usr/bin/env lua\nprint(1)\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "text/x-lua", result)
+}
+
+func (s *MagicTestSuite) TestDetectorClose() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType|MagicError), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	detector.Close()
+	detector.Close()
+
+	_, err = detector.DetectBuffer(pngHeader)
+	assert.ErrorIs(t, err, ErrClosed)
+	assert.ErrorIs(t, detector.Reload([]string{"../testdata/magic.mgc"}), ErrClosed)
+}
+
+func (s *MagicTestSuite) TestDetectorInterface() {
+	t := s.T()
+	t.Parallel()
+	var detector Detector
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	result, err := detector.DetectBytes(pngHeader)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+	result, err = detector.DetectReader(bytes.NewReader(pngHeader))
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+	_, err = detector.DetectFile("../testdata/magic.mgc")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,136 @@
+package libmagic
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrNotELF is returned by DetectELF for content that is not an ELF file.
+var ErrNotELF = errors.New("not an ELF file")
+
+// Linking tells how an ELF file is linked.
+type Linking int
+
+const (
+	// LinkingUnknown is used for files libmagic tells no linking for, such
+	// as relocatable objects.
+	LinkingUnknown Linking = iota
+	// LinkingStatic is a statically linked file, including static PIEs.
+	LinkingStatic
+	// LinkingDynamic is a dynamically linked file.
+	LinkingDynamic
+)
+
+func (l Linking) String() string {
+	switch l {
+	case LinkingStatic:
+		return "static"
+	case LinkingDynamic:
+		return "dynamic"
+	}
+	return "unknown"
+}
+
+// ELF holds the details libmagic gives about an ELF file, such as
+// "ELF 64-bit LSB pie executable, x86-64, version 1 (SYSV), dynamically
+// linked, interpreter /lib64/ld-linux-x86-64.so.2, ..., stripped".
+type ELF struct {
+	// Class is 32 or 64.
+	Class int
+	// ByteOrder is binary.LittleEndian or binary.BigEndian.
+	ByteOrder binary.ByteOrder
+	// Type is the object type, e.g. "executable", "pie executable",
+	// "shared object", "relocatable" or "core file".
+	Type string
+	// Arch is the machine architecture, e.g. "x86-64" or "ARM aarch64".
+	Arch string
+	// OSABI is the ABI, e.g. "SYSV" or "GNU/Linux".
+	OSABI string
+	// Linking tells whether the file is statically or dynamically linked.
+	Linking Linking
+	// Interpreter is the program interpreter of dynamically linked
+	// executables, e.g. "/lib64/ld-linux-x86-64.so.2".
+	Interpreter string
+	// BuildID is the GNU build ID in hex, if any.
+	BuildID string
+	// Stripped tells whether the symbol table was removed, DebugInfo whether
+	// the file has DWARF debugging information.
+	Stripped  bool
+	DebugInfo bool
+	// Description is the description ELF was parsed from.
+	Description string
+}
+
+var (
+	// elfHeader matches the start of ELF descriptions.
+	elfHeader = regexp.MustCompile(`^ELF (32|64)-bit (LSB|MSB) (.+)$`)
+	// elfVersion matches the version and ABI part, such as
+	// "version 1 (SYSV)" or "EABI5 version 1 (SYSV)".
+	elfVersion = regexp.MustCompile(`version \d+ \((.*)\)$`)
+)
+
+// ParseELF parses the description libmagic prints for an ELF file. It
+// returns ErrNotELF if description is not one.
+func ParseELF(description string) (ELF, error) {
+	parts := strings.Split(description, ", ")
+	match := elfHeader.FindStringSubmatch(parts[0])
+	if match == nil {
+		return ELF{}, fmt.Errorf("%q: %w", description, ErrNotELF)
+	}
+	e := ELF{Type: match[3], Description: description}
+	e.Class, _ = strconv.Atoi(match[1])
+	e.ByteOrder = binary.LittleEndian
+	if match[2] == "MSB" {
+		e.ByteOrder = binary.BigEndian
+	}
+	if len(parts) > 1 && !elfVersion.MatchString(parts[1]) {
+		e.Arch = parts[1]
+	}
+	for _, part := range parts[1:] {
+		switch {
+		case part == "dynamically linked":
+			e.Linking = LinkingDynamic
+		case part == "statically linked", part == "static-pie linked":
+			e.Linking = LinkingStatic
+		case part == "stripped":
+			e.Stripped = true
+		case part == "with debug_info":
+			e.DebugInfo = true
+		case strings.HasPrefix(part, "interpreter "):
+			e.Interpreter = strings.TrimPrefix(part, "interpreter ")
+		case strings.HasPrefix(part, "BuildID["):
+			_, e.BuildID, _ = strings.Cut(part, "=")
+		default:
+			if match := elfVersion.FindStringSubmatch(part); match != nil {
+				e.OSABI = match[1]
+			}
+		}
+	}
+	return e, nil
+}
+
+// DetectELF detects the file at path and returns its ELF details, or
+// ErrNotELF if it is not an ELF file. The description is requested for this
+// call whatever the flags of the MagicDetector, see DetectFileWithFlags.
+func (d *MagicDetector) DetectELF(path string) (ELF, error) {
+	description, err := d.DetectFileWithFlags(path, d.flags&^(outputFlags|MagicContinue))
+	if err != nil {
+		return ELF{}, err
+	}
+	return ParseELF(description)
+}
+
+// DetectELFBuffer is like DetectELF but detects content. libmagic only reads
+// the program and section headers of files, so the details are limited to
+// Class, ByteOrder, Type, Arch and OSABI.
+func (d *MagicDetector) DetectELFBuffer(content []byte) (ELF, error) {
+	description, err := d.DetectBufferWithFlags(content, d.flags&^(outputFlags|MagicContinue))
+	if err != nil {
+		return ELF{}, err
+	}
+	return ParseELF(description)
+}
@@ -0,0 +1,107 @@
+package libmagic
+
+import (
+	"encoding/binary"
+	"os"
+	"runtime"
+	"strconv"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestParseELF() {
+	t := s.T()
+	tests := []struct {
+		description string
+		want        ELF
+	}{
+		{
+			description: "ELF 64-bit LSB pie executable, x86-64, version 1 (SYSV), dynamically linked, interpreter /lib64/ld-linux-x86-64.so.2, BuildID[sha1]=15dfff3239aa7c3b16a71e6b2e3b6e4009dab998, for GNU/Linux 3.2.0, stripped",
+			want: ELF{
+				Class:       64,
+				ByteOrder:   binary.LittleEndian,
+				Type:        "pie executable",
+				Arch:        "x86-64",
+				OSABI:       "SYSV",
+				Linking:     LinkingDynamic,
+				Interpreter: "/lib64/ld-linux-x86-64.so.2",
+				BuildID:     "15dfff3239aa7c3b16a71e6b2e3b6e4009dab998",
+				Stripped:    true,
+			},
+		},
+		{
+			description: "ELF 64-bit LSB executable, x86-64, version 1 (SYSV), statically linked, Go BuildID=HJX0JcoX/dLbMpfcX5, BuildID[sha1]=09350ee6c9629afec4f1062e29230cc02f8e7eb8, not stripped",
+			want: ELF{
+				Class:     64,
+				ByteOrder: binary.LittleEndian,
+				Type:      "executable",
+				Arch:      "x86-64",
+				OSABI:     "SYSV",
+				Linking:   LinkingStatic,
+				BuildID:   "09350ee6c9629afec4f1062e29230cc02f8e7eb8",
+			},
+		},
+		{
+			description: "ELF 32-bit MSB relocatable, MIPS, MIPS-I version 1 (SYSV), with debug_info, not stripped",
+			want: ELF{
+				Class:     32,
+				ByteOrder: binary.BigEndian,
+				Type:      "relocatable",
+				Arch:      "MIPS",
+				OSABI:     "SYSV",
+				DebugInfo: true,
+			},
+		},
+		{
+			description: "ELF 64-bit LSB core file, x86-64, version 1 (SYSV), SVR4-style, from 'bash'",
+			want: ELF{
+				Class:     64,
+				ByteOrder: binary.LittleEndian,
+				Type:      "core file",
+				Arch:      "x86-64",
+				OSABI:     "SYSV",
+			},
+		},
+	}
+	for _, tt := range tests {
+		e, err := ParseELF(tt.description)
+		require.NoError(t, err)
+		tt.want.Description = tt.description
+		assert.Equal(t, tt.want, e)
+	}
+
+	_, err := ParseELF("PNG image data, 1 x 1, 8-bit/color RGBA, non-interlaced")
+	assert.ErrorIs(t, err, ErrNotELF)
+	assert.Equal(t, "dynamic", LinkingDynamic.String())
+}
+
+func (s *MagicTestSuite) TestDetectorDetectELF() {
+	t := s.T()
+	if runtime.GOOS != "linux" {
+		t.Skip("the test binary is not an ELF file")
+	}
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMime),
+		WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	executable, err := os.Executable()
+	require.NoError(t, err)
+	e, err := detector.DetectELF(executable)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.IntSize, e.Class)
+	assert.Contains(t, e.Type, "executable")
+	assert.NotEmpty(t, e.Arch)
+
+	content, err := os.ReadFile(executable)
+	require.NoError(t, err)
+	e2, err := detector.DetectELFBuffer(content)
+	require.NoError(t, err)
+	assert.Equal(t, e.Class, e2.Class)
+	assert.Equal(t, e.Arch, e2.Arch)
+	assert.Empty(t, e2.BuildID)
+
+	_, err = detector.DetectELFBuffer(pngHeader)
+	assert.ErrorIs(t, err, ErrNotELF)
+}
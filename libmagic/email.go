@@ -0,0 +1,135 @@
+package libmagic
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// maxMessageDepth bounds the nesting of multipart bodies.
+const maxMessageDepth = 16
+
+// MessagePart is a leaf part of an email or multipart body.
+type MessagePart struct {
+	// Section numbers the part as IMAP does, e.g. "1" or "2.1"; a body
+	// that is not multipart is section "1".
+	Section string
+	// ContentType is the declared media type, without parameters, and
+	// Filename the declared file name, if any.
+	ContentType string
+	Filename    string
+	// Attachment is set for parts with an attachment disposition.
+	Attachment bool
+	// Size is the size of the decoded content.
+	Size int64
+	// Result is the Result of detecting the decoded content, from its first
+	// DefaultHeadSize bytes; Path is left empty. Content that cannot be
+	// decoded has Err set.
+	Result Result
+}
+
+// DetectMessageParts parses a raw RFC 822 email from r, decodes the base64
+// or quoted-printable transfer encoding of each of its parts, nested
+// multipart ones included, and detects their content, as mail gateways
+// scanning attachments do. It returns the leaf parts in order; an error is
+// only returned if the message structure cannot be parsed, with the parts
+// before.
+func (d *MagicDetector) DetectMessageParts(r io.Reader) ([]MessagePart, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	return d.detectParts(textproto.MIMEHeader(msg.Header), msg.Body, "", 0, nil)
+}
+
+// DetectMultipartParts is like DetectMessageParts for a multipart body with
+// the given boundary, such as an HTTP form upload.
+func (d *MagicDetector) DetectMultipartParts(r io.Reader, boundary string) ([]MessagePart, error) {
+	return d.detectMultipart(multipart.NewReader(r, boundary), "", 0, nil)
+}
+
+// detectParts appends the parts of the entity with header and body, numbered
+// section, to parts.
+func (d *MagicDetector) detectParts(header textproto.MIMEHeader, body io.Reader, section string, depth int, parts []MessagePart) ([]MessagePart, error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+	if strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		if depth == maxMessageDepth {
+			return parts, fmt.Errorf("multipart nesting deeper than %d", maxMessageDepth)
+		}
+		return d.detectMultipart(multipart.NewReader(body, params["boundary"]), section, depth+1, parts)
+	}
+	if section == "" {
+		section = "1"
+	}
+	part := MessagePart{Section: section, ContentType: mediaType, Filename: params["name"]}
+	if disposition, params, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil {
+		part.Attachment = disposition == "attachment"
+		if params["filename"] != "" {
+			part.Filename = params["filename"]
+		}
+	}
+	counter := &countingReader{r: decodeTransfer(body, header.Get("Content-Transfer-Encoding"))}
+	part.Result, _ = d.DetectReaderResult(counter)
+	if part.Result.Err == nil {
+		if _, err := io.Copy(io.Discard, counter); err != nil {
+			part.Result.Err = err
+		}
+	}
+	part.Size = counter.n
+	return append(parts, part), nil
+}
+
+// detectMultipart appends the parts of mr, numbered within section, to parts.
+func (d *MagicDetector) detectMultipart(mr *multipart.Reader, section string, depth int, parts []MessagePart) ([]MessagePart, error) {
+	for i := 1; ; i++ {
+		p, err := mr.NextRawPart()
+		if err == io.EOF {
+			return parts, nil
+		}
+		if err != nil {
+			return parts, err
+		}
+		number := strconv.Itoa(i)
+		if section != "" {
+			number = section + "." + number
+		}
+		parts, err = d.detectParts(p.Header, p, number, depth, parts)
+		if err != nil {
+			return parts, err
+		}
+	}
+}
+
+// decodeTransfer returns r decoded from the Content-Transfer-Encoding
+// encoding; 7bit, 8bit, binary and unknown encodings are left as they are.
+func decodeTransfer(r io.Reader, encoding string) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	}
+	return r
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
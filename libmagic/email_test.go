@@ -0,0 +1,92 @@
+package libmagic
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMessage() string {
+	png := base64.StdEncoding.EncodeToString(pngHeader)
+	var wrapped strings.Builder
+	for len(png) > 20 {
+		wrapped.WriteString(png[:20] + "\r\n")
+		png = png[20:]
+	}
+	wrapped.WriteString(png)
+	return "From: a@example.com\r\n" +
+		"To: b@example.com\r\n" +
+		"Subject: report\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=outer\r\n" +
+		"\r\n" +
+		"--outer\r\n" +
+		"Content-Type: multipart/alternative; boundary=inner\r\n" +
+		"\r\n" +
+		"--inner\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n" +
+		"\r\n" +
+		"Caf=C3=A9 report attached.\r\n" +
+		"--inner\r\n" +
+		"Content-Type: text/html\r\n" +
+		"\r\n" +
+		"<html><body><p>report</p></body></html>\r\n" +
+		"--inner--\r\n" +
+		"--outer\r\n" +
+		"Content-Type: image/jpeg; name=\"photo.jpg\"\r\n" +
+		"Content-Disposition: attachment; filename=\"photo.jpg\"\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		wrapped.String() + "\r\n" +
+		"--outer--\r\n"
+}
+
+func (s *MagicTestSuite) TestDetectorDetectMessageParts() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType),
+		WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	parts, err := detector.DetectMessageParts(strings.NewReader(testMessage()))
+	require.NoError(t, err)
+	require.Len(t, parts, 3)
+
+	assert.Equal(t, "1.1", parts[0].Section)
+	assert.Equal(t, "text/plain", parts[0].ContentType)
+	assert.Equal(t, "text/plain", parts[0].Result.MIME)
+	assert.EqualValues(t, len("Café report attached."), parts[0].Size)
+
+	assert.Equal(t, "1.2", parts[1].Section)
+	assert.Equal(t, "text/html", parts[1].Result.MIME)
+
+	assert.Equal(t, "2", parts[2].Section)
+	assert.Equal(t, "image/jpeg", parts[2].ContentType)
+	assert.Equal(t, "photo.jpg", parts[2].Filename)
+	assert.True(t, parts[2].Attachment)
+	assert.Equal(t, "image/png", parts[2].Result.MIME)
+	assert.EqualValues(t, len(pngHeader), parts[2].Size)
+
+	parts, err = detector.DetectMessageParts(strings.NewReader("Subject: hi\r\n\r\nhello world\r\n"))
+	require.NoError(t, err)
+	require.Len(t, parts, 1)
+	assert.Equal(t, "1", parts[0].Section)
+	assert.Equal(t, "text/plain", parts[0].ContentType)
+
+	body := "--b\r\nContent-Disposition: form-data; name=\"file\"; filename=\"x.png\"\r\n\r\n" +
+		string(pngHeader) + "\r\n--b--\r\n"
+	parts, err = detector.DetectMultipartParts(strings.NewReader(body), "b")
+	require.NoError(t, err)
+	require.Len(t, parts, 1)
+	assert.Equal(t, "x.png", parts[0].Filename)
+	assert.Equal(t, "image/png", parts[0].Result.MIME)
+
+	parts, err = detector.DetectMessageParts(strings.NewReader("Content-Type: image/png\r\n" +
+		"Content-Transfer-Encoding: base64\r\n\r\n!!!!\r\n"))
+	require.NoError(t, err)
+	require.Len(t, parts, 1)
+	assert.Error(t, parts[0].Result.Err)
+}
@@ -0,0 +1,102 @@
+package libmagic
+
+import (
+	"bytes"
+	"strings"
+)
+
+// maxEmbedded bounds, per signature, the candidate offsets ScanEmbedded
+// detects, so content full of signatures cannot make it call libmagic
+// without end. As the bound is per signature, cheap fakes of one signature,
+// which libmagic may well confirm, do not hide content starting with
+// another.
+const maxEmbedded = 64
+
+// embeddedSignatures start formats commonly found appended to or hidden in
+// other content.
+var embeddedSignatures = [][]byte{
+	[]byte("PK\x03\x04"),
+	[]byte("\x89PNG\r\n\x1a\n"),
+	[]byte("\xff\xd8\xff"),
+	[]byte("GIF87a"),
+	[]byte("GIF89a"),
+	[]byte("%PDF-"),
+	[]byte("\x1f\x8b\x08"),
+	[]byte("BZh"),
+	[]byte("\xfd7zXZ\x00"),
+	[]byte("\x28\xb5\x2f\xfd"),
+	[]byte("7z\xbc\xaf\x27\x1c"),
+	[]byte("Rar!\x1a\x07"),
+	[]byte("\x7fELF"),
+	[]byte("OggS"),
+	[]byte("RIFF"),
+}
+
+// Embedded is content found by ScanEmbedded inside a buffer.
+type Embedded struct {
+	// Offset is where the embedded content starts in the buffer.
+	Offset int
+	// Result is the Result of the buffer from Offset on.
+	Result Result
+}
+
+// ScanEmbedded looks through buf for the signatures of well-known formats,
+// such as zip, PNG, JPEG, PDF or ELF, at non-zero offsets, and returns those
+// libmagic confirms by detecting buf from there, in offset order; e.g. a zip
+// archive appended to a JPEG image. Signatures libmagic takes for text are
+// not confirmed, and the members of an embedded zip archive are not
+// reported separately. At most 64 candidate offsets of each signature are
+// detected. Results have the MIME type and encoding set, whatever the flags
+// of the MagicDetector. The type of buf itself is not reported, see DetectBuffer.
+func (d *MagicDetector) ScanEmbedded(buf []byte) ([]Embedded, error) {
+	// next holds the next candidate offset of every signature, -1 once the
+	// signature is done.
+	next := make([]int, len(embeddedSignatures))
+	detected := make([]int, len(embeddedSignatures))
+	advance := func(sig, from int) {
+		next[sig] = -1
+		if i := bytes.Index(buf[min(from, len(buf)):], embeddedSignatures[sig]); i >= 0 {
+			next[sig] = from + i
+		}
+	}
+	for sig := range embeddedSignatures {
+		advance(sig, 1)
+	}
+
+	flags := d.flags&^outputFlags | MagicMime
+	var (
+		found []Embedded
+		inZip bool
+	)
+	for {
+		sig := -1
+		for i, offset := range next {
+			if offset >= 0 && (sig < 0 || offset < next[sig]) {
+				sig = i
+			}
+		}
+		if sig < 0 {
+			break
+		}
+		offset := next[sig]
+		advance(sig, offset+1)
+		content := buf[offset:]
+		if inZip && bytes.HasPrefix(content, zipSignature) {
+			continue
+		}
+		if detected[sig]++; detected[sig] == maxEmbedded {
+			next[sig] = -1
+		}
+		raw, err := d.DetectBufferWithFlags(content, flags)
+		if err != nil {
+			return found, err
+		}
+		r := newResult("", raw, flags, nil)
+		if unrecognized(raw) || strings.HasPrefix(r.MIME, "text/") {
+			continue
+		}
+		inZip = inZip || bytes.HasPrefix(content, zipSignature)
+		found = append(found, Embedded{Offset: offset, Result: d.finishContent(r, content)})
+	}
+	return found, nil
+}
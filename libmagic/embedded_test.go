@@ -0,0 +1,50 @@
+package libmagic
+
+import (
+	"bytes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectorScanEmbedded() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType),
+		WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	zipped := zipContent(t, "a.txt", "b.txt")
+	var buf bytes.Buffer
+	buf.Write(pngHeader)
+	buf.Write(bytes.Repeat([]byte{0}, 100))
+	zipOffset := buf.Len()
+	buf.Write(zipped)
+	buf.WriteString("trailing RIFF that is not a RIFF file")
+
+	found, err := detector.ScanEmbedded(buf.Bytes())
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, zipOffset, found[0].Offset)
+	assert.Equal(t, "application/zip", found[0].Result.MIME)
+
+	found, err = detector.ScanEmbedded(pngHeader)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	found, err = detector.ScanEmbedded(append([]byte("junk"), append(pngHeader, pngHeader...)...))
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, 4, found[0].Offset)
+	assert.Equal(t, 4+len(pngHeader), found[1].Offset)
+	assert.Equal(t, "image/png", found[1].Result.MIME)
+
+	// Signatures of other formats do not hide the appended zip.
+	padded := append(bytes.Repeat([]byte("BZh RIFF "), 4*maxEmbedded), zipped...)
+	found, err = detector.ScanEmbedded(padded)
+	require.NoError(t, err)
+	require.NotEmpty(t, found)
+	last := found[len(found)-1]
+	assert.Equal(t, len(padded)-len(zipped), last.Offset)
+	assert.Equal(t, "application/zip", last.Result.MIME)
+}
@@ -0,0 +1,17 @@
+package libmagic
+
+// DetectEncoding returns the character encoding of content as libmagic
+// reports it with MagicMimeEncoding, e.g. "utf-8", "us-ascii" or "binary",
+// whatever the output flags of the MagicDetector.
+func (d *MagicDetector) DetectEncoding(content []byte) (string, error) {
+	return d.DetectBufferWithFlags(content, d.encodingFlags())
+}
+
+// DetectFileEncoding is like DetectEncoding but detects the file at path.
+func (d *MagicDetector) DetectFileEncoding(path string) (string, error) {
+	return d.DetectFileWithFlags(path, d.encodingFlags())
+}
+
+func (d *MagicDetector) encodingFlags() Flags {
+	return d.flags&^outputFlags | MagicMimeEncoding
+}
@@ -0,0 +1,44 @@
+package libmagic
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectorDetectEncoding() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType),
+		WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+	}{
+		{name: "ascii", content: []byte("hello world\n"), want: "us-ascii"},
+		{name: "utf-8", content: []byte("héllo wörld\n"), want: "utf-8"},
+		{name: "binary", content: pngHeader, want: "binary"},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			encoding, err := detector.DetectEncoding(tt.content)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, encoding)
+
+			path := filepath.Join(t.TempDir(), "file")
+			require.NoError(t, os.WriteFile(path, tt.content, 0o600))
+			encoding, err = detector.DetectFileEncoding(path)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, encoding)
+		})
+	}
+
+	result, err := detector.DetectBuffer(pngHeader)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+}
@@ -0,0 +1,113 @@
+package libmagic
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// ErrUnsupported is returned by operations the linked libmagic is too old to
+// provide.
+var ErrUnsupported = errors.New("operation not supported by the linked libmagic")
+
+// ErrClosed is returned when using a Magic or MagicDetector after Close.
+var ErrClosed = errors.New("use of closed handle")
+
+// Classes of libmagic failures, matched with errors.Is against the errors
+// returned by Magic methods.
+var (
+	// ErrDatabaseNotFound means a database file to load does not exist.
+	ErrDatabaseNotFound = errors.New("database not found")
+	// ErrInvalidDatabase means a database could not be parsed or compiled.
+	ErrInvalidDatabase = errors.New("invalid database")
+	// ErrNotLoaded means a detection ran before any database was loaded.
+	ErrNotLoaded = errors.New("no database loaded")
+)
+
+// Error is returned by Magic methods when libmagic reports a failure.
+// It carries both the libmagic message and the errno value from
+// magic_errno(), so callers can use errors.Is against syscall.Errno values
+// such as syscall.ENOENT, or against fs.ErrNotExist. When the failure class
+// is known, errors.Is also matches Err, such as ErrInvalidDatabase.
+type Error struct {
+	// Op is the failing libmagic function, such as "magic_file".
+	Op string
+	// Path is the file or colon separated database list operated on, if any.
+	Path string
+	// Len is the length of the buffer operated on, if any.
+	Len     int
+	Context string
+	Message string
+	Errno   syscall.Errno
+	Err     error
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" && e.Errno != 0 {
+		msg = e.Errno.Error()
+	}
+	if msg == "" {
+		return e.Context
+	}
+	return e.Context + ": " + msg
+}
+
+// LogValue logs the fields of e that are set, for slog.
+func (e *Error) LogValue() slog.Value {
+	attrs := []slog.Attr{slog.String("op", e.Op)}
+	if e.Path != "" {
+		attrs = append(attrs, slog.String("path", e.Path))
+	}
+	if e.Len != 0 {
+		attrs = append(attrs, slog.Int("len", e.Len))
+	}
+	attrs = append(attrs, slog.String("message", e.Error()))
+	if e.Errno != 0 {
+		attrs = append(attrs, slog.Int("errno", int(e.Errno)))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// Unwrap returns Errno, or nil if libmagic reported no errno.
+func (e *Error) Unwrap() error {
+	if e.Errno == 0 {
+		return nil
+	}
+	return e.Errno
+}
+
+// Is reports whether Err, the failure class, matches target, so errors.Is
+// matches both the class and, through Unwrap, the errno.
+func (e *Error) Is(target error) bool {
+	return e.Err != nil && errors.Is(e.Err, target)
+}
+
+// databaseErrorClass tells why loading files failed: ErrDatabaseNotFound if
+// one of them does not exist, ErrInvalidDatabase otherwise. No files means
+// the default database.
+func databaseErrorClass(files []string) error {
+	if len(files) == 0 {
+		files = strings.Split(DefaultDatabasePath(), ":")
+	}
+	for _, file := range files {
+		if _, err := os.Stat(file); errors.Is(err, fs.ErrNotExist) {
+			if _, err := os.Stat(file + ".mgc"); errors.Is(err, fs.ErrNotExist) {
+				return ErrDatabaseNotFound
+			}
+		}
+	}
+	return ErrInvalidDatabase
+}
+
+// detectErrorClass returns ErrNotLoaded if a detection failed because no
+// database was loaded. The caller must hold m.lock.
+func (m *Magic) detectErrorClass() error {
+	if m.files == nil && m.buffers == nil {
+		return ErrNotLoaded
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package libmagic
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestErrorClasses() {
+	t := s.T()
+	t.Parallel()
+	magic, err := NewMagic(MagicError)
+	require.NoError(t, err)
+	defer magic.Close()
+
+	_, err = magic.MagicBuffer(pngHeader)
+	assert.ErrorIs(t, err, ErrNotLoaded)
+	var magicErr *Error
+	require.True(t, errors.As(err, &magicErr))
+	assert.Equal(t, ErrNotLoaded, magicErr.Err)
+	_, err = magic.MagicFile("../testdata/lua")
+	assert.ErrorIs(t, err, ErrNotLoaded)
+
+	err = magic.MagicLoad([]string{"../testdata/nonexist.mgc"})
+	assert.ErrorIs(t, err, ErrDatabaseNotFound)
+	err = magic.MagicLoad([]string{"../testdata/nonexist.mgc", "../testdata/nonexist2.mgc"})
+	assert.ErrorIs(t, err, ErrDatabaseNotFound)
+
+	invalid := filepath.Join(t.TempDir(), "invalid.mgc")
+	require.NoError(t, os.WriteFile(invalid, []byte("not a database"), 0o644))
+	err = magic.MagicLoad([]string{invalid})
+	assert.ErrorIs(t, err, ErrInvalidDatabase)
+	assert.NotErrorIs(t, err, ErrDatabaseNotFound)
+	err = magic.MagicLoadBuffers([][]byte{[]byte("not a database")})
+	assert.ErrorIs(t, err, ErrInvalidDatabase)
+
+	require.NoError(t, magic.MagicLoad([]string{"../testdata/magic.mgc"}))
+	_, err = magic.MagicFile("../testdata/nonexist")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.NotErrorIs(t, err, ErrNotLoaded)
+
+	magic.Close()
+	_, err = magic.MagicBuffer(pngHeader)
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func (s *MagicTestSuite) TestErrorFields() {
+	t := s.T()
+	t.Parallel()
+	magic, err := NewMagic(MagicError)
+	require.NoError(t, err)
+	defer magic.Close()
+
+	var magicErr *Error
+	_, err = magic.MagicBuffer(pngHeader)
+	require.True(t, errors.As(err, &magicErr))
+	assert.Equal(t, "magic_buffer", magicErr.Op)
+	assert.Equal(t, len(pngHeader), magicErr.Len)
+	assert.NotEmpty(t, magicErr.Message)
+
+	err = magic.MagicLoad([]string{"../testdata/nonexist.mgc"})
+	require.True(t, errors.As(err, &magicErr))
+	assert.Equal(t, "magic_load", magicErr.Op)
+	assert.Equal(t, "../testdata/nonexist.mgc", magicErr.Path)
+
+	require.NoError(t, magic.MagicLoad([]string{"../testdata/magic.mgc"}))
+	_, err = magic.MagicFile("../testdata/nonexist")
+	require.True(t, errors.As(err, &magicErr))
+	assert.Equal(t, "magic_file", magicErr.Op)
+	assert.Equal(t, "../testdata/nonexist", magicErr.Path)
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Error("detection failed", "err", err)
+	assert.Contains(t, buf.String(), "err.op=magic_file")
+	assert.Contains(t, buf.String(), "err.path=../testdata/nonexist")
+	assert.Contains(t, buf.String(), "err.errno=2")
+}
+
+func (s *MagicTestSuite) TestErrorUnwrap() {
+	t := s.T()
+	t.Parallel()
+	err := &Error{Op: "magic_load", Errno: syscall.ENOENT, Err: ErrDatabaseNotFound}
+	assert.Equal(t, syscall.ENOENT, errors.Unwrap(err))
+	assert.ErrorIs(t, err, ErrDatabaseNotFound)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.NotErrorIs(t, err, ErrInvalidDatabase)
+
+	err = &Error{Op: "magic_buffer", Err: ErrNotLoaded}
+	assert.Nil(t, errors.Unwrap(err))
+	assert.ErrorIs(t, err, ErrNotLoaded)
+}
@@ -0,0 +1,63 @@
+package libmagic
+
+import (
+	"strings"
+)
+
+// unknownExtension is what libmagic prints when MAGIC_EXTENSION is set but
+// the matching rule carries no extension list.
+const unknownExtension = "???"
+
+// MagicFileExtensions returns the file name extensions libmagic associates
+// with the content of filename, e.g. []string{"jpeg", "jpg", "jpe", "jfif"}.
+// An empty slice means no extensions are known for the detected type.
+func (m *Magic) MagicFileExtensions(filename string) ([]string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	restore, err := m.withExtensionFlags()
+	if err != nil {
+		return nil, err
+	}
+	defer restore()
+
+	result, err := m.magicFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return parseExtensions(result), nil
+}
+
+// MagicBufferExtensions is like MagicFileExtensions but detects content.
+func (m *Magic) MagicBufferExtensions(content []byte) ([]string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	restore, err := m.withExtensionFlags()
+	if err != nil {
+		return nil, err
+	}
+	defer restore()
+
+	result, err := m.magicBuffer(content)
+	if err != nil {
+		return nil, err
+	}
+	return parseExtensions(result), nil
+}
+
+// withExtensionFlags switches the handle to extension-only output and returns
+// a function restoring the previous flags. The caller must hold m.lock.
+func (m *Magic) withExtensionFlags() (func(), error) {
+	return m.withFlags(m.flags&^MagicNoDesc | MagicExtension)
+}
+
+func parseExtensions(result string) []string {
+	extensions := []string{}
+	for _, ext := range strings.Split(result, "/") {
+		ext = strings.TrimSpace(ext)
+		if ext == "" || ext == unknownExtension {
+			continue
+		}
+		extensions = append(extensions, ext)
+	}
+	return extensions
+}
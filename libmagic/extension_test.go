@@ -0,0 +1,45 @@
+package libmagic
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x02\x00\x00\x00")
+
+func (s *MagicTestSuite) TestMagicBufferExtensions() {
+	t := s.T()
+	t.Parallel()
+	tests := []struct {
+		name  string
+		input []byte
+		want  []string
+	}{
+		{
+			name:  "happy path",
+			input: pngHeader,
+			want:  []string{"png"},
+		},
+		{
+			name:  "unknown extension",
+			input: []byte("hello world\n"),
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			result, err := s.magic.MagicBufferExtensions(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+			assert.Equal(t, MagicMimeType|MagicError, s.magic.MagicGetFlags())
+		})
+	}
+}
+
+func (s *MagicTestSuite) TestParseExtensions() {
+	t := s.T()
+	t.Parallel()
+	assert.Equal(t, []string{"jpeg", "jpg", "jpe", "jfif"}, parseExtensions("jpeg/jpg/jpe/jfif"))
+	assert.Equal(t, []string{}, parseExtensions("???"))
+	assert.Equal(t, []string{}, parseExtensions(""))
+}
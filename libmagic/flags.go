@@ -0,0 +1,173 @@
+package libmagic
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// Flags is a set of Magic* flags, printed by name, such as
+// "mime-type|error".
+type Flags int
+
+// flagNames names the single-bit flags, in bit order.
+var flagNames = []struct {
+	flag Flags
+	name string
+}{
+	{MagicDebug, "debug"},
+	{MagicSymlink, "symlink"},
+	{MagicCompress, "compress"},
+	{MagicDevices, "devices"},
+	{MagicMimeType, "mime-type"},
+	{MagicContinue, "continue"},
+	{MagicCheck, "check"},
+	{MagicPreserveAtime, "preserve-atime"},
+	{MagicRaw, "raw"},
+	{MagicError, "error"},
+	{MagicMimeEncoding, "mime-encoding"},
+	{MagicApple, "apple"},
+	{MagicNoCheckCompress, "no-check-compress"},
+	{MagicNoCheckTar, "no-check-tar"},
+	{MagicNoCheckSoft, "no-check-soft"},
+	{MagicNoCheckAppType, "no-check-apptype"},
+	{MagicNoCheckElf, "no-check-elf"},
+	{MagicNoCheckText, "no-check-text"},
+	{MagicNoCheckCdf, "no-check-cdf"},
+	{MagicNoCheckCsv, "no-check-csv"},
+	{MagicNoCheckTokens, "no-check-tokens"},
+	{MagicNoCheckEncoding, "no-check-encoding"},
+	{MagicNoCheckJSON, "no-check-json"},
+	{MagicNoCheckSimh, "no-check-simh"},
+	{MagicExtension, "extension"},
+	{MagicCompressTransp, "compress-transp"},
+}
+
+// String returns the names of the flags in f joined by "|", such as
+// "mime-type|error", or "none" if f is empty. Unknown bits are printed in
+// hexadecimal.
+func (f Flags) String() string {
+	if f == MagicNone {
+		return "none"
+	}
+	var names []string
+	for _, n := range flagNames {
+		if f.Has(n.flag) {
+			names = append(names, n.name)
+			f = f.Without(n.flag)
+		}
+	}
+	if f != 0 {
+		names = append(names, fmt.Sprintf("%#x", int(f)))
+	}
+	return strings.Join(names, "|")
+}
+
+// ParseFlags parses flag names separated by "," or "|", such as
+// "mime-type,error,no-check-elf" or the output of Flags.String. Names are
+// case-insensitive; "none", "mime" for mime-type|mime-encoding and
+// hexadecimal values are accepted as well.
+func ParseFlags(s string) (Flags, error) {
+	var f Flags
+	for _, name := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '|' }) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch {
+		case name == "" || name == "none":
+			continue
+		case name == "mime":
+			f = f.With(MagicMime)
+			continue
+		case strings.HasPrefix(name, "0x"):
+			bits, err := strconv.ParseInt(name, 0, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid flag %q", name)
+			}
+			f = f.With(Flags(bits))
+			continue
+		}
+		flag, ok := lookupFlag(name)
+		if !ok {
+			return 0, fmt.Errorf("unknown flag %q", name)
+		}
+		f = f.With(flag)
+	}
+	return f, nil
+}
+
+func lookupFlag(name string) (Flags, bool) {
+	for _, n := range flagNames {
+		if n.name == name {
+			return n.flag, true
+		}
+	}
+	return 0, false
+}
+
+// Has reports whether all flags of flags are set in f.
+func (f Flags) Has(flags Flags) bool {
+	return f&flags == flags
+}
+
+// With returns f with flags set.
+func (f Flags) With(flags Flags) Flags {
+	return f | flags
+}
+
+// Without returns f with flags cleared.
+func (f Flags) Without(flags Flags) Flags {
+	return f &^ flags
+}
+
+// MagicFileWithFlags is like MagicFile but uses flags for this call only.
+// The flags of the handle are restored before it is unlocked, so concurrent
+// callers never observe them.
+func (m *Magic) MagicFileWithFlags(filename string, flags Flags) (string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	restore, err := m.withFlags(flags)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+	return m.magicFile(filename)
+}
+
+// MagicBufferWithFlags is like MagicFileWithFlags but detects content.
+func (m *Magic) MagicBufferWithFlags(content []byte, flags Flags) (string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	restore, err := m.withFlags(flags)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+	return m.magicBuffer(content)
+}
+
+// DetectFileWithFlags is like DetectFile but uses flags instead of the flags
+// of the MagicDetector for this call only, for example to get the MIME type
+// of some files and the description of others from the same MagicDetector.
+func (d *MagicDetector) DetectFileWithFlags(path string, flags Flags) (string, error) {
+	return d.detect(context.Background(), slog.String("path", path), func(m *Magic) (string, error) {
+		restore, err := m.withFlags(flags)
+		if err != nil {
+			return "", err
+		}
+		defer restore()
+		return m.magicFile(path)
+	})
+}
+
+// DetectBufferWithFlags is like DetectFileWithFlags but detects content.
+func (d *MagicDetector) DetectBufferWithFlags(content []byte, flags Flags) (string, error) {
+	return d.detect(context.Background(), slog.Int("len", len(content)), func(m *Magic) (string, error) {
+		restore, err := m.withFlags(flags)
+		if err != nil {
+			return "", err
+		}
+		defer restore()
+		return m.magicBuffer(content)
+	})
+}
@@ -0,0 +1,114 @@
+package libmagic
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestMagicWithFlags() {
+	t := s.T()
+	t.Parallel()
+	magic, err := NewMagic(MagicNone)
+	require.NoError(t, err)
+	defer magic.Close()
+	require.NoError(t, magic.MagicLoad([]string{"../testdata/magic.mgc"}))
+
+	result, err := magic.MagicBufferWithFlags(pngHeader, MagicMimeType)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+	assert.Equal(t, MagicNone, magic.MagicGetFlags())
+
+	result, err = magic.MagicBuffer(pngHeader)
+	assert.NoError(t, err)
+	assert.Contains(t, result, "PNG image data")
+
+	_, err = magic.MagicFileWithFlags("../testdata/nonexist.mgc", MagicError)
+	assert.Error(t, err)
+	assert.Equal(t, MagicNone, magic.MagicGetFlags())
+}
+
+func (s *MagicTestSuite) TestDetectWithFlags() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(2), WithFlags(MagicNone), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			result, err := detector.DetectBufferWithFlags(pngHeader, MagicMimeType)
+			assert.NoError(t, err)
+			assert.Equal(t, "image/png", result)
+		}()
+		go func() {
+			defer wg.Done()
+			result, err := detector.DetectBuffer(pngHeader)
+			assert.NoError(t, err)
+			assert.Contains(t, result, "PNG image data")
+		}()
+	}
+	wg.Wait()
+
+	result, err := detector.DetectFileWithFlags("../testdata/magic.mgc", MagicMimeType)
+	assert.NoError(t, err)
+	assert.NotContains(t, result, " ")
+}
+
+func (s *MagicTestSuite) TestFlags() {
+	t := s.T()
+	t.Parallel()
+	assert.Equal(t, "none", MagicNone.String())
+	assert.Equal(t, "mime-type|error", (MagicMimeType | MagicError).String())
+	assert.Equal(t, "mime-type|mime-encoding", MagicMime.String())
+	assert.Equal(t, "debug|0x80000000", (MagicDebug | 1<<31).String())
+	assert.Equal(t, "no-check-elf", fmt.Sprint(MagicNoCheckElf))
+
+	f := MagicMime
+	assert.True(t, f.Has(MagicMimeType))
+	assert.True(t, f.Has(MagicMime))
+	assert.False(t, f.Has(MagicMimeType|MagicError))
+	assert.Equal(t, MagicMime|MagicError, f.With(MagicError))
+	assert.Equal(t, MagicMimeEncoding, f.Without(MagicMimeType))
+
+	// The API takes and returns Flags, which print as names.
+	magic, err := NewMagic(f)
+	require.NoError(t, err)
+	defer magic.Close()
+	assert.Equal(t, f, magic.MagicGetFlags())
+	assert.Equal(t, "mime-type|mime-encoding", fmt.Sprint(magic.MagicGetFlags()))
+}
+
+func (s *MagicTestSuite) TestParseFlags() {
+	t := s.T()
+	t.Parallel()
+	f, err := ParseFlags("mime-type,error,no-check-elf")
+	assert.NoError(t, err)
+	assert.Equal(t, MagicMimeType|MagicError|MagicNoCheckElf, f)
+
+	f, err = ParseFlags(" Mime | continue ")
+	assert.NoError(t, err)
+	assert.Equal(t, MagicMime|MagicContinue, f)
+
+	for _, input := range []string{"", "none"} {
+		f, err = ParseFlags(input)
+		assert.NoError(t, err)
+		assert.Equal(t, MagicNone, f)
+	}
+
+	for _, flags := range []Flags{MagicNone, MagicMime | MagicError, MagicDebug | 1<<31, MagicNoCheckJSON | MagicExtension} {
+		f, err = ParseFlags(flags.String())
+		assert.NoError(t, err)
+		assert.Equal(t, flags, f)
+	}
+
+	_, err = ParseFlags("mime-type,bogus")
+	assert.ErrorContains(t, err, "bogus")
+	_, err = ParseFlags("0xzz")
+	assert.Error(t, err)
+}
@@ -0,0 +1,35 @@
+package libmagic
+
+import (
+	"io"
+	"io/fs"
+	"syscall"
+)
+
+// DetectFS detects the content of the file name in fsys, such as an embed.FS
+// or another virtual file system magic_file cannot reach. The file is read
+// as a buffer, up to DefaultHeadSize bytes, so only regular file content is
+// detected; directories fail with an error matching syscall.EISDIR.
+func (d *MagicDetector) DetectFS(fsys fs.FS, name string) (string, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return d.detectOpenFile(f, name)
+}
+
+// detectOpenFile detects the content of f, named name, like DetectFS.
+func (d *MagicDetector) detectOpenFile(f fs.File, name string) (string, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", &fs.PathError{Op: "detect", Path: name, Err: syscall.EISDIR}
+	}
+	if r, ok := f.(io.ReaderAt); ok && info.Mode().IsRegular() {
+		return d.DetectReaderAt(r, 0)
+	}
+	return d.DetectReaderHead(f, 0)
+}
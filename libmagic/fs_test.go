@@ -0,0 +1,40 @@
+package libmagic
+
+import (
+	"os"
+	"syscall"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectFS() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	fsys := fstest.MapFS{
+		"images/image.png": {Data: pngHeader},
+		"empty":            {},
+	}
+	result, err := detector.DetectFS(fsys, "images/image.png")
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+	result, err = detector.DetectFS(fsys, "empty")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result)
+
+	_, err = detector.DetectFS(fsys, "images")
+	assert.ErrorIs(t, err, syscall.EISDIR)
+	_, err = detector.DetectFS(fsys, "nonexist")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	expected, err := detector.DetectFile("../testdata/lua")
+	require.NoError(t, err)
+	result, err = detector.DetectFS(os.DirFS("../testdata"), "lua")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
@@ -0,0 +1,15 @@
+package libmagic
+
+// WithResultHooks registers hooks run, in order, on every Result of the
+// MagicDetector: those of DetectFileResult, DetectBufferResult,
+// DetectReaderResult, DetectAll, batch and asynchronous detections,
+// DetectStream, DetectWalk and Scan. Each hook gets the Result returned by
+// the previous one, after MIME normalization, and can normalize, enrich or
+// override it; Results with Err set are passed too. Hooks may be called
+// concurrently. APIs returning the libmagic output as a string are not
+// affected.
+func WithResultHooks(hooks ...func(Result) Result) DetectorOption {
+	return func(d *MagicDetector) {
+		d.hooks = append(d.hooks, hooks...)
+	}
+}
@@ -0,0 +1,63 @@
+package libmagic
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectorWithResultHooks() {
+	t := s.T()
+	var calls atomic.Int32
+	detector, err := NewDetector(WithPoolSize(2), WithFlags(MagicMimeType),
+		WithDatabases("../testdata/magic.mgc"), WithMIMENormalization(),
+		WithResultHooks(
+			func(r Result) Result {
+				calls.Add(1)
+				if r.MIME == "image/png" {
+					r.Description = "picture"
+				}
+				return r
+			},
+			func(r Result) Result {
+				r.Description += "!"
+				return r
+			},
+		))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	path := filepath.Join(t.TempDir(), "image")
+	require.NoError(t, os.WriteFile(path, pngHeader, 0o600))
+
+	result, err := detector.DetectFileResult(path)
+	require.NoError(t, err)
+	assert.Equal(t, "picture!", result.Description)
+	result, err = detector.DetectBufferResult(pngHeader)
+	require.NoError(t, err)
+	assert.Equal(t, "picture!", result.Description)
+	result, err = detector.DetectReaderResult(bytes.NewReader(pngHeader))
+	require.NoError(t, err)
+	assert.Equal(t, "picture!", result.Description)
+	result, err = detector.DetectAll(path)
+	require.NoError(t, err)
+	assert.Equal(t, "picture!", result.Description)
+
+	results, err := detector.DetectFiles([]string{path, path})
+	require.NoError(t, err)
+	for _, result := range results {
+		assert.Equal(t, "picture!", result.Description)
+	}
+	result = <-detector.DetectFileAsync(path)
+	assert.Equal(t, "picture!", result.Description)
+	assert.Equal(t, int32(7), calls.Load())
+
+	raw, err := detector.DetectFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", raw)
+	assert.Equal(t, int32(7), calls.Load())
+}
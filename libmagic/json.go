@@ -0,0 +1,131 @@
+package libmagic
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// resultJSON is the JSON schema of a Result, shared by API servers and
+// command line output. Fields other than path and error are always present.
+type resultJSON struct {
+	Path        string        `json:"path,omitempty"`
+	MIME        string        `json:"mime"`
+	Encoding    string        `json:"encoding"`
+	Description string        `json:"description"`
+	Extensions  []string      `json:"extensions"`
+	MatchedDB   string        `json:"matched_db"`
+	Container   string        `json:"container,omitempty"`
+	Strength    int           `json:"strength,omitempty"`
+	Rule        *ruleJSON     `json:"rule,omitempty"`
+	Offset      *int64        `json:"offset,omitempty"`
+	Examined    int           `json:"examined,omitempty"`
+	Truncated   bool          `json:"truncated,omitempty"`
+	NeedsMore   bool          `json:"needs_more,omitempty"`
+	Language    *languageJSON `json:"language,omitempty"`
+	Kind        string        `json:"kind,omitempty"`
+	Target      string        `json:"target,omitempty"`
+	Error       *errorJSON    `json:"error,omitempty"`
+}
+
+// ruleJSON is the JSON schema of a Rule.
+type ruleJSON struct {
+	Database    string `json:"database"`
+	Line        int    `json:"line"`
+	Description string `json:"description"`
+	MIME        string `json:"mime"`
+	Strength    int    `json:"strength"`
+}
+
+// languageJSON is the JSON schema of a Language.
+type languageJSON struct {
+	Name       string  `json:"name"`
+	MIME       string  `json:"mime"`
+	Confidence float64 `json:"confidence"`
+}
+
+// errorJSON is the JSON schema of an error. Fields other than message are
+// only present for an Error.
+type errorJSON struct {
+	Message string `json:"message"`
+	Op      string `json:"op,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Len     int    `json:"len,omitempty"`
+	Errno   int    `json:"errno,omitempty"`
+	Class   string `json:"class,omitempty"`
+}
+
+func newErrorJSON(err error) *errorJSON {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if !errors.As(err, &e) {
+		return &errorJSON{Message: err.Error()}
+	}
+	j := &errorJSON{
+		Message: err.Error(),
+		Op:      e.Op,
+		Path:    e.Path,
+		Len:     e.Len,
+		Errno:   int(e.Errno),
+	}
+	if e.Err != nil {
+		j.Class = e.Err.Error()
+	}
+	return j
+}
+
+// MarshalJSON encodes r with the keys path, mime, encoding, description,
+// extensions, matched_db, container, strength, rule, offset, examined,
+// truncated, needs_more, language, kind, target and error. Extensions is
+// never null; path, container, strength, rule, examined, truncated,
+// needs_more, language, target and error are omitted when empty, offset
+// when nil and kind for content.
+func (r Result) MarshalJSON() ([]byte, error) {
+	extensions := r.Extensions
+	if extensions == nil {
+		extensions = []string{}
+	}
+	j := resultJSON{
+		Path:        r.Path,
+		MIME:        r.MIME,
+		Encoding:    r.Encoding,
+		Description: r.Description,
+		Extensions:  extensions,
+		MatchedDB:   r.Database,
+		Container:   r.Container,
+		Strength:    r.Strength,
+		Offset:      r.Offset,
+		Examined:    r.Examined,
+		Truncated:   r.Truncated,
+		NeedsMore:   r.NeedsMore,
+		Target:      r.Target,
+		Error:       newErrorJSON(r.Err),
+	}
+	if r.Rule != nil {
+		j.Rule = &ruleJSON{
+			Database:    r.Rule.Database,
+			Line:        r.Rule.Line,
+			Description: r.Rule.Description,
+			MIME:        r.Rule.MIME,
+			Strength:    r.Rule.Strength,
+		}
+	}
+	if r.Language != nil {
+		j.Language = &languageJSON{
+			Name:       r.Language.Name,
+			MIME:       r.Language.MIME,
+			Confidence: r.Language.Confidence,
+		}
+	}
+	if r.Kind != KindContent {
+		j.Kind = r.Kind.String()
+	}
+	return json.Marshal(j)
+}
+
+// MarshalJSON encodes e with the keys message, op, path, len, errno and
+// class, the message of Err; keys other than message are omitted when empty.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newErrorJSON(e))
+}
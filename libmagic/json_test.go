@@ -0,0 +1,114 @@
+package libmagic
+
+import (
+	"encoding/json"
+	"errors"
+	"syscall"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ptr returns a pointer to v.
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func (s *MagicTestSuite) TestResultMarshalJSON() {
+	t := s.T()
+	tests := []struct {
+		name   string
+		result Result
+		want   string
+	}{
+		{
+			name: "detected",
+			result: Result{
+				Path:       "image.png",
+				Raw:        "image/png",
+				MIME:       "image/png",
+				Encoding:   "binary",
+				Extensions: []string{"png"},
+				Database:   "magic.mgc",
+			},
+			want: `{"path":"image.png","mime":"image/png","encoding":"binary","description":"","extensions":["png"],"matched_db":"magic.mgc"}`,
+		},
+		{
+			name: "rule",
+			result: Result{
+				Description: "PNG image data",
+				Strength:    80,
+				Rule:        &Rule{Database: "magic.mgc", Line: 9, Description: "PNG image data", MIME: "image/png", Strength: 80},
+			},
+			want: `{"mime":"","encoding":"","description":"PNG image data","extensions":[],"matched_db":"","strength":80,"rule":{"database":"magic.mgc","line":9,"description":"PNG image data","mime":"image/png","strength":80}}`,
+		},
+		{
+			name:   "offset",
+			result: Result{Description: "ISO 9660 CD-ROM filesystem data", Offset: ptr[int64](0x8001)},
+			want:   `{"mime":"","encoding":"","description":"ISO 9660 CD-ROM filesystem data","extensions":[],"matched_db":"","offset":32769}`,
+		},
+		{
+			name:   "zero offset",
+			result: Result{Description: "PNG image data", Offset: ptr[int64](0)},
+			want:   `{"mime":"","encoding":"","description":"PNG image data","extensions":[],"matched_db":"","offset":0}`,
+		},
+		{
+			name:   "no signature",
+			result: Result{Description: "ASCII text", Offset: ptr[int64](-1)},
+			want:   `{"mime":"","encoding":"","description":"ASCII text","extensions":[],"matched_db":"","offset":-1}`,
+		},
+		{
+			name:   "truncated",
+			result: Result{Description: "data", Examined: 512, Truncated: true, NeedsMore: true},
+			want:   `{"mime":"","encoding":"","description":"data","extensions":[],"matched_db":"","examined":512,"truncated":true,"needs_more":true}`,
+		},
+		{
+			name:   "empty",
+			result: Result{},
+			want:   `{"mime":"","encoding":"","description":"","extensions":[],"matched_db":""}`,
+		},
+		{
+			name:   "error",
+			result: Result{Err: errors.New("failed")},
+			want:   `{"mime":"","encoding":"","description":"","extensions":[],"matched_db":"","error":{"message":"failed"}}`,
+		},
+		{
+			name: "libmagic error",
+			result: Result{Err: &Error{
+				Op:      "magic_file",
+				Path:    "missing",
+				Context: "magic_file",
+				Message: "cannot open",
+				Errno:   syscall.ENOENT,
+				Err:     ErrNotLoaded,
+			}},
+			want: `{"mime":"","encoding":"","description":"","extensions":[],"matched_db":"","error":{"message":"magic_file: cannot open","op":"magic_file","path":"missing","errno":2,"class":"no database loaded"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			data, err := json.Marshal(tt.result)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(data))
+		})
+	}
+
+	data, err := json.Marshal(&Error{Op: "magic_load", Context: "magic_load", Message: "bad", Err: ErrInvalidDatabase})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"message":"magic_load: bad","op":"magic_load","class":"invalid database"}`, string(data))
+}
+
+func (s *MagicTestSuite) TestDetectorResultMatchedDB() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType),
+		WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	result, err := detector.DetectBufferResult(pngHeader)
+	require.NoError(t, err)
+	data, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"mime":"image/png","encoding":"","description":"","extensions":[],"matched_db":"../testdata/magic.mgc"}`, string(data))
+}
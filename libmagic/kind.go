@@ -0,0 +1,104 @@
+package libmagic
+
+import (
+	"strings"
+)
+
+// Kind is the kind of input a Result describes.
+type Kind int
+
+const (
+	// KindContent is file or buffer content, described by libmagic rules.
+	KindContent Kind = iota
+	// KindSymlink is a symbolic link libmagic did not follow because the
+	// flags lack MagicSymlink.
+	KindSymlink
+	// KindDirectory is a directory.
+	KindDirectory
+	// KindSocket is a Unix domain socket.
+	KindSocket
+	// KindFIFO is a named pipe.
+	KindFIFO
+	// KindCharDevice is a character device, such as /dev/null.
+	KindCharDevice
+	// KindBlockDevice is a block device.
+	KindBlockDevice
+	// KindEmpty is an empty file or buffer.
+	KindEmpty
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindContent:
+		return "content"
+	case KindSymlink:
+		return "symlink"
+	case KindDirectory:
+		return "directory"
+	case KindSocket:
+		return "socket"
+	case KindFIFO:
+		return "fifo"
+	case KindCharDevice:
+		return "chardevice"
+	case KindBlockDevice:
+		return "blockdevice"
+	case KindEmpty:
+		return "empty"
+	}
+	return "unknown"
+}
+
+// kindMIMEs maps the MIME types libmagic prints for special files to their
+// Kind.
+var kindMIMEs = map[string]Kind{
+	"inode/directory":     KindDirectory,
+	"inode/socket":        KindSocket,
+	"inode/fifo":          KindFIFO,
+	"inode/chardevice":    KindCharDevice,
+	"inode/blockdevice":   KindBlockDevice,
+	"inode/x-empty":       KindEmpty,
+	"application/x-empty": KindEmpty,
+}
+
+// kindDescriptions maps the descriptions libmagic prints for special files,
+// or their start when followed by device numbers, to their Kind.
+var kindDescriptions = []struct {
+	prefix string
+	kind   Kind
+}{
+	{"directory", KindDirectory},
+	{"socket", KindSocket},
+	{"fifo (named pipe)", KindFIFO},
+	{"character special", KindCharDevice},
+	{"block special", KindBlockDevice},
+	{"empty", KindEmpty},
+}
+
+// modePrefixes are printed by libmagic before the description of special
+// files with these permission bits, e.g. "sticky, directory".
+var modePrefixes = []string{"setuid, ", "setgid, ", "sticky, "}
+
+// parseKind sets Kind, and Target for symbolic links, from the description
+// or the MIME type of r. Other outputs, such as extensions, tell nothing
+// about special files, which are then reported as KindContent.
+func (r *Result) parseKind() {
+	r.parseSymlink()
+	if r.Kind != KindContent {
+		return
+	}
+	if kind, ok := kindMIMEs[r.MIME]; ok {
+		r.Kind = kind
+		return
+	}
+	description := r.Description
+	for _, prefix := range modePrefixes {
+		description = strings.TrimPrefix(description, prefix)
+	}
+	for _, d := range kindDescriptions {
+		if description == d.prefix || strings.HasPrefix(description, d.prefix+" (") {
+			r.Kind = d.kind
+			return
+		}
+	}
+}
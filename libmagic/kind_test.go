@@ -0,0 +1,72 @@
+package libmagic
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestResultKind() {
+	t := s.T()
+	tests := []struct {
+		raw   string
+		flags Flags
+		kind  Kind
+	}{
+		{"directory", MagicNone, KindDirectory},
+		{"sticky, directory", MagicNone, KindDirectory},
+		{"fifo (named pipe)", MagicNone, KindFIFO},
+		{"socket", MagicNone, KindSocket},
+		{"character special (1/3)", MagicNone, KindCharDevice},
+		{"block special (7/0)", MagicNone, KindBlockDevice},
+		{"empty", MagicNone, KindEmpty},
+		{"inode/directory; charset=binary", MagicMime, KindDirectory},
+		{"inode/fifo", MagicMimeType, KindFIFO},
+		{"inode/socket", MagicMimeType, KindSocket},
+		{"inode/chardevice", MagicMimeType, KindCharDevice},
+		{"inode/blockdevice", MagicMimeType, KindBlockDevice},
+		{"inode/x-empty", MagicMimeType, KindEmpty},
+		{"application/x-empty; charset=binary", MagicMime, KindEmpty},
+		{"directory listing, ASCII text", MagicNone, KindContent},
+		{"socket (not really)", MagicNone, KindSocket},
+		{"text/plain", MagicMimeType, KindContent},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.kind, newResult("", tt.raw, tt.flags, nil).Kind, tt.raw)
+	}
+	assert.Equal(t, "chardevice", KindCharDevice.String())
+}
+
+func (s *MagicTestSuite) TestDetectorKind() {
+	t := s.T()
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(empty, nil, 0o600))
+
+	for _, flags := range []Flags{MagicNone, MagicMime} {
+		detector, err := NewDetector(WithPoolSize(1), WithFlags(flags),
+			WithDatabases("../testdata/magic.mgc"))
+		require.NoError(t, err)
+		defer detector.Close()
+
+		result, err := detector.DetectFileResult(dir)
+		require.NoError(t, err)
+		assert.Equal(t, KindDirectory, result.Kind)
+		result, err = detector.DetectFileResult(empty)
+		require.NoError(t, err)
+		assert.Equal(t, KindEmpty, result.Kind)
+		result, err = detector.DetectBufferResult(nil)
+		require.NoError(t, err)
+		assert.Equal(t, KindEmpty, result.Kind)
+		result, err = detector.DetectBufferResult(pngHeader)
+		require.NoError(t, err)
+		assert.Equal(t, KindContent, result.Kind)
+		if _, err := os.Stat("/dev/null"); err == nil {
+			result, err = detector.DetectFileResult("/dev/null")
+			require.NoError(t, err)
+			assert.Equal(t, KindCharDevice, result.Kind)
+		}
+	}
+}
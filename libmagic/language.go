@@ -0,0 +1,227 @@
+package libmagic
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// languageHeadSize is how much of a text file DetectLanguage looks at.
+const languageHeadSize = 64 << 10
+
+// minLanguageScore is the score a language needs to be reported.
+const minLanguageScore = 4
+
+// Language is a programming language guessed from source text.
+type Language struct {
+	// Name is the name of the language, e.g. "Python".
+	Name string
+	// MIME is its MIME type, e.g. "text/x-python".
+	MIME string
+	// Confidence is between 0 and 1, higher when more of the hints found
+	// point to the language rather than to others.
+	Confidence float64
+}
+
+// languageHint is a pattern hinting at a language, weighted by how specific
+// it is.
+type languageHint struct {
+	pattern *regexp.Regexp
+	weight  int
+}
+
+// languages are the languages DetectLanguage knows, with the interpreters
+// of their shebang lines and their hints.
+var languages = []struct {
+	name, mime   string
+	interpreters []string
+	hints        []languageHint
+}{
+	{"Python", "text/x-python", []string{"python", "python2", "python3"}, []languageHint{
+		{regexp.MustCompile(`(?m)^\s*def \w+\(.*\)\s*(->.*)?:\s*$`), 3},
+		{regexp.MustCompile(`(?m)^\s*class \w+(\(.*\))?:\s*$`), 3},
+		{regexp.MustCompile(`(?m)^from [\w.]+ import `), 3},
+		{regexp.MustCompile(`(?m)^import \w+(\.\w+)*( as \w+)?\s*$`), 1},
+		{regexp.MustCompile(`if __name__ == ['"]__main__['"]:`), 4},
+		{regexp.MustCompile(`(?m)^\s*elif .*:\s*$`), 2},
+		{regexp.MustCompile(`\bself\.\w+`), 1},
+	}},
+	{"Go", "text/x-go", nil, []languageHint{
+		{regexp.MustCompile(`(?m)^package \w+\s*$`), 3},
+		{regexp.MustCompile(`(?m)^func (\(\w+ \*?\w+\) )?\w+\(`), 3},
+		{regexp.MustCompile(`(?m)^import \($`), 2},
+		{regexp.MustCompile(`\w+ := `), 1},
+		{regexp.MustCompile(`\berr != nil\b`), 2},
+	}},
+	{"Shell", "text/x-shellscript", []string{"sh", "bash", "dash", "ksh", "zsh"}, []languageHint{
+		{regexp.MustCompile(`(?m)^\s*(fi|esac|done)\s*$`), 2},
+		{regexp.MustCompile(`(?m)^\s*if \[\[? .*\]\]?; then\s*$`), 3},
+		{regexp.MustCompile(`(?m)^\s*for \w+ in .*; do\s*$`), 3},
+		{regexp.MustCompile(`(?m)^\s*export \w+=`), 2},
+		{regexp.MustCompile(`\$\{?\w+\}?`), 1},
+	}},
+	{"JavaScript", "text/javascript", []string{"node", "nodejs"}, []languageHint{
+		{regexp.MustCompile(`(?m)^\s*(const|let|var) \w+ = `), 1},
+		{regexp.MustCompile(`\bfunction\s*\w*\s*\(`), 2},
+		{regexp.MustCompile(`=> \{`), 2},
+		{regexp.MustCompile(`\bconsole\.log\(`), 3},
+		{regexp.MustCompile(`\brequire\(['"][\w./@-]+['"]\)`), 3},
+		{regexp.MustCompile(`(?m)^export (default |const |function )`), 3},
+	}},
+	{"C", "text/x-c", nil, []languageHint{
+		{regexp.MustCompile(`(?m)^#include [<"][\w./]+[>"]`), 3},
+		{regexp.MustCompile(`(?m)^#define \w+`), 2},
+		{regexp.MustCompile(`\bint main\s*\(`), 3},
+		{regexp.MustCompile(`\b(printf|malloc|free|sizeof)\(`), 2},
+	}},
+	{"Java", "text/x-java", nil, []languageHint{
+		{regexp.MustCompile(`(?m)^import java\.`), 4},
+		{regexp.MustCompile(`\bpublic (final )?class \w+`), 3},
+		{regexp.MustCompile(`\bpublic static void main\(`), 4},
+		{regexp.MustCompile(`\bSystem\.out\.print`), 3},
+	}},
+	{"Ruby", "text/x-ruby", []string{"ruby"}, []languageHint{
+		{regexp.MustCompile(`(?m)^\s*require ['"][\w/]+['"]\s*$`), 3},
+		{regexp.MustCompile(`(?m)^\s*def \w+[?!]?(\(.*\))?\s*$`), 2},
+		{regexp.MustCompile(`(?m)^\s*end\s*$`), 1},
+		{regexp.MustCompile(`(?m)^\s*puts `), 2},
+		{regexp.MustCompile(`\.each do \|`), 3},
+	}},
+	{"Rust", "text/x-rust", nil, []languageHint{
+		{regexp.MustCompile(`(?m)^\s*(pub )?fn \w+(<.*>)?\(`), 3},
+		{regexp.MustCompile(`(?m)^use (std|crate|super)::`), 4},
+		{regexp.MustCompile(`\blet mut \w+`), 3},
+		{regexp.MustCompile(`(?m)^\s*impl(<.*>)? \w+`), 3},
+		{regexp.MustCompile(`\bprintln!\(`), 3},
+	}},
+}
+
+// DetectLanguage guesses the programming language of the source text
+// content from its shebang line or, failing that, from patterns typical of
+// each language, such as Python's "def f():" and Go's "package main". It
+// looks at the first 64 KiB of content and returns false if no language
+// stands out. The guess is a heuristic; Confidence tells how clear-cut it
+// is.
+func DetectLanguage(content []byte) (Language, bool) {
+	if len(content) > languageHeadSize {
+		content = content[:languageHeadSize]
+	}
+	if interpreter := shebangInterpreter(content); interpreter != "" {
+		for _, lang := range languages {
+			for _, name := range lang.interpreters {
+				if interpreter == name {
+					return Language{Name: lang.name, MIME: lang.mime, Confidence: 1}, true
+				}
+			}
+		}
+	}
+	best, bestScore, total := -1, 0, 0
+	for i, lang := range languages {
+		score := 0
+		for _, hint := range lang.hints {
+			if hint.pattern.Match(content) {
+				score += hint.weight
+			}
+		}
+		total += score
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if bestScore < minLanguageScore {
+		return Language{}, false
+	}
+	return Language{
+		Name:       languages[best].name,
+		MIME:       languages[best].mime,
+		Confidence: float64(bestScore) / float64(total),
+	}, true
+}
+
+// shebangInterpreter returns the name of the interpreter of the shebang
+// line content starts with, such as "python3" for "#!/usr/bin/env python3",
+// or "".
+func shebangInterpreter(content []byte) string {
+	line, ok := bytes.CutPrefix(content, []byte("#!"))
+	if !ok {
+		return ""
+	}
+	line, _, _ = bytes.Cut(line, []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := fields[0][strings.LastIndexByte(fields[0], '/')+1:]
+	if interpreter == "env" {
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") {
+				return field
+			}
+		}
+		return ""
+	}
+	return interpreter
+}
+
+// plainTextDescriptions start the descriptions libmagic prints for text it
+// recognizes no format of.
+var plainTextDescriptions = []string{"ASCII text", "Unicode text, UTF-8 text", "ISO-8859 text", "Non-ISO extended-ASCII text"}
+
+// isPlainTextResult reports whether r is text libmagic recognizes no format
+// of.
+func isPlainTextResult(r Result) bool {
+	if r.MIME != "" {
+		return r.MIME == "text/plain"
+	}
+	for _, description := range plainTextDescriptions {
+		if r.Description == description || strings.HasPrefix(r.Description, description+",") {
+			return true
+		}
+	}
+	return false
+}
+
+// WithLanguageDetection makes the MagicDetector refine Results libmagic
+// reports as plain text with the programming language DetectLanguage
+// guesses, setting Language, MIME, such as "text/x-python", and Description,
+// such as "Python source, ASCII text"; Raw keeps the libmagic output. Files
+// are refined from their first 64 KiB, read again.
+func WithLanguageDetection() DetectorOption {
+	return func(d *MagicDetector) {
+		d.detectLanguage = true
+	}
+}
+
+// refineLanguageResult refines r as WithLanguageDetection describes, from
+// content or, if nil, from the file at r.Path.
+func refineLanguageResult(r *Result, content []byte) {
+	if !isPlainTextResult(*r) {
+		return
+	}
+	if content == nil {
+		if r.Path == "" {
+			return
+		}
+		f, err := os.Open(r.Path)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		if content, err = io.ReadAll(io.LimitReader(f, languageHeadSize)); err != nil {
+			return
+		}
+	}
+	lang, ok := DetectLanguage(content)
+	if !ok {
+		return
+	}
+	r.Language = &lang
+	if r.MIME != "" {
+		r.MIME = lang.MIME
+	}
+	if r.Description != "" {
+		r.Description = lang.Name + " source, " + r.Description
+	}
+}
@@ -0,0 +1,137 @@
+package libmagic
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var languageSamples = map[string]string{
+	"Python": `import os
+from pathlib import Path
+
+
+class Walker:
+    def __init__(self, root):
+        self.root = root
+
+    def files(self):
+        for path in Path(self.root).iterdir():
+            yield path
+
+
+if __name__ == "__main__":
+    print(list(Walker(os.getcwd()).files()))
+`,
+	"Go": `package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	data, err := os.ReadFile("input")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(len(data))
+}
+`,
+	"Shell": `export PATH=/usr/local/bin:$PATH
+for f in *.txt; do
+	if [ -s "$f" ]; then
+		echo "$f"
+	fi
+done
+`,
+	"JavaScript": `const fs = require('fs');
+
+function count(path) {
+  return fs.readFileSync(path).length;
+}
+
+console.log(count(process.argv[2]));
+`,
+	"Rust": `use std::fs;
+
+fn main() {
+    let mut total = 0;
+    for entry in fs::read_dir(".").unwrap() {
+        total += 1;
+    }
+    println!("{}", total);
+}
+`,
+}
+
+func (s *MagicTestSuite) TestDetectLanguage() {
+	t := s.T()
+	for name, sample := range languageSamples {
+		lang, ok := DetectLanguage([]byte(sample))
+		require.True(t, ok, name)
+		assert.Equal(t, name, lang.Name)
+		assert.Greater(t, lang.Confidence, 0.5, name)
+		assert.LessOrEqual(t, lang.Confidence, 1.0, name)
+	}
+
+	lang, ok := DetectLanguage([]byte("#!/usr/bin/env -S python3 -u\nprint('hi')\n"))
+	require.True(t, ok)
+	assert.Equal(t, Language{Name: "Python", MIME: "text/x-python", Confidence: 1}, lang)
+	lang, ok = DetectLanguage([]byte("#!/bin/bash\necho hi\n"))
+	require.True(t, ok)
+	assert.Equal(t, "text/x-shellscript", lang.MIME)
+
+	_, ok = DetectLanguage([]byte("Dear team,\n\nthe meeting is moved to Friday.\n"))
+	assert.False(t, ok)
+	_, ok = DetectLanguage([]byte("#!/usr/bin/awk -f\n"))
+	assert.False(t, ok)
+}
+
+func (s *MagicTestSuite) TestDetectorWithLanguageDetection() {
+	t := s.T()
+	path := filepath.Join(t.TempDir(), "build")
+	require.NoError(t, os.WriteFile(path, []byte(languageSamples["Shell"]), 0o600))
+
+	detector, err := NewDetector(WithPoolSize(1), WithDatabases("../testdata/magic.mgc"),
+		WithLanguageDetection())
+	require.NoError(t, err)
+	defer detector.Close()
+	result, err := detector.DetectFileResult(path)
+	require.NoError(t, err)
+	require.NotNil(t, result.Language, result.Raw)
+	assert.Equal(t, "Shell source, ASCII text", result.Description)
+	assert.Equal(t, "ASCII text", result.Raw)
+
+	detector, err = NewDetector(WithPoolSize(1), WithFlags(MagicMime),
+		WithDatabases("../testdata/magic.mgc"), WithLanguageDetection())
+	require.NoError(t, err)
+	defer detector.Close()
+	result, err = detector.DetectBufferResult([]byte(languageSamples["Shell"]))
+	require.NoError(t, err)
+	assert.Equal(t, "text/x-shellscript", result.MIME)
+	assert.Equal(t, "us-ascii", result.Encoding)
+	data, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"language":{"name":"Shell","mime":"text/x-shellscript","confidence":`)
+
+	result, err = detector.DetectBufferResult([]byte("Dear team,\n\nthe meeting is moved to Friday.\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", result.MIME)
+	assert.Nil(t, result.Language)
+
+	// The guessed type is not taken for the rule libmagic matched.
+	detector, err = NewDetector(WithPoolSize(1), WithFlags(MagicMimeType),
+		WithDatabases("../testdata/magic.mgc"), WithLanguageDetection(), WithStrength(), WithProvenance())
+	require.NoError(t, err)
+	defer detector.Close()
+	result, err = detector.DetectBufferResult([]byte(languageSamples["Shell"]))
+	require.NoError(t, err)
+	assert.Equal(t, "text/x-shellscript", result.MIME)
+	require.NotNil(t, result.Rule)
+	assert.Equal(t, "text/plain", result.Rule.MIME)
+	assert.Equal(t, result.Rule.Strength, result.Strength)
+}
@@ -0,0 +1,178 @@
+package libmagic
+
+import (
+	"bufio"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// DefaultMaxLayers is the number of compression layers DetectLayers peels by
+// default.
+const DefaultMaxLayers = 4
+
+// DefaultMaxLayerMemory is the largest xz dictionary or zstd window
+// DetectLayers accepts by default.
+const DefaultMaxLayerMemory = 64 << 20
+
+// ErrTooManyLayers is returned by DetectLayers when the content is still
+// compressed after the maximum number of layers.
+var ErrTooManyLayers = errors.New("too many compression layers")
+
+// Layer is one layer of content found by DetectLayers.
+type Layer struct {
+	// MIME is the MIME type of the layer, e.g. "application/gzip".
+	MIME string
+	// Size is the number of bytes of the layer read to detect it.
+	Size int
+}
+
+// LayerOption configures DetectLayers.
+type LayerOption func(*layerOptions)
+
+type layerOptions struct {
+	maxLayers int
+	maxSize   int
+	maxMemory int64
+}
+
+// WithMaxLayers sets how many compression layers DetectLayers peels at most,
+// DefaultMaxLayers by default.
+func WithMaxLayers(n int) LayerOption {
+	return func(o *layerOptions) {
+		o.maxLayers = n
+	}
+}
+
+// WithMaxLayerSize sets how many bytes of each layer DetectLayers reads at
+// most, DefaultHeadSize by default. As only that many bytes are decompressed
+// per layer, compression bombs cannot make DetectLayers use more time. The
+// memory of the decompressors is bounded separately, see WithMaxLayerMemory.
+func WithMaxLayerSize(size int) LayerOption {
+	return func(o *layerOptions) {
+		o.maxSize = size
+	}
+}
+
+// WithMaxLayerMemory sets the largest xz dictionary or zstd window, in bytes,
+// DetectLayers accepts for each layer, DefaultMaxLayerMemory by default.
+// Layers asking for more fail before the memory is allocated, so a few bytes
+// of input cannot claim gigabytes.
+func WithMaxLayerMemory(size int64) LayerOption {
+	return func(o *layerOptions) {
+		o.maxMemory = size
+	}
+}
+
+// decompressors open a reader decompressing r, by canonical MIME type of r,
+// using at most about maxMemory bytes for the dictionary or window.
+var decompressors = map[string]func(r io.Reader, maxMemory int64) (io.ReadCloser, error){
+	"application/gzip": func(r io.Reader, _ int64) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	"application/x-bzip2": func(r io.Reader, _ int64) (io.ReadCloser, error) {
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	},
+	"application/x-xz": func(r io.Reader, maxMemory int64) (io.ReadCloser, error) {
+		xr, err := xz.NewReader(newXZGuard(r, maxMemory))
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(xr), nil
+	},
+	"application/zstd": func(r io.Reader, maxMemory int64) (io.ReadCloser, error) {
+		zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1),
+			zstd.WithDecoderMaxWindow(uint64(maxMemory)), zstd.WithDecoderMaxMemory(uint64(maxMemory)))
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	},
+}
+
+// decompressor returns the decompressor for content of type mime, which may
+// be an alias such as "application/x-gzip" older libmagic releases print.
+func decompressor(mime string) (func(r io.Reader, maxMemory int64) (io.ReadCloser, error), bool) {
+	decompress, ok := decompressors[NormalizeMIME(mime)]
+	return decompress, ok
+}
+
+// DetectLayers detects the MIME type of the content of r and, as long as it
+// is gzip, bzip2, xz or zstd compressed, of the content it decompresses to,
+// such as []Layer{{MIME: "application/gzip"}, {MIME: "application/x-tar"}}
+// for a .tar.gz file. Compression is undone in Go, whatever the flags of the
+// MagicDetector; see WithMaxLayers, WithMaxLayerSize and WithMaxLayerMemory
+// for the limits.
+//
+// If a layer cannot be decompressed, or the last one allowed is still
+// compressed, the layers found so far are returned with the error, which
+// matches ErrTooManyLayers in the latter case.
+func (d *MagicDetector) DetectLayers(r io.Reader, opts ...LayerOption) ([]Layer, error) {
+	o := layerOptions{maxLayers: DefaultMaxLayers, maxSize: DefaultHeadSize, maxMemory: DefaultMaxLayerMemory}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.maxSize <= 0 {
+		o.maxSize = DefaultHeadSize
+	}
+	if o.maxMemory <= 0 {
+		o.maxMemory = DefaultMaxLayerMemory
+	}
+	flags := d.flags&^(outputFlags|MagicCompress) | MagicMimeType
+	var layers []Layer
+	for {
+		br := bufio.NewReaderSize(r, o.maxSize)
+		head, err := br.Peek(o.maxSize)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return layers, err
+		}
+		mime, err := d.DetectBufferWithFlags(head, flags)
+		if err != nil {
+			return layers, err
+		}
+		layers = append(layers, Layer{MIME: mime, Size: len(head)})
+		decompress, ok := decompressor(mime)
+		if !ok {
+			return layers, nil
+		}
+		if len(layers) > o.maxLayers {
+			return layers, ErrTooManyLayers
+		}
+		rc, err := decompress(br, o.maxMemory)
+		if err != nil {
+			return layers, fmt.Errorf("decompressing %s: %w", mime, err)
+		}
+		defer rc.Close()
+		r = &layerReader{r: rc, mime: mime}
+	}
+}
+
+// DetectFileLayers is like DetectLayers but reads the file at path.
+func (d *MagicDetector) DetectFileLayers(path string, opts ...LayerOption) ([]Layer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return d.DetectLayers(f, opts...)
+}
+
+// layerReader annotates errors decompressing a layer with its MIME type.
+type layerReader struct {
+	r    io.Reader
+	mime string
+}
+
+func (r *layerReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		err = fmt.Errorf("decompressing %s: %w", r.mime, err)
+	}
+	return n, err
+}
@@ -0,0 +1,191 @@
+package libmagic
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
+)
+
+func tarContent(t require.TestingT, name string, content []byte) []byte {
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	require.NoError(t, w.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(content))}))
+	_, err := w.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func (s *MagicTestSuite) TestDetectorDetectLayers() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	archive := tarContent(t, "image.png", pngHeader)
+	var zst bytes.Buffer
+	zw, err := zstd.NewWriter(&zst)
+	require.NoError(t, err)
+	_, err = zw.Write(archive)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	var xzBuf bytes.Buffer
+	xw, err := xz.NewWriter(&xzBuf)
+	require.NoError(t, err)
+	_, err = xw.Write(gzipContent(t, []byte("hello world\n")))
+	require.NoError(t, err)
+	require.NoError(t, xw.Close())
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    []string
+	}{
+		{name: "tar.gz", content: gzipContent(t, archive), want: []string{"application/gzip", "application/x-tar"}},
+		{name: "tar.zst", content: zst.Bytes(), want: []string{"application/zstd", "application/x-tar"}},
+		{name: "txt.gz.xz", content: xzBuf.Bytes(), want: []string{"application/x-xz", "application/gzip", "text/plain"}},
+		{name: "plain", content: pngHeader, want: []string{"image/png"}},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			layers, err := detector.DetectLayers(bytes.NewReader(tt.content))
+			require.NoError(t, err)
+			var mimes []string
+			for _, layer := range layers {
+				mimes = append(mimes, layer.MIME)
+				assert.Positive(t, layer.Size)
+			}
+			assert.Equal(t, tt.want, mimes)
+		})
+	}
+
+	nested := []byte("hello world\n")
+	for i := 0; i < 3; i++ {
+		nested = gzipContent(t, nested)
+	}
+	layers, err := detector.DetectLayers(bytes.NewReader(nested), WithMaxLayers(2))
+	assert.ErrorIs(t, err, ErrTooManyLayers)
+	assert.Len(t, layers, 3)
+	layers, err = detector.DetectLayers(bytes.NewReader(nested), WithMaxLayers(3))
+	assert.NoError(t, err)
+	assert.Len(t, layers, 4)
+
+	bomb := gzipContent(t, make([]byte, 64<<20))
+	layers, err = detector.DetectLayers(bytes.NewReader(bomb), WithMaxLayerSize(4096))
+	require.NoError(t, err)
+	require.Len(t, layers, 2)
+	assert.Equal(t, 4096, layers[1].Size)
+
+	corrupt := gzipContent(t, archive)[:32]
+	layers, err = detector.DetectLayers(bytes.NewReader(corrupt))
+	assert.ErrorContains(t, err, "decompressing application/gzip")
+	assert.Len(t, layers, 1)
+
+	path := filepath.Join(t.TempDir(), "archive.tar.gz")
+	require.NoError(t, os.WriteFile(path, gzipContent(t, archive), 0o600))
+	layers, err = detector.DetectFileLayers(path)
+	require.NoError(t, err)
+	assert.Len(t, layers, 2)
+}
+
+// xzBlocks returns an xz stream header followed by a block header with the
+// LZMA2 dictionary size property dictBits for every entry of dictBits. All
+// blocks but the last hold "hello" as an uncompressed chunk.
+func xzBlocks(dictBits ...byte) []byte {
+	flags := []byte{0, 0}
+	b := append([]byte("\xfd7zXZ\x00"), flags...)
+	b = binary.LittleEndian.AppendUint32(b, crc32.ChecksumIEEE(flags))
+	for i, bits := range dictBits {
+		header := []byte{2, 0, 0x21, 1, bits, 0, 0, 0}
+		b = append(b, header...)
+		b = binary.LittleEndian.AppendUint32(b, crc32.ChecksumIEEE(header))
+		if i < len(dictBits)-1 {
+			b = append(b, "\x01\x00\x04hello\x00\x00\x00\x00"...)
+		}
+	}
+	return b
+}
+
+func (s *MagicTestSuite) TestDetectorDetectLayersXZDictionary() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	// A 1 GiB dictionary, in the first block or in a later one.
+	for _, bomb := range [][]byte{xzBlocks(36), xzBlocks(0, 36)} {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		layers, err := detector.DetectLayers(bytes.NewReader(bomb))
+		runtime.ReadMemStats(&after)
+		assert.ErrorIs(t, err, errXZDictTooLarge)
+		require.Len(t, layers, 1)
+		assert.Equal(t, "application/x-xz", layers[0].MIME)
+		assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(DefaultMaxLayerMemory))
+	}
+
+	// The 8 MiB dictionary xz uses by default.
+	var buf bytes.Buffer
+	w, err := xz.NewWriter(&buf)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("hello world\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	layers, err := detector.DetectLayers(bytes.NewReader(buf.Bytes()), WithMaxLayerMemory(1<<20))
+	assert.ErrorIs(t, err, errXZDictTooLarge)
+	assert.Len(t, layers, 1)
+	layers, err = detector.DetectLayers(bytes.NewReader(buf.Bytes()), WithMaxLayerMemory(8<<20))
+	assert.NoError(t, err)
+	assert.Len(t, layers, 2)
+}
+
+func (s *MagicTestSuite) TestDecompressorAliases() {
+	t := s.T()
+	t.Parallel()
+	for _, mime := range []string{"application/gzip", "application/x-gzip", "application/zstd"} {
+		_, ok := decompressor(mime)
+		assert.True(t, ok, mime)
+	}
+	_, ok := decompressor("application/x-tar")
+	assert.False(t, ok)
+}
+
+func (s *MagicTestSuite) TestXZGuard() {
+	t := s.T()
+	t.Parallel()
+	content := make([]byte, 64<<10)
+	rand.New(rand.NewSource(1)).Read(content[:32<<10])
+	copy(content[32<<10:], bytes.Repeat([]byte("hello world\n"), 4<<10))
+
+	for _, check := range []byte{xz.None, xz.CRC32, xz.CRC64, xz.SHA256} {
+		// Two streams of several blocks, with stream padding in between.
+		var buf bytes.Buffer
+		for i := 0; i < 2; i++ {
+			buf.Write(make([]byte, 4*i))
+			w, err := xz.WriterConfig{CheckSum: check, BlockSize: 8 << 10}.NewWriter(&buf)
+			require.NoError(t, err)
+			_, err = w.Write(content)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+		}
+		rc, err := decompressors["application/x-xz"](&buf, DefaultMaxLayerMemory)
+		require.NoError(t, err)
+		got, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		assert.Equal(t, append(content, content...), got)
+	}
+
+	_, err := io.ReadAll(newXZGuard(bytes.NewReader(xzBlocks(0, 0)[:20]), DefaultMaxLayerMemory))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
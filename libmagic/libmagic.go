@@ -1,118 +1,266 @@
 package libmagic
 
 // #cgo pkg-config: libmagic
-// #include <magic.h>
 // #include <stdlib.h>
+// #include "magic_compat.h"
 import "C"
 import (
 	"fmt"
+	"io"
+	"os"
+	"runtime"
 	"strings"
 	"sync"
+	"syscall"
 	"unsafe"
 )
 
 type Magic struct {
+	*magicState
+	lock *sync.RWMutex
+	// flags mirrors the flags set on handle, for libmagic releases without
+	// magic_getflags.
+	flags Flags
+	// debugFunc receives the MagicDebug output, see SetDebugFunc.
+	debugFunc func(line string)
+	// files and buffers describe the loaded databases, see Clone; both are
+	// nil until something is loaded. pinner keeps compiled buffers in place
+	// while libmagic references them.
+	files   []string
+	buffers [][]byte
+	// cleanup releases magicState if the Magic is garbage collected without
+	// being closed.
+	cleanup runtime.Cleanup
+	// spareOf is the MagicDetector handle set a transient handle was acquired
+	// from, see MagicDetector.Acquire.
+	spareOf *handleSet
+}
+
+// magicState holds the C resources of a Magic. It is kept apart from Magic so
+// that it can be released by a cleanup once the Magic is unreachable.
+type magicState struct {
 	handle C.magic_t
-	lock   *sync.Mutex
+	pinner *runtime.Pinner
+	// scratch is a reusable C buffer of scratchSize bytes, see cString.
+	scratch     unsafe.Pointer
+	scratchSize int
+	// scratchReserve and scratchMax are set by SetScratchSize.
+	scratchReserve int
+	scratchMax     int
 }
 
+// Flag values come straight from magic.h so they always match the linked
+// library; magic_compat.h supplies the ones older headers lack.
 const (
-	MagicNone  = 0
-	MagicDebug = 1 << (iota - 1)
-	MagicSymlink
-	MagicCompress
-	MagicDevices
-	MagicMimeType
-	MagicContinue
-	MagicCheck
-	MagicPreserveAtime
-	MagicRaw
-	MagicError
-	MagicMimeEncoding
-	MagicMime
-	MagicApple
-	MagicNoCheckCompress
-	MagicNoCheckTar
-	MagicNoCheckSoft
-	MagicNoCheckAppType
-	MagicNoCheckElf
-	MagicNoCheckText
-	MagicNoCheckCdf
-	MagicNoCheckTokens
-	MagicNoCheckEncoding
+	MagicNone            Flags = C.MAGIC_NONE
+	MagicDebug           Flags = C.MAGIC_DEBUG
+	MagicSymlink         Flags = C.MAGIC_SYMLINK
+	MagicCompress        Flags = C.MAGIC_COMPRESS
+	MagicDevices         Flags = C.MAGIC_DEVICES
+	MagicMimeType        Flags = C.MAGIC_MIME_TYPE
+	MagicContinue        Flags = C.MAGIC_CONTINUE
+	MagicCheck           Flags = C.MAGIC_CHECK
+	MagicPreserveAtime   Flags = C.MAGIC_PRESERVE_ATIME
+	MagicRaw             Flags = C.MAGIC_RAW
+	MagicError           Flags = C.MAGIC_ERROR
+	MagicMimeEncoding    Flags = C.MAGIC_MIME_ENCODING
+	MagicMime            Flags = C.MAGIC_MIME
+	MagicApple           Flags = C.MAGIC_APPLE
+	MagicExtension       Flags = C.MAGIC_EXTENSION
+	MagicCompressTransp  Flags = C.MAGIC_COMPRESS_TRANSP
+	MagicNoDesc          Flags = C.MAGIC_NODESC
+	MagicNoCheckCompress Flags = C.MAGIC_NO_CHECK_COMPRESS
+	MagicNoCheckTar      Flags = C.MAGIC_NO_CHECK_TAR
+	MagicNoCheckSoft     Flags = C.MAGIC_NO_CHECK_SOFT
+	MagicNoCheckAppType  Flags = C.MAGIC_NO_CHECK_APPTYPE
+	MagicNoCheckElf      Flags = C.MAGIC_NO_CHECK_ELF
+	MagicNoCheckText     Flags = C.MAGIC_NO_CHECK_TEXT
+	MagicNoCheckCdf      Flags = C.MAGIC_NO_CHECK_CDF
+	MagicNoCheckCsv      Flags = C.MAGIC_NO_CHECK_CSV
+	MagicNoCheckTokens   Flags = C.MAGIC_NO_CHECK_TOKENS
+	MagicNoCheckEncoding Flags = C.MAGIC_NO_CHECK_ENCODING
+	MagicNoCheckJSON     Flags = C.MAGIC_NO_CHECK_JSON
+	MagicNoCheckSimh     Flags = C.MAGIC_NO_CHECK_SIMH
 )
 
-func NewMagic(flags int) (*Magic, error) {
+func NewMagic(flags Flags) (*Magic, error) {
 	handle := C.magic_open(C.int(flags))
 	if handle == nil {
 		return nil, fmt.Errorf("failed to create a magic cookie")
 	}
 
-	return &Magic{
-		handle: handle,
-		lock:   &sync.Mutex{},
-	}, nil
+	state := &magicState{handle: handle}
+	m := &Magic{
+		magicState: state,
+		lock:       &sync.RWMutex{},
+		flags:      flags,
+	}
+	m.cleanup = runtime.AddCleanup(m, (*magicState).release, state)
+	return m, nil
+}
+
+// NewMagicDefault opens a handle and loads the default system database, see
+// DefaultDatabasePath.
+func NewMagicDefault(flags Flags) (*Magic, error) {
+	m, err := NewMagic(flags)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.MagicLoad(nil); err != nil {
+		m.Close()
+		return nil, err
+	}
+	return m, nil
 }
 
 func (m *Magic) MagicLoad(files []string) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	cFiles := prepareFiles(files)
-	if cFiles != nil {
-		defer C.free(unsafe.Pointer(cFiles))
+	err := m.magicLoad(files)
+	if err != nil && len(files) > 1 {
+		if file := findInvalidDatabase(m.flags, files); file != "" {
+			magicErr := m.magicError("magic_load", databaseErrorClass([]string{file}), fmt.Sprintf("failed to load database file %s", file))
+			magicErr.Path = file
+			return magicErr
+		}
+	}
+	return err
+}
+
+func (m *Magic) magicLoad(files []string) error {
+	if m.handle == nil {
+		return ErrClosed
 	}
-	if C.magic_load(m.handle, cFiles) == C.int(-1) {
-		return m.magicError("failed to load database files")
+	paths := files
+	if hasColon(files) {
+		dir, links, err := linkDatabases(files)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		paths = links
 	}
+	cFiles := m.cFiles(paths)
+	var ret C.int
+	m.captureDebug(func() { ret = C.magic_load(m.handle, cFiles) })
+	if ret == C.int(-1) {
+		magicErr := m.magicError("magic_load", databaseErrorClass(files), "failed to load database files")
+		magicErr.Path = strings.Join(files, ":")
+		return magicErr
+	}
+	m.setDatabases(append([]string{}, files...), nil, nil)
 	return nil
 }
 
 func (m *Magic) MagicLoadBuffers(buffers [][]byte) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
+	for _, buffer := range buffers {
+		if !isCompiledDatabase(buffer) {
+			return m.loadSourceBuffers(buffers)
+		}
+	}
+	return m.magicLoadBuffers(buffers)
+}
 
+// magicLoadBuffers loads compiled databases. libmagic keeps using the
+// buffers without copying them, so they stay pinned until the handle loads
+// other databases or is closed.
+func (m *Magic) magicLoadBuffers(buffers [][]byte) error {
+	if m.handle == nil {
+		return ErrClosed
+	}
+	if !SupportsLoadBuffers() {
+		return ErrUnsupported
+	}
 	var (
-		sizeType     *C.char
-		nBuffers     = len(buffers)
-		sizes        = C.malloc(C.size_t(nBuffers) * C.sizeof_size_t)
-		tmpSizesPtr  = sizes
-		buffersArray = C.malloc(C.size_t(nBuffers) * C.size_t(unsafe.Sizeof(sizeType)))
-		tmpBufPtr    = buffersArray
-		tmpPtr       = (*unsafe.Pointer)(buffersArray)
+		nBuffers = len(buffers)
+		cSizes   = C.malloc(C.size_t(nBuffers) * C.sizeof_size_t)
+		cBuffers = C.malloc(C.size_t(nBuffers) * C.size_t(unsafe.Sizeof(unsafe.Pointer(nil))))
+		sizes    = unsafe.Slice((*C.size_t)(cSizes), nBuffers)
+		ptrs     = unsafe.Slice((*unsafe.Pointer)(cBuffers), nBuffers)
+		pinner   = &runtime.Pinner{}
 	)
-	defer C.free(sizes)
-	defer C.free(buffersArray)
+	defer C.free(cSizes)
+	defer C.free(cBuffers)
 
-	for i := 0; i < nBuffers; i++ {
-		tmpSizesPtr = unsafe.Pointer(uintptr(tmpSizesPtr) + C.sizeof_size_t*uintptr(i))
-		*((*C.size_t)(tmpSizesPtr)) = C.size_t(len(buffers[i]))
-		tmpBufPtr = unsafe.Pointer(uintptr(tmpBufPtr) + unsafe.Sizeof(sizeType)*uintptr(i))
-		*((*unsafe.Pointer)(tmpBufPtr)) = unsafe.Pointer(&buffers[i][0])
+	for i, buffer := range buffers {
+		sizes[i] = C.size_t(len(buffer))
+		ptrs[i] = nil
+		if len(buffer) != 0 {
+			pinner.Pin(&buffer[0])
+			ptrs[i] = unsafe.Pointer(&buffer[0])
+		}
 	}
-	if C.magic_load_buffers(m.handle, tmpPtr, (*C.size_t)(sizes), C.size_t(nBuffers)) == C.int(-1) {
-		return m.magicError("failed to load database buffers")
+	if C.gomagic_load_buffers(m.handle, (*unsafe.Pointer)(cBuffers), (*C.size_t)(cSizes), C.size_t(nBuffers)) == C.int(-1) {
+		pinner.Unpin()
+		return m.magicError("magic_load_buffers", ErrInvalidDatabase, "failed to load database buffers")
 	}
-
+	m.setDatabases(nil, buffers, pinner)
 	return nil
 }
 
+// setDatabases records what the handle has loaded, releasing the buffers of
+// the previous databases. The caller must hold m.lock.
+func (m *Magic) setDatabases(files []string, buffers [][]byte, pinner *runtime.Pinner) {
+	if m.pinner != nil {
+		m.pinner.Unpin()
+	}
+	m.files = files
+	m.buffers = buffers
+	m.pinner = pinner
+}
+
+// Close releases the handle. It is safe to call more than once; any other
+// method called afterwards fails with ErrClosed.
 func (m *Magic) Close() {
 	m.lock.Lock()
-	m.lock.Unlock()
-	if m.handle != nil {
-		C.magic_close(m.handle)
+	defer m.lock.Unlock()
+	m.cleanup.Stop()
+	m.release()
+	m.files, m.buffers = nil, nil
+}
+
+// DisableCleanup stops m from being closed automatically when it is garbage
+// collected without a call to Close, for callers managing the lifetime of
+// their handles explicitly.
+func (m *Magic) DisableCleanup() {
+	m.cleanup.Stop()
+}
+
+// release frees the C resources. The handle is closed before the database
+// buffers it references are unpinned.
+func (s *magicState) release() {
+	if s.handle != nil {
+		C.magic_close(s.handle)
+		s.handle = nil
 	}
+	if s.pinner != nil {
+		s.pinner.Unpin()
+		s.pinner = nil
+	}
+	C.free(s.scratch)
+	s.scratch, s.scratchSize = nil, 0
 }
 
 func (m *Magic) MagicFile(filename string) (string, error) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	cFilename := C.CString(filename)
-	defer C.free(unsafe.Pointer(cFilename))
+	return m.magicFile(filename)
+}
+
+func (m *Magic) magicFile(filename string) (string, error) {
+	if m.handle == nil {
+		return "", ErrClosed
+	}
+	cFilename := m.cString(filename)
 
-	result := C.magic_file(m.handle, cFilename)
+	var result *C.char
+	m.captureDebug(func() { result = C.magic_file(m.handle, cFilename) })
 	if result == nil {
-		return "", m.magicError(fmt.Sprintf("failed to detect file %s", filename))
+		magicErr := m.magicError("magic_file", m.detectErrorClass(), fmt.Sprintf("failed to detect file %s", filename))
+		magicErr.Path = filename
+		return "", magicErr
 	}
 	return C.GoString(result), nil
 }
@@ -120,23 +268,65 @@ func (m *Magic) MagicFile(filename string) (string, error) {
 func (m *Magic) MagicBuffer(content []byte) (string, error) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	cContent := C.CBytes(content)
-	defer C.free(cContent)
+	return m.magicBuffer(content)
+}
+
+// magicBuffer hands the backing array of content straight to libmagic; cgo
+// keeps it pinned for the duration of the call, so nothing is copied.
+func (m *Magic) magicBuffer(content []byte) (string, error) {
+	if m.handle == nil {
+		return "", ErrClosed
+	}
+	cContent := unsafe.Pointer(&emptyContent[0])
+	if len(content) != 0 {
+		cContent = unsafe.Pointer(&content[0])
+	}
 
-	result := C.magic_buffer(m.handle, cContent, C.ulong(len(content)))
+	var result *C.char
+	m.captureDebug(func() { result = C.magic_buffer(m.handle, cContent, C.size_t(len(content))) })
 	if result == nil {
-		return "", m.magicError("failed to detect buffer")
+		magicErr := m.magicError("magic_buffer", m.detectErrorClass(), "failed to detect buffer")
+		magicErr.Len = len(content)
+		return "", magicErr
 	}
 	return C.GoString(result), nil
 }
 
+// MagicDescriptor detects the content readable from fd. libmagic gets a
+// duplicate of fd, so it can never close the caller's descriptor.
 func (m *Magic) MagicDescriptor(fd int) (string, error) {
+	dupFd, err := syscall.Dup(fd)
+	if err != nil {
+		return "", fmt.Errorf("failed to duplicate fd %d: %w", fd, err)
+	}
+	defer syscall.Close(dupFd)
+
 	m.lock.Lock()
 	defer m.lock.Unlock()
+	return m.magicDescriptor(dupFd)
+}
+
+// MagicOsFile detects the content of f starting at its current offset. Like
+// MagicDescriptor it works on a duplicate of the descriptor, and the offset
+// of f is restored afterwards, so f stays usable by the caller.
+func (m *Magic) MagicOsFile(f *os.File) (string, error) {
+	offset, seekErr := f.Seek(0, io.SeekCurrent)
+	if seekErr == nil {
+		defer f.Seek(offset, io.SeekStart)
+	}
+	result, err := m.MagicDescriptor(int(f.Fd()))
+	runtime.KeepAlive(f)
+	return result, err
+}
 
-	result := C.magic_descriptor(m.handle, C.int(fd))
+func (m *Magic) magicDescriptor(fd int) (string, error) {
+	if m.handle == nil {
+		return "", ErrClosed
+	}
+	var result *C.char
+	m.captureDebug(func() { result = C.magic_descriptor(m.handle, C.int(fd)) })
 	if result == nil {
-		return "", m.magicError("failed to detect fd")
+		return "", m.magicError("magic_descriptor", m.detectErrorClass(), "failed to detect fd")
 	}
 	return C.GoString(result), nil
 }
@@ -144,35 +334,73 @@ func (m *Magic) MagicDescriptor(fd int) (string, error) {
 func (m *Magic) MagicCompile(files []string) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	cFiles := prepareFiles(files)
-	if cFiles != nil {
-		defer C.free(unsafe.Pointer(cFiles))
+	return m.magicCompile(files)
+}
+
+func (m *Magic) magicCompile(files []string) error {
+	if m.handle == nil {
+		return ErrClosed
 	}
+	cFiles := m.cFiles(files)
 
 	if C.magic_compile(m.handle, cFiles) == C.int(-1) {
-		return m.magicError("failed to load database files")
+		magicErr := m.magicError("magic_compile", databaseErrorClass(files), "failed to load database files")
+		magicErr.Path = strings.Join(files, ":")
+		return magicErr
 	}
 	return nil
 }
 
-func (m *Magic) magicError(errStr string) error {
-	err := C.magic_error(m.handle)
-	if err == nil {
-		return nil
+// magicError returns the last libmagic error of the handle as an *Error for
+// the libmagic function op, of the given class, which may be nil.
+func (m *Magic) magicError(op string, class error, errStr string) *Error {
+	err := &Error{
+		Op:      op,
+		Context: errStr,
+		Errno:   syscall.Errno(C.magic_errno(m.handle)),
+		Err:     class,
 	}
-	return fmt.Errorf("%s: %s", errStr, C.GoString(err))
+	if msg := C.magic_error(m.handle); msg != nil {
+		err.Message = C.GoString(msg)
+	}
+	return err
 }
 
 func (m *Magic) MagicList(files []string) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	cFiles := prepareFiles(files)
-	if cFiles != nil {
-		defer C.free(unsafe.Pointer(cFiles))
+	return m.magicList(files)
+}
+
+// MagicListTo is like MagicList but writes the listing to w instead of the
+// process standard output.
+func (m *Magic) MagicListTo(w io.Writer, files []string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var err error
+	if captureErr := captureFd(syscall.Stdout, w, func() { err = m.magicList(files) }); captureErr != nil {
+		return captureErr
+	}
+	return err
+}
+
+// MagicListString returns the listing MagicList would print.
+func (m *Magic) MagicListString(files []string) (string, error) {
+	var buf strings.Builder
+	err := m.MagicListTo(&buf, files)
+	return buf.String(), err
+}
+
+func (m *Magic) magicList(files []string) error {
+	if m.handle == nil {
+		return ErrClosed
 	}
+	cFiles := m.cFiles(files)
 
 	if C.magic_list(m.handle, cFiles) == C.int(-1) {
-		return m.magicError("failed to list entries")
+		magicErr := m.magicError("magic_list", databaseErrorClass(files), "failed to list entries")
+		magicErr.Path = strings.Join(files, ":")
+		return magicErr
 	}
 	return nil
 }
@@ -180,35 +408,68 @@ func (m *Magic) MagicList(files []string) error {
 func (m *Magic) MagicCheck(files []string) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	cFiles := prepareFiles(files)
-	if cFiles != nil {
-		defer C.free(unsafe.Pointer(cFiles))
+	return m.magicCheck(files)
+}
+
+func (m *Magic) magicCheck(files []string) error {
+	if m.handle == nil {
+		return ErrClosed
 	}
+	cFiles := m.cFiles(files)
 	if C.magic_check(m.handle, cFiles) == C.int(-1) {
-		return m.magicError("invalid database files")
+		magicErr := m.magicError("magic_check", databaseErrorClass(files), "invalid database files")
+		magicErr.Path = strings.Join(files, ":")
+		return magicErr
 	}
 	return nil
 }
 
-func (m *Magic) MagicGetFlags() int {
-	m.lock.Lock()
-	defer m.lock.Unlock()
-	return int(C.magic_getflags(m.handle))
+// MagicGetFlags only takes the read side of the handle lock, so it does not
+// contend with other read-only queries.
+func (m *Magic) MagicGetFlags() Flags {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if !SupportsGetFlags() || m.handle == nil {
+		return m.flags
+	}
+	return Flags(C.gomagic_getflags(m.handle))
 }
 
-func (m *Magic) MagicSetFlags(flags int) error {
+func (m *Magic) MagicSetFlags(flags Flags) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
+	return m.magicSetFlags(flags)
+}
+
+func (m *Magic) magicSetFlags(flags Flags) error {
+	if m.handle == nil {
+		return ErrClosed
+	}
 	if C.magic_setflags(m.handle, C.int(flags)) == C.int(-1) {
-		return m.magicError("failed to set flags")
+		return m.magicError("magic_setflags", nil, "failed to set flags")
 	}
+	m.flags = flags
 	return nil
 }
 
-func prepareFiles(files []string) (cFiles *C.char) {
-	if len(files) != 0 {
-		cFiles = C.CString(strings.Join(files, ":"))
+// withFlags temporarily sets flags on the handle and returns a function
+// restoring the previous ones. The caller must hold m.lock.
+func (m *Magic) withFlags(flags Flags) (func(), error) {
+	oldFlags := m.flags
+	if err := m.magicSetFlags(flags); err != nil {
+		return nil, err
 	}
+	return func() { m.magicSetFlags(oldFlags) }, nil
+}
+
+// emptyContent gives magic_buffer a valid pointer for empty input.
+var emptyContent [1]byte
 
-	return
+// cFiles converts a database list into the colon separated C string libmagic
+// expects, or nil for the default database. See cString for its lifetime.
+func (m *Magic) cFiles(files []string) *C.char {
+	if len(files) == 0 {
+		return nil
+	}
+	return m.cString(strings.Join(files, ":"))
 }
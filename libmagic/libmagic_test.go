@@ -1,7 +1,11 @@
 package libmagic
 
 import (
+	"io"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"sync"
 	"syscall"
 	"testing"
@@ -23,6 +27,17 @@ func (s *MagicTestSuite) SetupTest() {
 	require.NoError(s.T(), s.magic.MagicLoad([]string{"../testdata/magic.mgc"}))
 }
 
+func (s *MagicTestSuite) TestNewMagicDefault() {
+	t := s.T()
+	t.Parallel()
+	magic, err := NewMagicDefault(MagicMimeType | MagicError)
+	require.NoError(t, err)
+	defer magic.Close()
+	result, err := magic.MagicBuffer(pngHeader)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+}
+
 func (s *MagicTestSuite) TestMagicLoadBuffers() {
 	m, err := NewMagic(MagicMimeType | MagicError)
 	require.NoError(s.T(), err, "failed to create magic descriptor")
@@ -46,7 +61,7 @@ func (s *MagicTestSuite) TestMagicLoad() {
 	t := s.T()
 	t.Parallel()
 	type args struct {
-		flags int
+		flags Flags
 		files []string
 	}
 	tests := []struct {
@@ -100,12 +115,58 @@ func (s *MagicTestSuite) TestClose() {
 	assert.NotPanics(s.T(), func() { magic.Close() })
 
 	magic = &Magic{
-		handle: nil,
-		lock:   &sync.Mutex{},
+		magicState: &magicState{handle: nil},
+		lock:       &sync.RWMutex{},
 	}
 	assert.NotPanics(s.T(), func() { magic.Close() })
 }
 
+func (s *MagicTestSuite) TestUseAfterClose() {
+	t := s.T()
+	t.Parallel()
+	magic, err := NewMagic(MagicMimeType)
+	require.NoError(t, err)
+	require.NoError(t, magic.MagicLoad([]string{"../testdata/magic.mgc"}))
+	magic.Close()
+	assert.NotPanics(t, func() { magic.Close() })
+
+	_, err = magic.MagicFile("../testdata/magic.mgc")
+	assert.ErrorIs(t, err, ErrClosed)
+	_, err = magic.MagicBuffer(pngHeader)
+	assert.ErrorIs(t, err, ErrClosed)
+	_, err = magic.MagicDescriptor(0)
+	assert.ErrorIs(t, err, ErrClosed)
+	_, err = magic.Clone()
+	assert.ErrorIs(t, err, ErrClosed)
+	_, err = magic.MagicGetParam(MagicParamBytesMax)
+	assert.ErrorIs(t, err, ErrClosed)
+	assert.ErrorIs(t, magic.MagicLoad(nil), ErrClosed)
+	assert.ErrorIs(t, magic.MagicLoadBuffers([][]byte{sourceRule}), ErrClosed)
+	assert.ErrorIs(t, magic.MagicSetFlags(MagicNone), ErrClosed)
+	assert.ErrorIs(t, magic.MagicCheck(nil), ErrClosed)
+	assert.ErrorIs(t, magic.MagicCompile(nil), ErrClosed)
+	assert.Equal(t, MagicMimeType, magic.MagicGetFlags())
+}
+
+func (s *MagicTestSuite) TestCleanup() {
+	t := s.T()
+	t.Parallel()
+	for i := 0; i < 8; i++ {
+		magic, err := NewMagic(MagicNone)
+		require.NoError(t, err)
+		require.NoError(t, magic.MagicLoad([]string{"../testdata/magic.mgc"}))
+	}
+	assert.NotPanics(t, func() {
+		runtime.GC()
+		runtime.GC()
+	})
+
+	magic, err := NewMagic(MagicNone)
+	require.NoError(t, err)
+	magic.DisableCleanup()
+	assert.NotPanics(t, func() { magic.Close() })
+}
+
 func (s *MagicTestSuite) TestMagicFile() {
 	t := s.T()
 	t.Parallel()
@@ -250,6 +311,47 @@ func (s *MagicTestSuite) TestMagicDescriptor() {
 	}
 }
 
+func (s *MagicTestSuite) TestMagicDescriptorKeepsFd() {
+	t := s.T()
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "image")
+	require.NoError(t, os.WriteFile(path, pngHeader, 0600))
+	fd, err := syscall.Open(path, syscall.O_RDONLY, 0600)
+	require.NoError(t, err)
+	defer syscall.Close(fd)
+
+	for i := 0; i < 2; i++ {
+		result, err := s.magic.MagicDescriptor(fd)
+		assert.NoError(t, err)
+		assert.Equal(t, "image/png", result)
+	}
+	buf := make([]byte, len(pngHeader))
+	n, err := syscall.Read(fd, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, pngHeader, buf[:n])
+}
+
+func (s *MagicTestSuite) TestMagicOsFile() {
+	t := s.T()
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "image")
+	require.NoError(t, os.WriteFile(path, pngHeader, 0600))
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	result, err := s.magic.MagicOsFile(f)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+
+	offset, err := f.Seek(0, io.SeekCurrent)
+	assert.NoError(t, err)
+	assert.Zero(t, offset)
+	content, err := io.ReadAll(f)
+	assert.NoError(t, err)
+	assert.Equal(t, pngHeader, content)
+}
+
 func (s *MagicTestSuite) TestMagicCompile() {
 	t := s.T()
 	t.Parallel()
@@ -258,6 +360,16 @@ func (s *MagicTestSuite) TestMagicCompile() {
 	}
 	magic, err := NewMagic(MagicMimeType | MagicError)
 	require.NoError(t, err)
+	// MagicCompile writes into the working directory, the package one.
+	t.Cleanup(func() {
+		names := []string{"lua.mgc", "rpm.mgc"}
+		for _, file := range strings.Split(DefaultDatabasePath(), ":") {
+			names = append(names, compiledName(file))
+		}
+		for _, name := range names {
+			os.Remove(name)
+		}
+	})
 	tests := []struct {
 		name      string
 		args      args
@@ -350,6 +462,35 @@ func (s *MagicTestSuite) TestMagicList() {
 	}
 }
 
+func (s *MagicTestSuite) TestMagicListString() {
+	t := s.T()
+	magic, err := NewMagic(MagicMimeType | MagicError)
+	require.NoError(t, err)
+	defer magic.Close()
+
+	result, err := magic.MagicListString([]string{"../testdata/lua"})
+	assert.NoError(t, err)
+	assert.Contains(t, result, "Lua script text executable")
+
+	_, err = magic.MagicListString([]string{"../testdata/nonexist"})
+	assert.Error(t, err)
+
+	// Listing to the captured stdout itself, redirected to a file.
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	defer out.Close()
+	saved, err := syscall.Dup(syscall.Stdout)
+	require.NoError(t, err)
+	defer syscall.Close(saved)
+	require.NoError(t, syscall.Dup2(int(out.Fd()), syscall.Stdout))
+	err = magic.MagicListTo(os.Stdout, []string{"../testdata/magic.mgc"})
+	require.NoError(t, syscall.Dup2(saved, syscall.Stdout))
+	assert.NoError(t, err)
+	data, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Lua script text executable")
+}
+
 func (s *MagicTestSuite) TestMagicCheck() {
 	t := s.T()
 	t.Parallel()
@@ -411,8 +552,8 @@ func (s *MagicTestSuite) TestMagicSetFlags() {
 	require.NoError(s.T(), err)
 	tests := []struct {
 		name  string
-		flags int
-		want  int
+		flags Flags
+		want  Flags
 	}{
 		{
 			name:  "MagicMimeType",
@@ -424,20 +565,112 @@ func (s *MagicTestSuite) TestMagicSetFlags() {
 			flags: MagicMimeType | MagicError,
 			want:  MagicMimeType | MagicError,
 		},
+		{
+			name:  "MagicCompress|MagicCompressTransp",
+			flags: MagicCompress | MagicCompressTransp,
+			want:  MagicCompress | MagicCompressTransp,
+		},
+		{
+			name:  "MagicNoDesc",
+			flags: MagicNoDesc,
+			want:  MagicExtension | MagicMimeType | MagicMimeEncoding | MagicApple,
+		},
 	}
 
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
 			assert.NoError(t, magic.MagicSetFlags(tt.flags))
 			assert.Equal(t, tt.want, magic.MagicGetFlags())
+			assert.Equal(t, tt.want, magic.flags)
+		})
+	}
+}
+
+func (s *MagicTestSuite) TestMagicParam() {
+	t := s.T()
+	t.Parallel()
+	magic, err := NewMagic(MagicNone)
+	require.NoError(t, err)
+	defer magic.Close()
+	tests := []struct {
+		name  string
+		param Param
+		value uint
+	}{
+		{
+			name:  "MagicParamIndirMax",
+			param: MagicParamIndirMax,
+			value: 10,
+		},
+		{
+			name:  "MagicParamBytesMax",
+			param: MagicParamBytesMax,
+			value: 1 << 20,
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			assert.NoError(t, magic.MagicSetParam(tt.param, tt.value))
+			value, err := magic.MagicGetParam(tt.param)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.value, value)
 		})
 	}
 }
 
+func (s *MagicTestSuite) TestVersion() {
+	t := s.T()
+	t.Parallel()
+	assert.Greater(t, Version(), 0)
+	assert.Equal(t, Version() >= versionGetFlags, SupportsGetFlags())
+	assert.Equal(t, Version() >= versionSetParam, SupportsParams())
+	assert.Equal(t, Version() >= versionLoadBuffers, SupportsLoadBuffers())
+	assert.True(t, SupportsFlags(MagicMimeType|MagicError))
+	assert.Equal(t, Version() >= 538, SupportsFlags(MagicNoCheckCsv|MagicNoCheckJSON))
+	assert.Equal(t, Version() >= 545, SupportsFlags(MagicNoCheckSimh))
+}
+
+func (s *MagicTestSuite) TestCStringScratch() {
+	t := s.T()
+	t.Parallel()
+	magic, err := NewMagic(MagicNone)
+	require.NoError(t, err)
+	defer magic.Close()
+
+	magic.cString("short")
+	scratch := magic.scratch
+	assert.NotNil(t, scratch)
+	magic.cString("another short one")
+	assert.Equal(t, scratch, magic.scratch)
+	magic.cString(strings.Repeat("x", 1000))
+	assert.GreaterOrEqual(t, magic.scratchSize, 1001)
+}
+
 func (s *MagicTestSuite) TestMagicError() {
 	magic, err := NewMagic(MagicNone)
 	require.NoError(s.T(), err)
-	assert.NotPanics(s.T(), func() { magic.magicError("") })
+	assert.NotPanics(s.T(), func() { magic.magicError("", nil, "") })
+}
+
+func (s *MagicTestSuite) TestMagicErrno() {
+	t := s.T()
+	t.Parallel()
+	magic, err := NewMagic(MagicError)
+	require.NoError(t, err)
+	defer magic.Close()
+	require.NoError(t, magic.MagicLoad([]string{"../testdata/magic.mgc"}))
+
+	_, err = magic.MagicFile("../testdata/nonexist.mgc")
+	require.Error(t, err)
+	var magicErr *Error
+	require.ErrorAs(t, err, &magicErr)
+	assert.Equal(t, syscall.ENOENT, magicErr.Errno)
+	assert.ErrorIs(t, err, syscall.ENOENT)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = magic.MagicGetParam(Param(-1))
+	assert.Error(t, err)
 }
 
 func TestMagic(t *testing.T) {
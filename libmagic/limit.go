@@ -0,0 +1,42 @@
+package libmagic
+
+import (
+	"context"
+)
+
+// WithMaxInflight caps the number of libmagic calls a MagicDetector runs at
+// the same time to n. Every running cgo call occupies an OS thread, and calls
+// abandoned after a timeout keep running on handles that were replaced, so
+// without a cap a burst of slow detections can grow the thread count well
+// beyond the number of handles. Callers over the cap wait, honoring their
+// context. A value of zero or less means no limit, which is the default.
+func WithMaxInflight(n int) DetectorOption {
+	return func(d *MagicDetector) {
+		if n > 0 {
+			d.inflight = make(chan struct{}, n)
+		} else {
+			d.inflight = nil
+		}
+	}
+}
+
+// enter takes a slot of the inflight limit, waiting until one is free or ctx
+// is done.
+func (d *MagicDetector) enter(ctx context.Context) error {
+	if d.inflight == nil {
+		return nil
+	}
+	select {
+	case d.inflight <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// leave gives back a slot taken by enter.
+func (d *MagicDetector) leave() {
+	if d.inflight != nil {
+		<-d.inflight
+	}
+}
@@ -0,0 +1,34 @@
+package libmagic
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectMaxInflight() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(2), WithFlags(MagicMimeType), WithDatabases("../testdata/magic.mgc"), WithMaxInflight(1))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	result, err := detector.DetectBuffer(pngHeader)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+
+	// Take the only slot; a handle is still idle, but the call has to wait.
+	require.NoError(t, detector.enter(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = detector.DetectBufferCtx(ctx, pngHeader)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	detector.leave()
+
+	result, err = detector.DetectBuffer(pngHeader)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+	assert.Empty(t, detector.inflight)
+}
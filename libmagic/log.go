@@ -0,0 +1,52 @@
+package libmagic
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// WithLogger makes the MagicDetector log debug events to logger: databases
+// loaded by NewDetector and Reload, and the start and end of every detection
+// with its input, duration, result and error. Nothing is logged, and no
+// time is measured, unless logger has debug level enabled.
+func WithLogger(logger *slog.Logger) DetectorOption {
+	return func(d *MagicDetector) {
+		d.logger = logger
+	}
+}
+
+// logLoad logs the result of loading a handle set started at start.
+func (d *MagicDetector) logLoad(start time.Time, set *handleSet, buffers int, err error) {
+	if d.logger == nil || !d.logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	if err != nil {
+		d.logger.LogAttrs(context.Background(), slog.LevelDebug, "magic database load failed",
+			slog.Duration("duration", time.Since(start)),
+			slog.Any("error", err))
+		return
+	}
+	d.logger.LogAttrs(context.Background(), slog.LevelDebug, "magic database loaded",
+		slog.String("database", set.database),
+		slog.Int("buffers", buffers),
+		slog.Int("handles", len(set.slots)),
+		slog.Bool("lazy", set.lazy),
+		slog.Duration("duration", time.Since(start)))
+}
+
+// logDetect is like run but logs the detection.
+func (d *MagicDetector) logDetect(ctx context.Context, input slog.Attr, detect func(m *Magic) (string, error)) (string, error) {
+	d.logger.LogAttrs(ctx, slog.LevelDebug, "magic detection started", input)
+	start := time.Now()
+	raw, err := d.run(ctx, detect)
+	duration := slog.Duration("duration", time.Since(start))
+	if err != nil {
+		d.logger.LogAttrs(ctx, slog.LevelDebug, "magic detection failed", input, duration,
+			slog.Any("error", err))
+	} else {
+		d.logger.LogAttrs(ctx, slog.LevelDebug, "magic detection finished", input, duration,
+			slog.String("result", raw))
+	}
+	return raw, err
+}
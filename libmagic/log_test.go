@@ -0,0 +1,55 @@
+package libmagic
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectorWithLogger() {
+	t := s.T()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType|MagicError),
+		WithDatabases("../testdata/magic.mgc"), WithLogger(logger))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	_, err = detector.DetectBuffer(pngHeader)
+	require.NoError(t, err)
+	_, err = detector.DetectFile("../testdata/nonexist")
+	require.Error(t, err)
+
+	var events []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var event map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+		events = append(events, event)
+	}
+	require.Len(t, events, 5)
+	assert.Equal(t, "magic database loaded", events[0]["msg"])
+	assert.Equal(t, "../testdata/magic.mgc", events[0]["database"])
+	assert.Equal(t, float64(1), events[0]["handles"])
+	assert.Equal(t, "magic detection started", events[1]["msg"])
+	assert.Equal(t, float64(len(pngHeader)), events[1]["len"])
+	assert.Equal(t, "magic detection finished", events[2]["msg"])
+	assert.Equal(t, "image/png", events[2]["result"])
+	assert.Contains(t, events[2], "duration")
+	assert.Equal(t, "magic detection failed", events[4]["msg"])
+	assert.Equal(t, "../testdata/nonexist", events[4]["path"])
+	assert.Equal(t, "magic_file", events[4]["error"].(map[string]any)["op"])
+
+	buf.Reset()
+	logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	detector, err = NewDetector(WithPoolSize(1), WithDatabases("../testdata/magic.mgc"),
+		WithLogger(logger))
+	require.NoError(t, err)
+	defer detector.Close()
+	_, err = detector.DetectBuffer(pngHeader)
+	require.NoError(t, err)
+	assert.Empty(t, buf.String())
+}
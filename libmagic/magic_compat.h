@@ -0,0 +1,105 @@
+/*
+ * Shims for libmagic functions that are missing from older releases. Each
+ * gomagic_* wrapper forwards to libmagic when the installed magic.h is recent
+ * enough and otherwise fails with ENOSYS, so the package builds against
+ * libmagic 5.2x through current. GOMAGIC_HAVE_* report what was compiled in.
+ */
+#ifndef GOMAGIC_COMPAT_H
+#define GOMAGIC_COMPAT_H
+
+#include <errno.h>
+#include <stddef.h>
+#include <magic.h>
+
+#ifdef MAGIC_VERSION
+#define GOMAGIC_HEADER_VERSION MAGIC_VERSION
+#else
+#define GOMAGIC_HEADER_VERSION 0
+#endif
+
+#define GOMAGIC_HAVE_VERSION (GOMAGIC_HEADER_VERSION > 0)
+#define GOMAGIC_HAVE_PARAMS (GOMAGIC_HEADER_VERSION >= 521)
+#define GOMAGIC_HAVE_LOAD_BUFFERS (GOMAGIC_HEADER_VERSION >= 523)
+#define GOMAGIC_HAVE_GETFLAGS (GOMAGIC_HEADER_VERSION >= 533)
+
+/* Flags newer than the oldest supported header, with their magic.h values. */
+#ifndef MAGIC_EXTENSION
+#define MAGIC_EXTENSION 0x1000000
+#endif
+#ifndef MAGIC_COMPRESS_TRANSP
+#define MAGIC_COMPRESS_TRANSP 0x2000000
+#endif
+#ifndef MAGIC_NODESC
+#define MAGIC_NODESC (MAGIC_EXTENSION|MAGIC_MIME|MAGIC_APPLE)
+#endif
+#ifndef MAGIC_NO_CHECK_CSV
+#define MAGIC_NO_CHECK_CSV 0x0080000
+#endif
+#ifndef MAGIC_NO_CHECK_JSON
+#define MAGIC_NO_CHECK_JSON 0x0400000
+#endif
+#ifndef MAGIC_NO_CHECK_SIMH
+#define MAGIC_NO_CHECK_SIMH 0x0800000
+#endif
+
+static inline int gomagic_version(void)
+{
+#if GOMAGIC_HAVE_VERSION
+	return magic_version();
+#else
+	return 0;
+#endif
+}
+
+static inline int gomagic_getflags(magic_t ms)
+{
+#if GOMAGIC_HAVE_GETFLAGS
+	return magic_getflags(ms);
+#else
+	(void)ms;
+	errno = ENOSYS;
+	return -1;
+#endif
+}
+
+static inline int gomagic_setparam(magic_t ms, int param, const void *val)
+{
+#if GOMAGIC_HAVE_PARAMS
+	return magic_setparam(ms, param, val);
+#else
+	(void)ms;
+	(void)param;
+	(void)val;
+	errno = ENOSYS;
+	return -1;
+#endif
+}
+
+static inline int gomagic_getparam(magic_t ms, int param, void *val)
+{
+#if GOMAGIC_HAVE_PARAMS
+	return magic_getparam(ms, param, val);
+#else
+	(void)ms;
+	(void)param;
+	(void)val;
+	errno = ENOSYS;
+	return -1;
+#endif
+}
+
+static inline int gomagic_load_buffers(magic_t ms, void **buffers, size_t *sizes, size_t nbuffers)
+{
+#if GOMAGIC_HAVE_LOAD_BUFFERS
+	return magic_load_buffers(ms, buffers, sizes, nbuffers);
+#else
+	(void)ms;
+	(void)buffers;
+	(void)sizes;
+	(void)nbuffers;
+	errno = ENOSYS;
+	return -1;
+#endif
+}
+
+#endif /* GOMAGIC_COMPAT_H */
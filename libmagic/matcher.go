@@ -0,0 +1,109 @@
+package libmagic
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// Matcher recognizes content in Go, such as proprietary formats that will
+// never be in a magic database. It returns the Result for content and true,
+// or false if it does not recognize it. content must not be retained.
+type Matcher func(content []byte) (Result, bool)
+
+// unrecognizedOutputs are the outputs libmagic prints for content no rule
+// matches, for each kind of output.
+var unrecognizedOutputs = []string{"", "data", "application/octet-stream", unknownExtension, unknownAppleCode + unknownAppleCode}
+
+// WithMatchersBefore registers matchers tried, in order, before libmagic by
+// DetectFileResult, DetectBufferResult and DetectReaderResult; the first one
+// recognizing the content provides the Result and libmagic is not called.
+// Files and readers are matched on their first DefaultHeadSize bytes.
+func WithMatchersBefore(matchers ...Matcher) DetectorOption {
+	return func(d *MagicDetector) {
+		d.matchersBefore = append(d.matchersBefore, matchers...)
+	}
+}
+
+// WithMatchersAfter is like WithMatchersBefore but the matchers are only
+// tried when libmagic does not recognize the content, that is when it only
+// reports "data", "application/octet-stream" or an unknown extension or
+// Apple code.
+func WithMatchersAfter(matchers ...Matcher) DetectorOption {
+	return func(d *MagicDetector) {
+		d.matchersAfter = append(d.matchersAfter, matchers...)
+	}
+}
+
+func (d *MagicDetector) hasMatchers() bool {
+	return len(d.matchersBefore) > 0 || len(d.matchersAfter) > 0
+}
+
+// match returns the Result of content, named path if it is a file: that of
+// the matchers, or that of libmagic run by detect.
+func (d *MagicDetector) match(path string, content []byte, detect func() (string, error)) (Result, error) {
+	for _, matcher := range d.matchersBefore {
+		if r, ok := matcher(content); ok {
+			r.Path = path
+			return d.finish(r), nil
+		}
+	}
+	raw, err := detect()
+	if err == nil && unrecognized(raw) {
+		for _, matcher := range d.matchersAfter {
+			if r, ok := matcher(content); ok {
+				r.Path = path
+				return d.finish(r), nil
+			}
+		}
+	}
+	if path != "" {
+		// Only the head of the file was read.
+		content = nil
+	}
+	return d.finishContent(newResult(path, raw, d.flags, err), content), err
+}
+
+// matchFile is like match but reads the content of the file at path. Files
+// that cannot be read, or are not regular files, are left to libmagic.
+func (d *MagicDetector) matchFile(path string) (Result, error) {
+	detect := func() (string, error) { return d.DetectFile(path) }
+	if f, err := os.Open(path); err == nil {
+		defer f.Close()
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			return d.matchReader(path, f, detect)
+		}
+	}
+	raw, err := detect()
+	return d.result(path, raw, err), err
+}
+
+// matchReader is like match but reads the content from r, up to
+// DefaultHeadSize bytes. A nil detect detects the bytes read.
+func (d *MagicDetector) matchReader(path string, r io.Reader, detect func() (string, error)) (Result, error) {
+	p := headBuffers.Get().(*[]byte)
+	defer headBuffers.Put(p)
+	n, err := io.ReadFull(r, *p)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return d.result(path, "", err), err
+	}
+	head := (*p)[:n]
+	if detect == nil {
+		detect = func() (string, error) { return d.DetectBuffer(head) }
+	}
+	return d.match(path, head, detect)
+}
+
+// unrecognized reports whether raw means libmagic did not recognize the
+// content.
+func unrecognized(raw string) bool {
+	mime, _, _ := strings.Cut(raw, ";")
+	mime = strings.TrimSpace(mime)
+	for _, output := range unrecognizedOutputs {
+		if mime == output {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package libmagic
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectorMatchers() {
+	t := s.T()
+	internal := []byte("ACME\x00\x01\x02\x03\xff\xfe")
+	acme := func(content []byte) (Result, bool) {
+		if !bytes.HasPrefix(content, []byte("ACME\x00")) {
+			return Result{}, false
+		}
+		return Result{MIME: "application/x-acme", Description: "ACME archive"}, true
+	}
+	allPNG := func(content []byte) (Result, bool) {
+		return Result{MIME: "image/png", Description: "overridden"}, true
+	}
+
+	path := filepath.Join(t.TempDir(), "archive")
+	require.NoError(t, os.WriteFile(path, internal, 0o600))
+
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType),
+		WithDatabases("../testdata/magic.mgc"), WithMatchersAfter(acme, allPNG))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	result, err := detector.DetectBufferResult(internal)
+	require.NoError(t, err)
+	assert.Equal(t, "application/x-acme", result.MIME)
+	result, err = detector.DetectFileResult(path)
+	require.NoError(t, err)
+	assert.Equal(t, "application/x-acme", result.MIME)
+	assert.Equal(t, path, result.Path)
+	result, err = detector.DetectReaderResult(bytes.NewReader(internal))
+	require.NoError(t, err)
+	assert.Equal(t, "application/x-acme", result.MIME)
+
+	result, err = detector.DetectBufferResult([]byte("hello world\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", result.MIME)
+
+	detector, err = NewDetector(WithPoolSize(1), WithFlags(MagicMimeType),
+		WithDatabases("../testdata/magic.mgc"), WithMatchersBefore(allPNG))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	result, err = detector.DetectBufferResult([]byte("hello world\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "overridden", result.Description)
+
+	missing := filepath.Join(t.TempDir(), "missing")
+	result, err = detector.DetectFileResult(missing)
+	assert.NoError(t, err)
+	assert.NotEqual(t, "overridden", result.Description)
+}
+
+func (s *MagicTestSuite) TestUnrecognized() {
+	t := s.T()
+	assert.True(t, unrecognized("data"))
+	assert.True(t, unrecognized("application/octet-stream; charset=binary"))
+	assert.True(t, unrecognized("???"))
+	assert.True(t, unrecognized("UNKNUNKN"))
+	assert.False(t, unrecognized("image/png"))
+	assert.False(t, unrecognized("ASCII text"))
+}
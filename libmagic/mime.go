@@ -0,0 +1,41 @@
+package libmagic
+
+import (
+	"mime"
+	"strings"
+)
+
+// MIMEType is a MIME type as printed by libmagic with MagicMime, split into
+// its parts.
+type MIMEType struct {
+	// Type and Subtype are lower case, e.g. "text" and "plain".
+	Type    string
+	Subtype string
+	// Params holds the parameters, e.g. "charset", keyed by lower case name.
+	Params map[string]string
+}
+
+// ParseMIME parses a MIME type such as "text/plain; charset=us-ascii".
+func ParseMIME(s string) (MIMEType, error) {
+	mediaType, params, err := mime.ParseMediaType(s)
+	if err != nil {
+		return MIMEType{}, err
+	}
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	return MIMEType{Type: typ, Subtype: subtype, Params: params}, nil
+}
+
+// String returns the MIME type in the form accepted by ParseMIME.
+func (t MIMEType) String() string {
+	return mime.FormatMediaType(t.Type+"/"+t.Subtype, t.Params)
+}
+
+// IsText reports whether t is a text type.
+func (t MIMEType) IsText() bool {
+	return t.Type == "text"
+}
+
+// IsImage reports whether t is an image type.
+func (t MIMEType) IsImage() bool {
+	return t.Type == "image"
+}
@@ -0,0 +1,59 @@
+package libmagic
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestParseMIME() {
+	t := s.T()
+	tests := []struct {
+		name    string
+		input   string
+		want    MIMEType
+		text    bool
+		image   bool
+		wantErr bool
+	}{
+		{
+			name:  "with charset",
+			input: "text/plain; charset=us-ascii",
+			want:  MIMEType{Type: "text", Subtype: "plain", Params: map[string]string{"charset": "us-ascii"}},
+			text:  true,
+		},
+		{
+			name:  "without parameters",
+			input: "Image/PNG",
+			want:  MIMEType{Type: "image", Subtype: "png", Params: map[string]string{}},
+			image: true,
+		},
+		{
+			name:    "invalid",
+			input:   "text/plain; charset",
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			input:   "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			got, err := ParseMIME(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.text, got.IsText())
+			assert.Equal(t, tt.image, got.IsImage())
+		})
+	}
+
+	mimeType, err := ParseMIME("application/octet-stream; charset=binary")
+	require.NoError(t, err)
+	assert.Equal(t, "application/octet-stream; charset=binary", mimeType.String())
+}
@@ -0,0 +1,47 @@
+package libmagic
+
+import (
+	"strings"
+)
+
+// mimeAliases maps historical or non-standard MIME types libmagic and other
+// tools print to their canonical form.
+var mimeAliases = map[string]string{
+	"application/x-gzip":           "application/gzip",
+	"application/x-javascript":     "text/javascript",
+	"application/x-pdf":            "application/pdf",
+	"application/x-zip-compressed": "application/zip",
+	"audio/x-wav":                  "audio/wav",
+	"image/jpg":                    "image/jpeg",
+	"image/pjpeg":                  "image/jpeg",
+	"image/svg":                    "image/svg+xml",
+	"image/x-icon":                 "image/vnd.microsoft.icon",
+	"image/x-ms-bmp":               "image/bmp",
+	"text/xml":                     "application/xml",
+}
+
+// NormalizeMIME returns mime with its type replaced by the canonical form if
+// it is a known alias, e.g. "application/gzip" for "application/x-gzip".
+// Parameters such as "; charset=binary" are kept; other types are returned
+// unchanged.
+func NormalizeMIME(mime string) string {
+	typ, params, hasParams := strings.Cut(mime, ";")
+	canonical, ok := mimeAliases[strings.ToLower(strings.TrimSpace(typ))]
+	if !ok {
+		return mime
+	}
+	if hasParams {
+		return canonical + ";" + params
+	}
+	return canonical
+}
+
+// WithMIMENormalization makes the MIME type of every Result of the
+// MagicDetector canonical, see NormalizeMIME, so policy checks are not
+// tripped up by historical names such as "text/xml". Raw is changed to
+// match. APIs returning the libmagic output as a string are not affected.
+func WithMIMENormalization() DetectorOption {
+	return func(d *MagicDetector) {
+		d.normalizeMIME = true
+	}
+}
@@ -0,0 +1,54 @@
+package libmagic
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestNormalizeMIME() {
+	t := s.T()
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "text/xml", want: "application/xml"},
+		{input: "application/x-gzip", want: "application/gzip"},
+		{input: "image/JPG", want: "image/jpeg"},
+		{input: "text/xml; charset=us-ascii", want: "application/xml; charset=us-ascii"},
+		{input: "image/png", want: "image/png"},
+		{input: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.input, func() {
+			assert.Equal(t, tt.want, NormalizeMIME(tt.input))
+		})
+	}
+}
+
+func (s *MagicTestSuite) TestDetectorWithMIMENormalization() {
+	t := s.T()
+	xml := []byte("<?xml version=\"1.0\"?>\n<a/>\n")
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMime),
+		WithDatabases("../testdata/magic.mgc"), WithMIMENormalization())
+	require.NoError(t, err)
+	defer detector.Close()
+
+	result, err := detector.DetectBufferResult(xml)
+	require.NoError(t, err)
+	assert.Equal(t, "application/xml", result.MIME)
+	assert.Equal(t, "us-ascii", result.Encoding)
+	assert.Equal(t, "application/xml; charset=us-ascii", result.Raw)
+
+	raw, err := detector.DetectBuffer(xml)
+	require.NoError(t, err)
+	assert.Equal(t, "text/xml; charset=us-ascii", raw)
+
+	detector, err = NewDetector(WithPoolSize(1), WithFlags(MagicMime),
+		WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+	result, err = detector.DetectBufferResult(xml)
+	require.NoError(t, err)
+	assert.Equal(t, "text/xml", result.MIME)
+}
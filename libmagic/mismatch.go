@@ -0,0 +1,114 @@
+package libmagic
+
+import (
+	"mime"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// extensionMIMEs maps file name extensions to the MIME types libmagic prints
+// for their content, where the mime package knows none or others.
+var extensionMIMEs = map[string][]string{
+	"7z":   {"application/x-7z-compressed"},
+	"apk":  {"application/vnd.android.package-archive", "application/zip"},
+	"bz2":  {"application/x-bzip2"},
+	"c":    {"text/x-c", "text/plain"},
+	"csv":  {"text/csv", "text/plain"},
+	"deb":  {"application/vnd.debian.binary-package"},
+	"dll":  {"application/vnd.microsoft.portable-executable", "application/x-dosexec"},
+	"docx": {"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "application/zip"},
+	"exe":  {"application/vnd.microsoft.portable-executable", "application/x-dosexec"},
+	"gz":   {"application/gzip"},
+	"h":    {"text/x-c", "text/plain"},
+	"ico":  {"image/vnd.microsoft.icon"},
+	"iso":  {"application/x-iso9660-image"},
+	"jar":  {"application/java-archive", "application/zip"},
+	"js":   {"text/javascript", "application/javascript", "text/plain"},
+	"json": {"application/json", "text/plain"},
+	"log":  {"text/plain"},
+	"md":   {"text/markdown", "text/plain"},
+	"pptx": {"application/vnd.openxmlformats-officedocument.presentationml.presentation", "application/zip"},
+	"py":   {"text/x-python", "text/x-script.python", "text/plain"},
+	"rar":  {"application/x-rar", "application/vnd.rar"},
+	"rpm":  {"application/x-rpm"},
+	"sh":   {"text/x-shellscript", "text/plain"},
+	"so":   {"application/x-sharedlib"},
+	"tar":  {"application/x-tar"},
+	"tgz":  {"application/gzip"},
+	"txt":  {"text/plain"},
+	"wav":  {"audio/wav"},
+	"xlsx": {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "application/zip"},
+	"xml":  {"application/xml"},
+	"xz":   {"application/x-xz"},
+	"yaml": {"text/plain"},
+	"yml":  {"text/plain"},
+	"zip":  {"application/zip"},
+	"zst":  {"application/zstd"},
+}
+
+// MismatchReport compares the content of a file with its name extension.
+type MismatchReport struct {
+	Path string
+	// Extension is the extension of Path, lower case and without the dot.
+	Extension string
+	// Expected are the MIME types the extension implies, canonical as
+	// returned by NormalizeMIME; empty for unknown extensions.
+	Expected []string
+	// Detected is the canonical MIME type of the content.
+	Detected string
+	// Mismatch is set when the extension implies MIME types and the content
+	// is none of them, such as an executable named "photo.jpg".
+	Mismatch bool
+	// Result is the Result of the detection.
+	Result Result
+}
+
+// ExpectedMIME returns the canonical MIME types the file name extension ext
+// implies, with or without the leading dot, from the mime package and a
+// table of the types libmagic prints.
+func ExpectedMIME(ext string) []string {
+	ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+	if ext == "" {
+		return nil
+	}
+	var expected []string
+	add := func(mimeType string) {
+		mimeType = canonicalMIME(mimeType)
+		if mimeType != "" && !slices.Contains(expected, mimeType) {
+			expected = append(expected, mimeType)
+		}
+	}
+	add(mime.TypeByExtension("." + ext))
+	for _, mimeType := range extensionMIMEs[ext] {
+		add(mimeType)
+	}
+	return expected
+}
+
+// CheckMismatch detects the MIME type of the file at path and compares it
+// with the types its extension implies, see ExpectedMIME. Plain text never
+// contradicts an extension of a text type, as libmagic often cannot tell
+// text formats apart. Files with no or unknown extensions are reported
+// without a mismatch. Files that cannot be read are an error.
+func (d *MagicDetector) CheckMismatch(path string) (MismatchReport, error) {
+	flags := d.flags&^(outputFlags|MagicContinue) | MagicMimeType | MagicError
+	raw, err := d.DetectFileWithFlags(path, flags)
+	report := MismatchReport{
+		Path:      path,
+		Extension: strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")),
+		Result:    d.finish(newResult(path, raw, flags, err)),
+	}
+	if err != nil {
+		return report, err
+	}
+	report.Expected = ExpectedMIME(report.Extension)
+	report.Detected = NormalizeMIME(report.Result.MIME)
+	report.Mismatch = len(report.Expected) > 0 && !slices.Contains(report.Expected, report.Detected) &&
+		!(report.Detected == "text/plain" && slices.ContainsFunc(report.Expected, isTextMIME))
+	return report, nil
+}
+
+func isTextMIME(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "text/")
+}
@@ -0,0 +1,57 @@
+package libmagic
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestExpectedMIME() {
+	t := s.T()
+	assert.Contains(t, ExpectedMIME(".JPG"), "image/jpeg")
+	assert.Equal(t, []string{"application/gzip"}, ExpectedMIME("gz"))
+	assert.Contains(t, ExpectedMIME("exe"), "application/x-dosexec")
+	assert.Empty(t, ExpectedMIME(""))
+	assert.Empty(t, ExpectedMIME("no-such-extension"))
+}
+
+func (s *MagicTestSuite) TestDetectorCheckMismatch() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		content  []byte
+		mismatch bool
+	}{
+		{"photo.jpg", pngHeader, true},
+		{"image.PNG", pngHeader, false},
+		{"notes.txt", []byte("hello world\n"), false},
+		{"main.c", []byte("hello world\n"), false},
+		{"archive.zip", []byte("hello world\n"), true},
+		{"README", []byte("hello world\n"), false},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		require.NoError(t, os.WriteFile(path, tt.content, 0600))
+		report, err := detector.CheckMismatch(path)
+		require.NoError(t, err)
+		assert.Equal(t, tt.mismatch, report.Mismatch, tt.name)
+		assert.Equal(t, path, report.Path)
+		assert.Equal(t, report.Detected, report.Result.MIME)
+	}
+
+	report, err := detector.CheckMismatch(filepath.Join(dir, "photo.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "jpg", report.Extension)
+	assert.Contains(t, report.Expected, "image/jpeg")
+	assert.Equal(t, "image/png", report.Detected)
+
+	_, err = detector.CheckMismatch(filepath.Join(dir, "missing.png"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,44 @@
+package libmagic
+
+import (
+	"os"
+)
+
+// DetectFileMmap detects the content of the regular file at path by mapping
+// it into memory and handing the mapping to libmagic as a buffer, which
+// avoids the reads and copies of DetectFile when scanning large files. Only
+// the first limit bytes are mapped; a limit of zero or less maps the whole
+// file. Other kinds of files, and platforms without mmap, use DetectFile.
+//
+// Unlike DetectFileCtx there is no variant giving up early: the mapping must
+// outlive the libmagic call.
+//
+// The file must not shrink while it is mapped: libmagic reading a page past
+// the new end of file raises SIGBUS, which crashes the process since the
+// fault happens in C, out of reach of recover and debug.SetPanicOnFault.
+// Use DetectFile or DetectReaderHead instead for files that other processes
+// may truncate, such as logs being rotated.
+func (d *MagicDetector) DetectFileMmap(path string, limit int) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	size := info.Size()
+	if !info.Mode().IsRegular() || size == 0 || !mmapSupported {
+		return d.DetectFile(path)
+	}
+	if limit > 0 && int64(limit) < size {
+		size = int64(limit)
+	}
+	data, err := mmap(f, int(size))
+	if err != nil {
+		return "", err
+	}
+	defer munmap(data)
+	return d.DetectBuffer(data)
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package libmagic
+
+import (
+	"os"
+)
+
+const mmapSupported = false
+
+func mmap(f *os.File, size int) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+func munmap(data []byte) {}
@@ -0,0 +1,44 @@
+package libmagic
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectFileMmap() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	dir := t.TempDir()
+	png := filepath.Join(dir, "image.png")
+	require.NoError(t, os.WriteFile(png, append(append([]byte{}, pngHeader...), make([]byte, 1<<16)...), 0o644))
+	empty := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(empty, nil, 0o644))
+
+	for _, limit := range []int{0, 64, 1 << 20} {
+		result, err := detector.DetectFileMmap(png, limit)
+		assert.NoError(t, err)
+		assert.Equal(t, "image/png", result)
+	}
+
+	expected, err := detector.DetectFile(empty)
+	require.NoError(t, err)
+	result, err := detector.DetectFileMmap(empty, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	expected, err = detector.DetectFile(dir)
+	require.NoError(t, err)
+	result, err = detector.DetectFileMmap(dir, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	_, err = detector.DetectFileMmap(filepath.Join(dir, "nonexist"), 0)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
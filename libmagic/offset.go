@@ -0,0 +1,144 @@
+package libmagic
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// mgetLine matches the debug line libmagic prints before reading the
+	// value of a test, such as "mget(type=5, flag=0, offset=32769, o=0,
+	// nbytes=36864, ...)", with the offset the test resolved to and the size
+	// of the content.
+	mgetLine = regexp.MustCompile(`^mget\(type=\d+, flag=\w+, offset=(\d+), o=\d+, nbytes=(\d+),`)
+	// testLine matches the debug line describing a test, such as
+	// `1968: > 32769 string,=CD001,""]`; a single '>' marks a top level one.
+	testLine = regexp.MustCompile(`^\d+: (>+) \S+ (\w+)`)
+	// tryLine matches the debug line telling which method produced the
+	// output, such as "[try softmagic 1]".
+	tryLine = regexp.MustCompile(`^\[try (\w+) 1\]$`)
+)
+
+// matchTracer follows the debug output of a detection to find the offset of
+// the last top level test that matched, and whether tests read past the end
+// of the content.
+type matchTracer struct {
+	// read is the offset of the test being run, size that of the content;
+	// top is whether the test is a top level one.
+	read, size int64
+	top        bool
+	// matched is the offset of the last top level test that matched, or -1;
+	// inMatch is set while running the continuations of that test.
+	matched int64
+	inMatch bool
+	// beyond is set when a top level test, or a continuation of the one that
+	// matched, read past the end of the content.
+	beyond bool
+	// soft is whether the output came from the magic tests rather than
+	// libmagic's built-in ones.
+	soft bool
+}
+
+func newMatchTracer() *matchTracer {
+	return &matchTracer{matched: -1}
+}
+
+func (t *matchTracer) line(line string) {
+	if match := tryLine.FindStringSubmatch(line); match != nil {
+		t.soft = match[1] == "softmagic"
+		return
+	}
+	if match := mgetLine.FindStringSubmatch(line); match != nil {
+		t.read, _ = strconv.ParseInt(match[1], 10, 64)
+		t.size, _ = strconv.ParseInt(match[2], 10, 64)
+		t.top = false
+		return
+	}
+	if match := testLine.FindStringSubmatch(line); match != nil {
+		// Named subroutines start at level 0 too, within the rule using
+		// them.
+		t.top = len(match[1]) == 1 && match[2] != "name"
+		if t.top {
+			t.inMatch = false
+		}
+		if (t.top || t.inMatch) && t.read >= t.size {
+			t.beyond = true
+		}
+		return
+	}
+	if t.top && strings.HasSuffix(line, " = 1") {
+		t.matched = t.read
+		t.top = false
+		t.inMatch = true
+	}
+}
+
+// offset returns the offset of the match, or -1 if the output did not come
+// from a magic test.
+func (t *matchTracer) offset() int64 {
+	if !t.soft {
+		return -1
+	}
+	return t.matched
+}
+
+// WithDebugMatchOffset makes DetectFileResult, DetectBufferResult and
+// DetectReaderResult set the Offset of every Result to where the signature
+// that matched was found, such as 0x8001 for an ISO 9660 image: the offset of
+// the last top level magic test that passed. It is -1 for content
+// recognized by libmagic's built-in tests, such as text.
+//
+// It is meant for debugging magic files, not for regular detections. The
+// offset is scraped from libmagic's debug output, whose format changes
+// between libmagic versions, see SetDebugFunc. While a traced detection
+// runs, the process stderr is redirected into the tracer, so anything else
+// written to it, including by the Go runtime and loggers, is lost; traced
+// detections of all MagicDetectors run one at a time.
+//
+// Detections are not traced when matchers are set, see WithMatchersBefore
+// and WithTextFastPath, nor by DetectAll: their Results have an Offset of
+// -1 too.
+func WithDebugMatchOffset() DetectorOption {
+	return func(d *MagicDetector) {
+		d.matchOffset = true
+	}
+}
+
+// trace runs detect with MagicDebug enabled and returns its output along
+// with the tracer that followed it. The caller must hold m.lock.
+func (m *Magic) trace(detect func() (string, error)) (string, *matchTracer, error) {
+	restore, err := m.withFlags(m.flags | MagicDebug)
+	if err != nil {
+		return "", nil, err
+	}
+	defer restore()
+	tracer, debugFunc := newMatchTracer(), m.debugFunc
+	m.debugFunc = tracer.line
+	defer func() { m.debugFunc = debugFunc }()
+	raw, err := detect()
+	return raw, tracer, err
+}
+
+// tracedResult returns the unfinished Result of running detect on a handle
+// of d, with Offset set if d reports it, along with the tracer that followed
+// the detection.
+func (d *MagicDetector) tracedResult(path string, input slog.Attr, detect func(m *Magic) (string, error)) (Result, *matchTracer, error) {
+	var tracer *matchTracer
+	raw, err := d.detect(context.Background(), input, func(m *Magic) (string, error) {
+		raw, traced, err := m.trace(func() (string, error) { return detect(m) })
+		tracer = traced
+		return raw, err
+	})
+	r := newResult(path, raw, d.flags, err)
+	if err != nil || tracer == nil {
+		return r, nil, err
+	}
+	if d.matchOffset {
+		offset := tracer.offset()
+		r.Offset = &offset
+	}
+	return r, tracer, nil
+}
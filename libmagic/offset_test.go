@@ -0,0 +1,90 @@
+package libmagic
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestOffsetTracer() {
+	t := s.T()
+	tracer := newMatchTracer()
+	for _, line := range []string{
+		"mget(type=5, flag=0x20, offset=64, o=0, nbytes=36864, il=0, nc=0)",
+		`416: > 64 string,=\000\000,""]`,
+		"0 == 0 = 1",
+		"mget(type=5, flag=0x20, offset=32769, o=0, nbytes=36864, il=0, nc=0)",
+		`1968: > 32769 string,=CD001,""]`,
+		"0 == 0 = 1",
+		"mget(type=5, flag=0, offset=38913, o=0, nbytes=36864, il=0, nc=1)",
+		`1942: >> 38913 string,!NSR0,"ISO 9660 CD-ROM filesystem data"]`,
+		"0 != 1 = 1",
+		"mget(type=10, flag=0, offset=510, o=0, nbytes=36864, il=0, nc=1)",
+		`1954: > 510 leshort&,=-21931,"(DOS/MBR boot sector)"]`,
+		"0 == 18446744073709529685 = 0",
+	} {
+		tracer.line(line)
+	}
+	assert.EqualValues(t, -1, tracer.offset())
+	tracer.line("[try softmagic 1]")
+	assert.EqualValues(t, 0x8001, tracer.offset())
+	assert.True(t, tracer.beyond)
+
+	tracer = newMatchTracer()
+	for _, line := range []string{
+		"mget(type=1, flag=0, offset=0, o=0, nbytes=12, il=0, nc=0)",
+		`35: > 0 ubyte&,>0,""]`,
+		"104 > 0 = 1",
+		"[try ascmagic 1]",
+	} {
+		tracer.line(line)
+	}
+	assert.EqualValues(t, -1, tracer.offset())
+	assert.False(t, tracer.beyond)
+}
+
+func (s *MagicTestSuite) TestDetectorWithDebugMatchOffset() {
+	t := s.T()
+	iso := make([]byte, 0x9000)
+	copy(iso[0x8000:], "\x01CD001")
+
+	detector, err := NewDetector(WithPoolSize(1), WithDatabases("../testdata/magic.mgc"),
+		WithDebugMatchOffset())
+	require.NoError(t, err)
+	defer detector.Close()
+
+	result, err := detector.DetectBufferResult(iso)
+	require.NoError(t, err)
+	assert.Contains(t, result.Description, "ISO 9660")
+	assert.Equal(t, ptr[int64](0x8001), result.Offset)
+
+	result, err = detector.DetectReaderResult(bytes.NewReader(iso))
+	require.NoError(t, err)
+	assert.Equal(t, ptr[int64](0x8001), result.Offset)
+
+	result, err = detector.DetectBufferResult(pngHeader)
+	require.NoError(t, err)
+	assert.Equal(t, ptr[int64](0), result.Offset)
+	data, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"offset":0`)
+
+	result, err = detector.DetectBufferResult([]byte("hello world\n"))
+	require.NoError(t, err)
+	assert.Equal(t, ptr[int64](-1), result.Offset)
+
+	raw, err := detector.DetectBuffer(iso)
+	require.NoError(t, err)
+	assert.Contains(t, raw, "ISO 9660")
+
+	// Results of matchers have no offset.
+	detector, err = NewDetector(WithPoolSize(1), WithDatabases("../testdata/magic.mgc"),
+		WithDebugMatchOffset(), WithTextFastPath())
+	require.NoError(t, err)
+	defer detector.Close()
+	result, err = detector.DetectBufferResult([]byte("hello world\n"))
+	require.NoError(t, err)
+	assert.Equal(t, ptr[int64](-1), result.Offset)
+}
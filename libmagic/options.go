@@ -0,0 +1,53 @@
+package libmagic
+
+// WithPoolSize sets the number of handles of a MagicDetector. A size of zero
+// or less means runtime.GOMAXPROCS(0), which is the default.
+func WithPoolSize(size int) DetectorOption {
+	return func(d *MagicDetector) {
+		d.size = size
+	}
+}
+
+// WithFlags sets the flags the handles of a MagicDetector are opened with. It
+// defaults to MagicNone.
+func WithFlags(flags Flags) DetectorOption {
+	return func(d *MagicDetector) {
+		d.flags = flags
+	}
+}
+
+// WithDatabases sets the database files a MagicDetector loads, see MagicLoad.
+// Without it, or WithDatabaseBuffers, the default database is loaded.
+func WithDatabases(files ...string) DetectorOption {
+	return func(d *MagicDetector) {
+		d.files, d.buffers = files, nil
+	}
+}
+
+// WithDatabaseBuffers makes a MagicDetector load compiled databases from
+// memory, see MagicLoadBuffers. The buffers must not be modified afterwards.
+func WithDatabaseBuffers(buffers ...[]byte) DetectorOption {
+	return func(d *MagicDetector) {
+		d.files, d.buffers = nil, buffers
+	}
+}
+
+// WithLazyLoading makes a MagicDetector open its handles only when concurrent
+// detections need them, instead of all of them up front. The databases are
+// still loaded, once, by NewDetector and Reload, so that errors in them are
+// reported there.
+func WithLazyLoading() DetectorOption {
+	return func(d *MagicDetector) {
+		d.lazy = true
+	}
+}
+
+// WithLoadOnFirstUse makes NewDetector return without loading anything; the
+// databases are loaded, once, by the first detection instead, which speeds
+// up the start of programs that may never detect anything. Loading errors
+// are then returned by that detection and every later one.
+func WithLoadOnFirstUse() DetectorOption {
+	return func(d *MagicDetector) {
+		d.deferred = true
+	}
+}
@@ -0,0 +1,125 @@
+package libmagic
+
+import (
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestNewDetectorDefaults() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector()
+	require.NoError(t, err)
+	defer detector.Close()
+	assert.Equal(t, runtime.GOMAXPROCS(0), detector.Size())
+	assert.Equal(t, MagicNone, detector.flags)
+}
+
+func (s *MagicTestSuite) TestNewDetectorDatabaseBuffers() {
+	t := s.T()
+	t.Parallel()
+	database, err := os.ReadFile("../testdata/magic.mgc")
+	require.NoError(t, err)
+	detector, err := NewDetector(WithPoolSize(2), WithFlags(MagicMimeType), WithDatabaseBuffers(database))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	result, err := detector.DetectBuffer(pngHeader)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+
+	_, err = NewDetector(WithDatabaseBuffers([]byte("not a database")))
+	assert.Error(t, err)
+}
+
+func (s *MagicTestSuite) TestNewDetectorLazyLoading() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(4), WithFlags(MagicMimeType), WithDatabases("../testdata/magic.mgc"), WithLazyLoading())
+	require.NoError(t, err)
+	defer detector.Close()
+	countHandles := func() int {
+		set := detector.set.Load()
+		n := 0
+		for i := range set.slots {
+			if set.slots[i].Load() != nil {
+				n++
+			}
+		}
+		return n
+	}
+	assert.Equal(t, 0, countHandles())
+
+	result, err := detector.DetectBuffer(pngHeader)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+	assert.Equal(t, 1, countHandles())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := detector.DetectBuffer(pngHeader)
+			assert.NoError(t, err)
+			assert.Equal(t, "image/png", result)
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, countHandles(), 4)
+
+	require.NoError(t, detector.Reload([]string{"../testdata/magic2.mgc"}))
+	assert.Equal(t, 0, countHandles())
+	_, err = NewDetector(WithDatabases("../testdata/nonexist.mgc"), WithLazyLoading())
+	assert.Error(t, err)
+}
+
+func (s *MagicTestSuite) TestDetectorLoadOnFirstUse() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(2), WithFlags(MagicMimeType),
+		WithDatabases("../testdata/magic.mgc"), WithLoadOnFirstUse())
+	require.NoError(t, err)
+	defer detector.Close()
+	assert.Nil(t, detector.set.Load())
+
+	result, err := detector.DetectBuffer(pngHeader)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+	set := detector.set.Load()
+	require.NotNil(t, set)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := detector.DetectBuffer(pngHeader)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Same(t, set, detector.set.Load())
+
+	detector, err = NewDetector(WithDatabases("../testdata/nonexist.mgc"),
+		WithLoadOnFirstUse())
+	require.NoError(t, err)
+	_, err = detector.DetectBuffer(pngHeader)
+	assert.ErrorIs(t, err, ErrDatabaseNotFound)
+	_, err = detector.DetectBuffer(pngHeader)
+	assert.ErrorIs(t, err, ErrDatabaseNotFound)
+	detector.Close()
+	_, err = detector.DetectBuffer(pngHeader)
+	assert.ErrorIs(t, err, ErrClosed)
+
+	detector, err = NewDetector(WithDatabases("../testdata/magic.mgc"),
+		WithLoadOnFirstUse())
+	require.NoError(t, err)
+	detector.Close()
+	_, err = detector.DetectBuffer(pngHeader)
+	assert.ErrorIs(t, err, ErrClosed)
+	assert.Nil(t, detector.set.Load())
+}
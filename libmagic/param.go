@@ -0,0 +1,55 @@
+package libmagic
+
+// #include "magic_compat.h"
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Param identifies a libmagic tunable limit accepted by MagicSetParam and
+// MagicGetParam.
+type Param int
+
+const (
+	MagicParamIndirMax    Param = 0
+	MagicParamNameMax     Param = 1
+	MagicParamElfPhnumMax Param = 2
+	MagicParamElfShnumMax Param = 3
+	MagicParamElfNotesMax Param = 4
+	MagicParamRegexMax    Param = 5
+	MagicParamBytesMax    Param = 6
+	MagicParamEncodingMax Param = 7
+)
+
+func (m *Magic) MagicSetParam(param Param, value uint) error {
+	if !SupportsParams() {
+		return ErrUnsupported
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.handle == nil {
+		return ErrClosed
+	}
+	cValue := C.size_t(value)
+	if C.gomagic_setparam(m.handle, C.int(param), unsafe.Pointer(&cValue)) == C.int(-1) {
+		return m.magicError("magic_setparam", nil, fmt.Sprintf("failed to set param %d", param))
+	}
+	return nil
+}
+
+func (m *Magic) MagicGetParam(param Param) (uint, error) {
+	if !SupportsParams() {
+		return 0, ErrUnsupported
+	}
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if m.handle == nil {
+		return 0, ErrClosed
+	}
+	var cValue C.size_t
+	if C.gomagic_getparam(m.handle, C.int(param), unsafe.Pointer(&cValue)) == C.int(-1) {
+		return 0, m.magicError("magic_getparam", nil, fmt.Sprintf("failed to get param %d", param))
+	}
+	return uint(cValue), nil
+}
@@ -0,0 +1,45 @@
+package libmagic
+
+// DetectBufferPolyglot returns every interpretation libmagic finds for
+// content, as reported with MagicContinue, instead of only the strongest
+// one: e.g. both a GIF image and a script for content valid as both. The
+// Results follow the flags of the MagicDetector, strongest first, without
+// duplicates; the fallback "data" interpretation is only returned when it is
+// the only one.
+func (d *MagicDetector) DetectBufferPolyglot(content []byte) ([]Result, error) {
+	raw, err := d.DetectBufferWithFlags(content, d.flags|MagicContinue)
+	if err != nil {
+		return nil, err
+	}
+	return d.interpretations("", raw, content), nil
+}
+
+// DetectFilePolyglot is like DetectBufferPolyglot but detects the file at
+// path.
+func (d *MagicDetector) DetectFilePolyglot(path string) ([]Result, error) {
+	raw, err := d.DetectFileWithFlags(path, d.flags|MagicContinue)
+	if err != nil {
+		return nil, err
+	}
+	return d.interpretations(path, raw, nil), nil
+}
+
+// interpretations splits raw, detected with MagicContinue, into Results.
+// content is the detected content, or nil for the file at path.
+func (d *MagicDetector) interpretations(path, raw string, content []byte) []Result {
+	parts := splitContinue(raw)
+	recognized := false
+	for _, part := range parts {
+		recognized = recognized || !unrecognized(part)
+	}
+	var results []Result
+	seen := make(map[string]bool, len(parts))
+	for _, part := range parts {
+		if seen[part] || recognized && unrecognized(part) {
+			continue
+		}
+		seen[part] = true
+		results = append(results, d.finishContent(newResult(path, part, d.flags, nil), content))
+	}
+	return results
+}
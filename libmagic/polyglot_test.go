@@ -0,0 +1,52 @@
+package libmagic
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectorDetectPolyglot() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType),
+		WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	svg := []byte("<?xml version=\"1.0\"?>\n<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>\n")
+	tests := []struct {
+		name    string
+		content []byte
+		want    []string
+	}{
+		{name: "two interpretations", content: svg, want: []string{"image/svg+xml", "text/xml"}},
+		{name: "duplicates", content: []byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n"), want: []string{"application/pdf"}},
+		{name: "fallback dropped", content: pngHeader, want: []string{"image/png"}},
+		{name: "only fallback", content: []byte{0, 1, 2, 3, 4, 250}, want: []string{"application/octet-stream"}},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			results, err := detector.DetectBufferPolyglot(tt.content)
+			require.NoError(t, err)
+			var mimes []string
+			for _, r := range results {
+				mimes = append(mimes, r.MIME)
+			}
+			assert.Equal(t, tt.want, mimes)
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "image.svg")
+	require.NoError(t, os.WriteFile(path, svg, 0o600))
+	results, err := detector.DetectFilePolyglot(path)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, path, results[1].Path)
+	assert.Equal(t, "text/xml", results[1].MIME)
+
+	raw, err := detector.DetectBuffer(svg)
+	require.NoError(t, err)
+	assert.Equal(t, "image/svg+xml", raw)
+}
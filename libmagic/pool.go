@@ -0,0 +1,154 @@
+package libmagic
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// MagicPool is a set of up to Size handles loaded with the same databases.
+// Each call borrows an idle handle, so up to Size detections run in parallel
+// instead of serializing on the mutex of a single Magic.
+//
+// Compiled databases are read from disk once, into an idle template handle;
+// the handles are cloned from it when concurrent calls need them, sharing
+// the database bytes through MagicLoadBuffers, see Clone. Preload creates
+// them all up front.
+type MagicPool struct {
+	size int
+	// template is loaded with the databases and only used to clone the
+	// handles, so growing never waits for a detection.
+	template *Magic
+	// mu guards handles, which only grows.
+	mu      sync.Mutex
+	handles []*Magic
+	idle    chan *Magic
+}
+
+// NewMagicPool creates a pool of size handles opened with flags and loaded
+// with files. A size of zero or less means runtime.GOMAXPROCS(0) handles.
+func NewMagicPool(size int, flags Flags, files []string) (*MagicPool, error) {
+	if size <= 0 {
+		size = runtime.GOMAXPROCS(0)
+	}
+	template, err := NewMagic(flags)
+	if err != nil {
+		return nil, err
+	}
+	if buffers, readErr := readCompiledDatabases(files); readErr == nil {
+		err = template.MagicLoadBuffers(buffers)
+	} else {
+		err = template.MagicLoad(files)
+	}
+	if err != nil {
+		template.Close()
+		return nil, err
+	}
+	p := &MagicPool{
+		size:     size,
+		template: template,
+		idle:     make(chan *Magic, size),
+	}
+	m, err := p.grow()
+	if err != nil {
+		template.Close()
+		return nil, err
+	}
+	p.idle <- m
+	return p, nil
+}
+
+// Size returns the maximum number of handles in the pool.
+func (p *MagicPool) Size() int {
+	return p.size
+}
+
+// Preload creates all handles of the pool now rather than on demand, so the
+// cost is paid at startup instead of by the first concurrent calls.
+func (p *MagicPool) Preload() error {
+	for {
+		m, err := p.grow()
+		if m == nil || err != nil {
+			return err
+		}
+		p.idle <- m
+	}
+}
+
+func (p *MagicPool) MagicFile(filename string) (string, error) {
+	return p.MagicFileCtx(context.Background(), filename)
+}
+
+func (p *MagicPool) MagicBuffer(content []byte) (string, error) {
+	return p.MagicBufferCtx(context.Background(), content)
+}
+
+// MagicFileCtx is like MagicFile but gives up with ctx.Err() when ctx is done
+// before an idle handle becomes available.
+func (p *MagicPool) MagicFileCtx(ctx context.Context, filename string) (string, error) {
+	m, err := p.borrow(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer func() { p.idle <- m }()
+	return m.MagicFile(filename)
+}
+
+// MagicBufferCtx is like MagicFileCtx but detects content.
+func (p *MagicPool) MagicBufferCtx(ctx context.Context, content []byte) (string, error) {
+	m, err := p.borrow(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer func() { p.idle <- m }()
+	return m.MagicBuffer(content)
+}
+
+func (p *MagicPool) borrow(ctx context.Context) (*Magic, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	select {
+	case m := <-p.idle:
+		return m, nil
+	default:
+	}
+	if m, err := p.grow(); m != nil || err != nil {
+		return m, err
+	}
+	select {
+	case m := <-p.idle:
+		return m, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// grow clones a new handle, or returns nil if the pool is full.
+func (p *MagicPool) grow() (*Magic, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.handles) >= p.size {
+		return nil, nil
+	}
+	m, err := p.template.Clone()
+	if err != nil {
+		return nil, err
+	}
+	p.handles = append(p.handles, m)
+	return m, nil
+}
+
+// Close waits for all borrowed handles to be returned and closes them. The
+// pool must not be used afterwards.
+func (p *MagicPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for range p.handles {
+		<-p.idle
+	}
+	for _, m := range p.handles {
+		m.Close()
+	}
+	p.template.Close()
+}
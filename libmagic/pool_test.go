@@ -0,0 +1,97 @@
+package libmagic
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestMagicPool() {
+	t := s.T()
+	t.Parallel()
+	pool, err := NewMagicPool(0, MagicMimeType|MagicError, []string{"../testdata/magic.mgc"})
+	require.NoError(t, err)
+	defer pool.Close()
+	assert.Equal(t, runtime.GOMAXPROCS(0), pool.Size())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4*pool.Size(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := pool.MagicBuffer(pngHeader)
+			assert.NoError(t, err)
+			assert.Equal(t, "image/png", result)
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, len(pool.handles), pool.Size())
+
+	_, err = pool.MagicFile("../testdata/nonexist.mgc")
+	assert.Error(t, err)
+}
+
+func (s *MagicTestSuite) TestMagicPoolPreload() {
+	t := s.T()
+	t.Parallel()
+	pool, err := NewMagicPool(4, MagicMimeType, []string{"../testdata/magic.mgc"})
+	require.NoError(t, err)
+	defer pool.Close()
+	assert.Len(t, pool.handles, 1)
+
+	require.NoError(t, pool.Preload())
+	assert.Len(t, pool.handles, 4)
+	assert.Len(t, pool.idle, 4)
+	// The database was read once and is shared by all handles.
+	require.Len(t, pool.template.buffers, 1)
+	for _, m := range pool.handles {
+		require.Len(t, m.buffers, 1)
+		assert.Same(t, &pool.template.buffers[0][0], &m.buffers[0][0])
+	}
+	require.NoError(t, pool.Preload())
+	assert.Len(t, pool.handles, 4)
+
+	result, err := pool.MagicBuffer(pngHeader)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+}
+
+func (s *MagicTestSuite) TestMagicPoolGrowWhileBusy() {
+	t := s.T()
+	t.Parallel()
+	pool, err := NewMagicPool(2, MagicMimeType, []string{"../testdata/magic.mgc"})
+	require.NoError(t, err)
+	defer pool.Close()
+
+	// Growing does not wait for a detection holding the first handle.
+	busy, err := pool.borrow(context.Background())
+	require.NoError(t, err)
+	busy.lock.Lock()
+	done := make(chan error, 1)
+	go func() {
+		_, err := pool.MagicBuffer(pngHeader)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Error("growing waited for the busy handle")
+		busy.lock.Unlock()
+		<-done
+		busy.lock.Lock()
+	}
+	busy.lock.Unlock()
+	pool.idle <- busy
+}
+
+func (s *MagicTestSuite) TestNewMagicPoolInvalidDatabase() {
+	t := s.T()
+	t.Parallel()
+	_, err := NewMagicPool(2, MagicNone, []string{"../testdata/nonexist.mgc"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,29 @@
+package libmagic
+
+// Rule is a top level rule of a magic database, as listed by MagicList.
+type Rule struct {
+	// Database is the database file holding the rule.
+	Database string
+	// Line is the line of the rule in the magic source the database was
+	// compiled from; libmagic does not keep the name of that source file.
+	Line int
+	// Description and MIME are the description and MIME type the rule
+	// prints; either may be empty.
+	Description string
+	MIME        string
+	// Strength is the strength of the rule, see WithStrength.
+	Strength int
+}
+
+// WithProvenance makes the MagicDetector set the Rule of every Result to the
+// top level rule that most likely matched, chosen as for WithStrength, along
+// with the database file holding it. This tells which of several databases
+// passed to WithDatabases produced a match. Results of libmagic's built-in
+// tests and of databases loaded from buffers have no Rule. The rules are
+// listed as for WithStrength, redirecting the process stdout once per
+// loading of the databases.
+func WithProvenance() DetectorOption {
+	return func(d *MagicDetector) {
+		d.provenance = true
+	}
+}
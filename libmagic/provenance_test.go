@@ -0,0 +1,47 @@
+package libmagic
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectorWithProvenance() {
+	t := s.T()
+	source := filepath.Join(t.TempDir(), "gomagic")
+	require.NoError(t, os.WriteFile(source, sourceRule, 0600))
+
+	for _, flags := range []Flags{MagicNone, MagicMimeType} {
+		detector, err := NewDetector(WithPoolSize(1), WithFlags(flags),
+			WithDatabases(source, "../testdata/magic.mgc"), WithProvenance())
+		require.NoError(t, err)
+		defer detector.Close()
+
+		result, err := detector.DetectBufferResult([]byte("GOMAGICTEST payload"))
+		require.NoError(t, err)
+		require.NotNil(t, result.Rule, flags.String())
+		assert.Equal(t, source, result.Rule.Database)
+		assert.Equal(t, 1, result.Rule.Line)
+		assert.Equal(t, "gomagic test data", result.Rule.Description)
+		assert.Equal(t, "application/x-gomagic-test", result.Rule.MIME)
+		assert.Zero(t, result.Strength)
+
+		result, err = detector.DetectBufferResult(pngHeader)
+		require.NoError(t, err)
+		require.NotNil(t, result.Rule, flags.String())
+		assert.Equal(t, "../testdata/magic.mgc", result.Rule.Database)
+		assert.Positive(t, result.Rule.Line)
+		assert.Equal(t, "image/png", result.Rule.MIME)
+	}
+
+	database, err := os.ReadFile("../testdata/magic.mgc")
+	require.NoError(t, err)
+	detector, err := NewDetector(WithPoolSize(1), WithDatabaseBuffers(database), WithProvenance())
+	require.NoError(t, err)
+	defer detector.Close()
+	result, err := detector.DetectBufferResult(pngHeader)
+	require.NoError(t, err)
+	assert.Nil(t, result.Rule)
+}
@@ -0,0 +1,127 @@
+package libmagic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// DefaultHeadSize is the prefix length read by DetectReaderHead when no
+// limit is given. It is enough for nearly all magic entries; libmagic itself
+// looks at no more than MagicParamBytesMax bytes of a buffer.
+const DefaultHeadSize = 1 << 20
+
+// headBuffers holds buffers of DefaultHeadSize bytes for reuse.
+var headBuffers = sync.Pool{
+	New: func() any {
+		buf := make([]byte, DefaultHeadSize)
+		return &buf
+	},
+}
+
+// DetectReaderHead detects the content of r from its first limit bytes, so
+// large streams do not have to be read entirely. A limit of zero or less
+// means DefaultHeadSize. r is read up to limit bytes and not closed.
+func (d *MagicDetector) DetectReaderHead(r io.Reader, limit int) (string, error) {
+	return d.DetectReaderHeadCtx(context.Background(), r, limit)
+}
+
+// DetectReaderHeadCtx is like DetectReaderHead but gives up when ctx is
+// done, see DetectFileCtx. Reading r is not interrupted.
+func (d *MagicDetector) DetectReaderHeadCtx(ctx context.Context, r io.Reader, limit int) (string, error) {
+	return d.detectHead(ctx, limit, func(buf []byte) (int, error) {
+		n, err := io.ReadFull(r, buf)
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			err = nil
+		}
+		return n, err
+	})
+}
+
+// DetectReaderAt detects the content of r from the limit bytes at its start.
+// A limit of zero or less means DefaultHeadSize. Only ReadAt is used, so r
+// can be shared with other readers and no stream position is changed.
+func (d *MagicDetector) DetectReaderAt(r io.ReaderAt, limit int) (string, error) {
+	return d.detectHead(context.Background(), limit, func(buf []byte) (int, error) {
+		return r.ReadAt(buf, 0)
+	})
+}
+
+// detectHead reads up to limit bytes into a head buffer with read and
+// detects them. io.EOF from read only marks the end of the content.
+func (d *MagicDetector) detectHead(ctx context.Context, limit int, read func(buf []byte) (int, error)) (string, error) {
+	if limit <= 0 {
+		limit = DefaultHeadSize
+	}
+	var buf []byte
+	if limit <= DefaultHeadSize {
+		p := headBuffers.Get().(*[]byte)
+		buf = *p
+		defer func() {
+			// An abandoned call may still read buf, so it is not reused.
+			if ctx.Err() == nil {
+				headBuffers.Put(p)
+			}
+		}()
+	} else {
+		buf = make([]byte, limit)
+	}
+	n, err := read(buf[:limit])
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return d.DetectBufferCtx(ctx, buf[:n])
+}
+
+// DetectReaderReplay is like DetectReaderHead but also returns a reader
+// yielding the bytes read for the detection followed by the rest of r, so the
+// caller can go on consuming the whole stream. The replay reader is returned
+// even when the detection fails, unless reading r does.
+func (d *MagicDetector) DetectReaderReplay(r io.Reader, limit int) (string, io.Reader, error) {
+	if limit <= 0 {
+		limit = DefaultHeadSize
+	}
+	// Grown as data arrives, so short streams do not cost a full limit.
+	var prefix bytes.Buffer
+	if _, err := io.CopyN(&prefix, r, int64(limit)); err != nil && !errors.Is(err, io.EOF) {
+		return "", nil, err
+	}
+	replay := io.MultiReader(bytes.NewReader(prefix.Bytes()), r)
+	raw, err := d.DetectBuffer(prefix.Bytes())
+	return raw, replay, err
+}
+
+// SniffReader detects the next n bytes of br without consuming them, so the
+// caller can still read the content from br afterwards. n is capped by the
+// buffer size of br, which it also defaults to when zero or less; use
+// bufio.NewReaderSize for a larger window.
+func (d *MagicDetector) SniffReader(br *bufio.Reader, n int) (string, error) {
+	if n <= 0 || n > br.Size() {
+		n = br.Size()
+	}
+	head, err := br.Peek(n)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	// head is only valid until br is read, which cannot happen meanwhile.
+	return d.DetectBuffer(head)
+}
+
+// DetectReadSeeker detects the content of rs from its current offset, up to
+// DefaultHeadSize bytes, and seeks back to that offset, so the caller can
+// go on reading rs as if it had not been touched. rs is seeked back even
+// when the detection fails.
+func (d *MagicDetector) DetectReadSeeker(rs io.ReadSeeker) (string, error) {
+	offset, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", err
+	}
+	raw, err := d.DetectReaderHead(rs, 0)
+	if _, seekErr := rs.Seek(offset, io.SeekStart); seekErr != nil && err == nil {
+		return "", seekErr
+	}
+	return raw, err
+}
@@ -0,0 +1,159 @@
+package libmagic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectReaderHead() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	result, err := detector.DetectReaderHead(bytes.NewReader(pngHeader), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+
+	// Only the limit is consumed from the stream.
+	stream := io.MultiReader(bytes.NewReader(pngHeader), bytes.NewReader(make([]byte, 4096)))
+	result, err = detector.DetectReaderHead(stream, len(pngHeader)+16)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+	rest, err := io.ReadAll(stream)
+	assert.NoError(t, err)
+	assert.Len(t, rest, 4096-16)
+
+	result, err = detector.DetectReaderHead(bytes.NewReader(pngHeader), 2*DefaultHeadSize)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+
+	readErr := errors.New("read failed")
+	_, err = detector.DetectReaderHead(iotest.ErrReader(readErr), 0)
+	assert.ErrorIs(t, err, readErr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = detector.DetectReaderHeadCtx(ctx, bytes.NewReader(pngHeader), 0)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func (s *MagicTestSuite) TestDetectReaderReplay() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	content := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{0xAB}, 4096)...)
+	for _, limit := range []int{0, 64, len(content)} {
+		result, replay, err := detector.DetectReaderReplay(bytes.NewReader(content), limit)
+		assert.NoError(t, err)
+		assert.Equal(t, "image/png", result)
+		all, err := io.ReadAll(replay)
+		assert.NoError(t, err)
+		assert.Equal(t, content, all)
+	}
+
+	readErr := errors.New("read failed")
+	_, replay, err := detector.DetectReaderReplay(iotest.ErrReader(readErr), 0)
+	assert.ErrorIs(t, err, readErr)
+	assert.Nil(t, replay)
+}
+
+func (s *MagicTestSuite) TestDetectReaderAt() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	r := bytes.NewReader(pngHeader)
+	_, err = r.Seek(4, io.SeekStart)
+	require.NoError(t, err)
+	result, err := detector.DetectReaderAt(r, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+	// The position of r is untouched.
+	assert.Equal(t, len(pngHeader)-4, r.Len())
+
+	result, err = detector.DetectReaderAt(io.NewSectionReader(r, 0, int64(len(pngHeader))), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+
+	f, err := os.Open("../testdata/magic.mgc")
+	require.NoError(t, err)
+	defer f.Close()
+	expected, err := detector.DetectFile("../testdata/magic.mgc")
+	require.NoError(t, err)
+	result, err = detector.DetectReaderAt(f, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func (s *MagicTestSuite) TestSniffReader() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	content := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{0xAB}, 8192)...)
+	br := bufio.NewReader(bytes.NewReader(content))
+	for _, n := range []int{0, 64, 1 << 20} {
+		result, err := detector.SniffReader(br, n)
+		assert.NoError(t, err)
+		assert.Equal(t, "image/png", result)
+	}
+	all, err := io.ReadAll(br)
+	assert.NoError(t, err)
+	assert.Equal(t, content, all)
+
+	br = bufio.NewReader(bytes.NewReader(pngHeader))
+	result, err := detector.SniffReader(br, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+
+	readErr := errors.New("read failed")
+	_, err = detector.SniffReader(bufio.NewReader(iotest.ErrReader(readErr)), 0)
+	assert.ErrorIs(t, err, readErr)
+}
+
+func (s *MagicTestSuite) TestDetectReadSeeker() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	content := append([]byte("header"), pngHeader...)
+	rs := bytes.NewReader(content)
+	_, err = rs.Seek(6, io.SeekStart)
+	require.NoError(t, err)
+	result, err := detector.DetectReadSeeker(rs)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+	rest, err := io.ReadAll(rs)
+	assert.NoError(t, err)
+	assert.Equal(t, pngHeader, rest)
+
+	readErr := errors.New("read failed")
+	rs = bytes.NewReader(content)
+	_, err = detector.DetectReadSeeker(struct {
+		io.Reader
+		io.Seeker
+	}{iotest.ErrReader(readErr), rs})
+	assert.ErrorIs(t, err, readErr)
+	offset, err := rs.Seek(0, io.SeekCurrent)
+	assert.NoError(t, err)
+	assert.Zero(t, offset)
+}
@@ -0,0 +1,263 @@
+package libmagic
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Result is the outcome of detecting a single input.
+type Result struct {
+	// Path is the detected file, empty for in-memory content.
+	Path string
+	// Raw is the output of libmagic as shaped by the handle flags.
+	Raw string
+	// MIME is the media type, e.g. "text/plain", set when the flags include
+	// MagicMimeType.
+	MIME string
+	// Encoding is the character encoding, e.g. "utf-8", set when the flags
+	// include MagicMimeEncoding.
+	Encoding string
+	// Description is the human-readable description, set when the flags
+	// request neither a MIME type, an encoding, an Apple code nor extensions.
+	Description string
+	// Extensions are the file name extensions of the content, set when the
+	// flags include MagicExtension.
+	Extensions []string
+	// Apple is the creator/type code, set when the flags include MagicApple
+	// and no MIME output; Err is set when libmagic printed an invalid one.
+	Apple AppleCode
+	// Database is the colon separated list of database files the detection
+	// ran against, empty for databases loaded from buffers. libmagic does not
+	// tell which of them matched, see WithProvenance.
+	Database string
+	// Container is the MIME type of the compression wrapping the content,
+	// e.g. "application/gzip", set when the flags include MagicCompress and
+	// MagicMime and libmagic looked inside compressed content; MIME and
+	// Encoding then describe the decompressed content.
+	Container string
+	// Strength is the strength of the libmagic rule that matched, set with
+	// WithStrength; weak matches have lower values, 0 means unknown.
+	Strength int
+	// Rule is the libmagic rule that matched, set with WithProvenance.
+	Rule *Rule
+	// Offset is where the signature that matched was found, set with
+	// WithDebugMatchOffset; -1 means there is none.
+	Offset *int64
+	// Examined is the number of bytes of content the detection was based
+	// on, Truncated whether they are only a prefix of the input and NeedsMore
+	// whether libmagic tests reached past their end, so that more of the
+	// input could change the Result; see WithTruncationReport.
+	Examined  int
+	Truncated bool
+	NeedsMore bool
+	// Language is the programming language of plain text, set with
+	// WithLanguageDetection.
+	Language *Language
+	// Kind tells whether the input was content, empty, a symbolic link or
+	// another special file.
+	Kind Kind
+	// Target is the target of a symbolic link, when Kind is KindSymlink.
+	Target string
+	// Err is the detection error, if any.
+	Err error
+}
+
+// newResult returns the Result of a detection with flags, splitting raw into
+// the fields it provides.
+func newResult(path, raw string, flags Flags, err error) Result {
+	r := Result{Path: path, Raw: raw, Err: err}
+	if err != nil {
+		return r
+	}
+	if flags&MagicCompress != 0 && flags&MagicMimeType != 0 {
+		var container string
+		raw, container, _ = strings.Cut(raw, compressedEncoding)
+		r.Container, _, _ = strings.Cut(container, ";")
+	}
+	switch {
+	case flags&MagicMime == MagicMime:
+		mime, params, _ := strings.Cut(raw, ";")
+		r.MIME = strings.TrimSpace(mime)
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(param, "=")
+			if strings.EqualFold(strings.TrimSpace(key), "charset") {
+				r.Encoding = strings.TrimSpace(value)
+			}
+		}
+	case flags&MagicMimeType != 0:
+		r.MIME = strings.TrimSpace(raw)
+	case flags&MagicMimeEncoding != 0:
+		r.Encoding = strings.TrimSpace(raw)
+	case flags&MagicApple != 0:
+		r.Apple, r.Err = ParseAppleCode(strings.TrimRight(raw, "\n"))
+	case flags&MagicExtension != 0:
+		r.Extensions = parseExtensions(raw)
+	default:
+		r.Description = raw
+	}
+	r.parseKind()
+	return r
+}
+
+// result returns the Result of a detection by d, see newResult.
+func (d *MagicDetector) result(path, raw string, err error) Result {
+	return d.finish(newResult(path, raw, d.flags, err))
+}
+
+// finish completes a Result of d with what does not come from libmagic.
+func (d *MagicDetector) finish(r Result) Result {
+	return d.finishContent(r, nil)
+}
+
+// finishContent is like finish for a Result of content, which is nil for a
+// file.
+func (d *MagicDetector) finishContent(r Result, content []byte) Result {
+	r.Database = d.database()
+	if d.matchOffset && r.Offset == nil {
+		// Not traced, such as the Result of a matcher.
+		offset := int64(-1)
+		r.Offset = &offset
+	}
+	// Before the refinements, so the rule is one libmagic matched.
+	if (d.strength || d.provenance) && r.Err == nil {
+		d.setRule(&r)
+	}
+	if d.refineZip && r.Err == nil {
+		refineZipResult(&r, content)
+	}
+	if d.detectLanguage && r.Err == nil {
+		refineLanguageResult(&r, content)
+	}
+	if d.normalizeMIME && r.MIME != "" {
+		mime := NormalizeMIME(r.MIME)
+		if mime != r.MIME && strings.HasPrefix(r.Raw, r.MIME) {
+			r.Raw = mime + r.Raw[len(r.MIME):]
+		}
+		r.MIME = mime
+	}
+	for _, hook := range d.hooks {
+		r = hook(r)
+	}
+	return r
+}
+
+// database returns the databases of the current handle set.
+func (d *MagicDetector) database() string {
+	if set := d.set.Load(); set != nil {
+		return set.database
+	}
+	return ""
+}
+
+// DetectFileResult is like DetectFile but returns a Result, so callers of a
+// MagicDetector created with e.g. WithFlags(MagicMime) get the MIME type and
+// the encoding without parsing "text/plain; charset=utf-8" themselves.
+func (d *MagicDetector) DetectFileResult(path string) (Result, error) {
+	if d.hasMatchers() {
+		return d.matchFile(path)
+	}
+	if d.matchOffset {
+		r, _, err := d.tracedResult(path, slog.String("path", path), func(m *Magic) (string, error) {
+			return m.magicFile(path)
+		})
+		return d.finish(r), err
+	}
+	raw, err := d.DetectFile(path)
+	return d.result(path, raw, err), err
+}
+
+// DetectBufferResult is like DetectFileResult but detects content.
+func (d *MagicDetector) DetectBufferResult(content []byte) (Result, error) {
+	if d.hasMatchers() {
+		return d.match("", content, func() (string, error) { return d.DetectBuffer(content) })
+	}
+	if d.matchOffset || d.truncation {
+		return d.contentResult(content, false)
+	}
+	raw, err := d.DetectBuffer(content)
+	return d.finishContent(newResult("", raw, d.flags, err), content), err
+}
+
+// DetectReaderResult is like DetectFileResult but detects the content of r,
+// see DetectReader.
+func (d *MagicDetector) DetectReaderResult(r io.Reader) (Result, error) {
+	if (d.matchOffset || d.truncation) && !d.hasMatchers() {
+		p := headBuffers.Get().(*[]byte)
+		defer headBuffers.Put(p)
+		n, err := io.ReadFull(r, *p)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return d.result("", "", err), err
+		}
+		return d.contentResult((*p)[:n], d.truncation && n == len(*p))
+	}
+	if d.hasMatchers() || d.refineZip {
+		return d.matchReader("", r, nil)
+	}
+	raw, err := d.DetectReader(r)
+	return d.result("", raw, err), err
+}
+
+// outputFlags select what libmagic prints rather than how it detects.
+const outputFlags = MagicMime | MagicExtension | MagicApple | MagicNoDesc
+
+// DetectAll detects the file at path once per kind of output and returns a
+// Result with the description, the MIME type, the encoding and the
+// extensions all set; Raw holds the description. The calls run on a single
+// handle with the flags of the MagicDetector, apart from those selecting the
+// output, which are switched in between and restored afterwards.
+func (d *MagicDetector) DetectAll(path string) (Result, error) {
+	var r Result
+	_, err := d.detect(context.Background(), slog.String("path", path), func(m *Magic) (string, error) {
+		var err error
+		r, err = m.detectAll(func() (string, error) { return m.magicFile(path) })
+		return r.Raw, err
+	})
+	r.Path = path
+	r.parseKind()
+	r.Err = err
+	return d.finish(r), err
+}
+
+// DetectAllBuffer is like DetectAll but detects content.
+func (d *MagicDetector) DetectAllBuffer(content []byte) (Result, error) {
+	var r Result
+	_, err := d.detect(context.Background(), slog.Int("len", len(content)), func(m *Magic) (string, error) {
+		var err error
+		r, err = m.detectAll(func() (string, error) { return m.magicBuffer(content) })
+		return r.Raw, err
+	})
+	r.parseKind()
+	r.Err = err
+	return d.finishContent(r, content), err
+}
+
+// detectAll runs detect with each kind of output flags and merges the
+// outputs. The caller must hold m.lock.
+func (m *Magic) detectAll(detect func() (string, error)) (Result, error) {
+	var r Result
+	base := m.flags &^ outputFlags
+	for _, flags := range []Flags{base, base | MagicMime, base | MagicExtension} {
+		restore, err := m.withFlags(flags)
+		if err != nil {
+			return Result{}, err
+		}
+		raw, err := detect()
+		restore()
+		if err != nil {
+			return Result{}, err
+		}
+		part := newResult("", raw, flags, nil)
+		switch {
+		case flags&MagicMime != 0:
+			r.MIME, r.Encoding, r.Container = part.MIME, part.Encoding, part.Container
+		case flags&MagicExtension != 0:
+			r.Extensions = part.Extensions
+		default:
+			r.Raw, r.Description = raw, part.Description
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,137 @@
+package libmagic
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestNewResult() {
+	t := s.T()
+	tests := []struct {
+		name  string
+		raw   string
+		flags Flags
+		err   error
+		want  Result
+	}{
+		{
+			name:  "mime",
+			raw:   "text/plain; charset=utf-8",
+			flags: MagicMime,
+			want:  Result{Raw: "text/plain; charset=utf-8", MIME: "text/plain", Encoding: "utf-8"},
+		},
+		{
+			name:  "mime type",
+			raw:   "image/png",
+			flags: MagicMimeType | MagicError,
+			want:  Result{Raw: "image/png", MIME: "image/png"},
+		},
+		{
+			name:  "mime encoding",
+			raw:   "binary",
+			flags: MagicMimeEncoding,
+			want:  Result{Raw: "binary", Encoding: "binary"},
+		},
+		{
+			name:  "extensions",
+			raw:   "jpeg/jpg",
+			flags: MagicExtension,
+			want:  Result{Raw: "jpeg/jpg", Extensions: []string{"jpeg", "jpg"}},
+		},
+		{
+			name:  "apple",
+			raw:   "8BIMGIFf",
+			flags: MagicApple,
+			want:  Result{Raw: "8BIMGIFf", Apple: AppleCode{Creator: "8BIM", Type: "GIFf"}},
+		},
+		{
+			name:  "description",
+			raw:   "ASCII text",
+			flags: MagicNone,
+			want:  Result{Raw: "ASCII text", Description: "ASCII text"},
+		},
+		{
+			name:  "error",
+			flags: MagicMime,
+			err:   errors.New("failed"),
+			want:  Result{Err: errors.New("failed")},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			assert.Equal(t, tt.want, newResult("", tt.raw, tt.flags, tt.err))
+		})
+	}
+}
+
+func (s *MagicTestSuite) TestDetectorDetectResult() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMime),
+		WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	result, err := detector.DetectBufferResult([]byte("hello world\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", result.MIME)
+	assert.Equal(t, "us-ascii", result.Encoding)
+	assert.Empty(t, result.Path)
+
+	result, err = detector.DetectFileResult("../testdata/magic.mgc")
+	require.NoError(t, err)
+	assert.Equal(t, "../testdata/magic.mgc", result.Path)
+	assert.NotEmpty(t, result.MIME)
+	assert.NotEmpty(t, result.Encoding)
+
+	detector.Close()
+	_, err = detector.DetectFileResult("../testdata/magic.mgc")
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func (s *MagicTestSuite) TestDetectorDetectAll() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType|MagicError),
+		WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	path := filepath.Join(t.TempDir(), "image")
+	require.NoError(t, os.WriteFile(path, pngHeader, 0o600))
+	result, err := detector.DetectAll(path)
+	require.NoError(t, err)
+	assert.Equal(t, path, result.Path)
+	assert.Equal(t, "image/png", result.MIME)
+	assert.Equal(t, "binary", result.Encoding)
+	assert.Equal(t, []string{"png"}, result.Extensions)
+	assert.Contains(t, result.Description, "PNG image data")
+	assert.Equal(t, result.Description, result.Raw)
+
+	raw, err := detector.DetectFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", raw)
+
+	result, err = detector.DetectAll(filepath.Join(t.TempDir(), "nonexist"))
+	assert.Error(t, err)
+	assert.Equal(t, err, result.Err)
+}
+
+func (s *MagicTestSuite) TestDetectorDetectAllBuffer() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType|MagicError),
+		WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	result, err := detector.DetectAllBuffer(pngHeader)
+	require.NoError(t, err)
+	assert.Empty(t, result.Path)
+	assert.Equal(t, "image/png", result.MIME)
+	assert.Equal(t, "binary", result.Encoding)
+	assert.Equal(t, []string{"png"}, result.Extensions)
+	assert.Contains(t, result.Description, "PNG image data")
+}
@@ -0,0 +1,75 @@
+package libmagic
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+// minScratchSize is the smallest scratch buffer cString allocates.
+const minScratchSize = 256
+
+// SetScratchSize tunes the C buffer the handle reuses for the file names it
+// passes to libmagic. size bytes are allocated up front, so workloads with
+// long paths do not grow the buffer call after call. A buffer grown past max
+// bytes for an unusually long name is shrunk again on the next call with a
+// shorter one; a max of zero or less keeps the buffer however large it grew.
+// Clones of the handle inherit the settings.
+func (m *Magic) SetScratchSize(size, max int) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.handle == nil {
+		return ErrClosed
+	}
+	if max > 0 && max < size {
+		max = size
+	}
+	m.scratchReserve, m.scratchMax = size, max
+	if size > m.scratchSize || (max > 0 && m.scratchSize > max) {
+		m.resizeScratch(size)
+	}
+	return nil
+}
+
+// cString copies s into the handle's scratch buffer, which is grown as needed
+// and reused across calls instead of allocating a C string per call. The
+// result is only valid until the next cString call. The caller must hold
+// m.lock.
+func (m *Magic) cString(s string) *C.char {
+	need := len(s) + 1
+	oversized := m.scratchMax > 0 && m.scratchSize > m.scratchMax && need <= m.scratchMax
+	if need > m.scratchSize || oversized {
+		size := max(m.scratchReserve, minScratchSize)
+		for size < need {
+			size *= 2
+		}
+		if m.scratchMax > 0 && size > m.scratchMax && need <= m.scratchMax {
+			size = m.scratchMax
+		}
+		m.resizeScratch(size)
+	}
+	buf := unsafe.Slice((*byte)(m.scratch), need)
+	copy(buf, s)
+	buf[len(s)] = 0
+	return (*C.char)(m.scratch)
+}
+
+func (m *Magic) resizeScratch(size int) {
+	C.free(m.scratch)
+	m.scratch, m.scratchSize = nil, 0
+	if size > 0 {
+		m.scratch = C.malloc(C.size_t(size))
+		m.scratchSize = size
+	}
+}
+
+// WithScratchSize calls SetScratchSize(size, max) on every handle of the
+// MagicDetector, see Magic.SetScratchSize.
+func WithScratchSize(size, max int) DetectorOption {
+	return func(d *MagicDetector) {
+		d.scratchSize, d.scratchMax = size, max
+	}
+}
@@ -0,0 +1,52 @@
+package libmagic
+
+import (
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestMagicSetScratchSize() {
+	t := s.T()
+	t.Parallel()
+	magic, err := NewMagic(MagicMimeType)
+	require.NoError(t, err)
+	defer magic.Close()
+	require.NoError(t, magic.MagicLoad([]string{"../testdata/magic.mgc"}))
+
+	require.NoError(t, magic.SetScratchSize(4096, 8192))
+	assert.Equal(t, 4096, magic.scratchSize)
+
+	_, err = magic.MagicFile("../testdata/magic.mgc")
+	assert.NoError(t, err)
+	assert.Equal(t, 4096, magic.scratchSize)
+
+	// A long name grows the buffer past max until the next shorter one.
+	magic.cString(strings.Repeat("x", 10000))
+	assert.Greater(t, magic.scratchSize, 8192)
+	magic.cString(strings.Repeat("x", 5000))
+	assert.Equal(t, 8192, magic.scratchSize)
+	magic.cString("short")
+	assert.Equal(t, 8192, magic.scratchSize)
+	magic.cString(strings.Repeat("x", 10000))
+	magic.cString("short")
+	assert.Equal(t, 4096, magic.scratchSize)
+
+	clone, err := magic.Clone()
+	require.NoError(t, err)
+	defer clone.Close()
+	assert.Equal(t, 4096, clone.scratchSize)
+	assert.Equal(t, 8192, clone.scratchMax)
+
+	detector, err := NewDetector(WithPoolSize(2), WithFlags(MagicMimeType), WithDatabases("../testdata/magic.mgc"), WithScratchSize(1024, 0))
+	require.NoError(t, err)
+	defer detector.Close()
+	set := detector.set.Load()
+	for i := range set.slots {
+		assert.Equal(t, 1024, set.slots[i].Load().scratchSize)
+	}
+
+	magic.Close()
+	assert.ErrorIs(t, magic.SetScratchSize(1024, 0), ErrClosed)
+}
@@ -0,0 +1,49 @@
+package libmagic
+
+import (
+	"context"
+	"sync"
+)
+
+// DetectStream detects the paths received from paths and sends one Result
+// per path on the returned channel, in completion order. A fixed number of
+// workers (see WithConcurrency) pull paths only as fast as results are
+// consumed, and the returned channel buffers at most one result per worker,
+// so memory stays bounded however many paths are streamed.
+//
+// The returned channel is closed once paths is closed and drained, or once
+// ctx is done; in the latter case remaining paths are left unread.
+func (d *MagicDetector) DetectStream(ctx context.Context, paths <-chan string, opts ...BatchOption) <-chan Result {
+	o := d.batchOptions(opts)
+	results := make(chan Result, o.concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < o.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				var path string
+				select {
+				case p, ok := <-paths:
+					if !ok {
+						return
+					}
+					path = p
+				case <-ctx.Done():
+					return
+				}
+				raw, err := d.detectFile(ctx, path, o)
+				select {
+				case results <- d.result(path, raw, err):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
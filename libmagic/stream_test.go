@@ -0,0 +1,65 @@
+package libmagic
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectStream() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(2), WithFlags(MagicMimeType|MagicError), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	dir := t.TempDir()
+	const count = 100
+	for i := 0; i < count; i++ {
+		if i%10 != 0 {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.png", i)), pngHeader, 0o644))
+		}
+	}
+	paths := make(chan string)
+	go func() {
+		defer close(paths)
+		for i := 0; i < count; i++ {
+			paths <- filepath.Join(dir, fmt.Sprintf("%d.png", i))
+		}
+	}()
+
+	var ok, failed int
+	for r := range detector.DetectStream(context.Background(), paths, WithConcurrency(3)) {
+		if r.Err != nil {
+			failed++
+			continue
+		}
+		assert.Equal(t, "image/png", r.Raw, r.Path)
+		ok++
+	}
+	assert.Equal(t, count-count/10, ok)
+	assert.Equal(t, count/10, failed)
+}
+
+func (s *MagicTestSuite) TestDetectStreamCancel() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	// Never closed: the stream ends only because ctx is canceled.
+	paths := make(chan string)
+	results := detector.DetectStream(ctx, paths)
+	paths <- "../testdata/magic.mgc"
+	r := <-results
+	assert.NoError(t, r.Err)
+	cancel()
+	for range results {
+	}
+}
@@ -0,0 +1,177 @@
+package libmagic
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// strengthLine matches the lines of a MagicList listing, such as
+// "Strength = 340@21: PGP private key block [application/pgp-keys]".
+var strengthLine = regexp.MustCompile(`^Strength = *(\d+)@(\d+): (.*) \[(.*)\]$`)
+
+// ruleIndex holds the top level rules of a set of databases, to find the one
+// that produced a Result.
+type ruleIndex struct {
+	rules []Rule
+	// byMIME maps MIME types to the strongest of the rules with it.
+	byMIME map[string]int
+	// byWord maps the first words of descriptions to the rules with them,
+	// in listing order.
+	byWord map[string][]int
+}
+
+// parseRules adds the rules of the listing of database to index.
+func (index *ruleIndex) parseRules(listing, database string) {
+	if index.byMIME == nil {
+		index.byMIME = map[string]int{}
+		index.byWord = map[string][]int{}
+	}
+	for _, line := range strings.Split(listing, "\n") {
+		match := strengthLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		strength, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+		number, err := strconv.Atoi(match[2])
+		if err != nil {
+			continue
+		}
+		rule := Rule{
+			Database:    database,
+			Line:        number,
+			Description: strings.TrimSpace(match[3]),
+			MIME:        match[4],
+			Strength:    strength,
+		}
+		if rule.Description == "" && rule.MIME == "" {
+			continue
+		}
+		index.rules = append(index.rules, rule)
+		if rule.Description != "" {
+			word := firstWord(rule.Description)
+			index.byWord[word] = append(index.byWord[word], len(index.rules)-1)
+		}
+		if best, ok := index.byMIME[rule.MIME]; rule.MIME != "" && (!ok || strength > index.rules[best].Strength) {
+			index.byMIME[rule.MIME] = len(index.rules) - 1
+		}
+	}
+}
+
+// lookup returns the rule that most likely produced r: the one with the
+// longest description r.Description starts with, the strongest among equals,
+// or, without a description, the strongest one with the MIME type of r.
+func (index *ruleIndex) lookup(r Result) (Rule, bool) {
+	if r.Description == "" {
+		i, ok := index.byMIME[r.MIME]
+		if !ok {
+			return Rule{}, false
+		}
+		return index.rules[i], true
+	}
+	// A rule with a description r.Description starts with has a first word
+	// r's first word starts with, and equal unless it is the whole
+	// description.
+	word := firstWord(r.Description)
+	best := -1
+	for n := 1; n <= len(word); n++ {
+		for _, i := range index.byWord[word[:n]] {
+			rule := index.rules[i]
+			if !strings.HasPrefix(r.Description, rule.Description) {
+				continue
+			}
+			if best < 0 || len(rule.Description) > len(index.rules[best].Description) ||
+				len(rule.Description) == len(index.rules[best].Description) && rule.Strength > index.rules[best].Strength {
+				best = i
+			}
+		}
+	}
+	if best < 0 {
+		return Rule{}, false
+	}
+	return index.rules[best], true
+}
+
+func firstWord(s string) string {
+	word, _, _ := strings.Cut(s, " ")
+	return word
+}
+
+// strength returns the strength of the rule that most likely produced r, see
+// lookup, or 0 if there is none.
+func (index *ruleIndex) strength(r Result) int {
+	rule, _ := index.lookup(r)
+	return rule.Strength
+}
+
+// WithStrength makes the MagicDetector set the Strength of every Result: the
+// strength libmagic gives the top level rule that most likely matched, from
+// the listing of the databases; see MagicList. Without a description, rules
+// are told apart by MIME type only. Content recognized by libmagic's built-in
+// tests, such as text, when described, and databases loaded from buffers get
+// 0.
+//
+// The listing is read by the first detection after the databases are
+// loaded, by NewDetector or Reload. libmagic prints it to the standard
+// output, so the process stdout is redirected meanwhile, and whatever else
+// is written to it is lost; see loadRules.
+func WithStrength() DetectorOption {
+	return func(d *MagicDetector) {
+		d.strength = true
+	}
+}
+
+// loadRules builds the rule index of the databases of s, leaving it nil if
+// they cannot be listed. Each database file is listed on its own, so that
+// rules know where they come from.
+//
+// libmagic prints listings to the standard output, which is redirected into
+// a pipe meanwhile, see captureFd: whatever else the process writes there at
+// the same time goes into the listing and is lost. This is why the index is
+// only built for WithStrength and WithProvenance, once per handle set.
+func (s *handleSet) loadRules() {
+	if s.database == "" {
+		return
+	}
+	files := s.files
+	if len(files) == 0 {
+		files = strings.Split(s.database, ":")
+	}
+	index := &ruleIndex{}
+	for _, file := range files {
+		listing, err := s.template.MagicListString([]string{file})
+		if err != nil {
+			continue
+		}
+		index.parseRules(listing, file)
+	}
+	if len(index.rules) > 0 {
+		s.ruleIndex = index
+	}
+}
+
+// setRule sets r.Strength and r.Rule, as enabled, from the current handle
+// set of d.
+func (d *MagicDetector) setRule(r *Result) {
+	set := d.set.Load()
+	if set == nil {
+		return
+	}
+	set.rulesOnce.Do(set.loadRules)
+	if set.ruleIndex == nil {
+		return
+	}
+	rule, ok := set.ruleIndex.lookup(*r)
+	if !ok {
+		return
+	}
+	if d.strength {
+		r.Strength = rule.Strength
+	}
+	if d.provenance {
+		r.Rule = &rule
+	}
+}
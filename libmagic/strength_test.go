@@ -0,0 +1,70 @@
+package libmagic
+
+import (
+	"os"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestParseStrengths() {
+	t := s.T()
+	index := &ruleIndex{}
+	index.parseRules(`Set 0:
+Binary patterns:
+Strength = 340@21: PGP private key block [application/pgp-keys]
+Strength =  90@12: PGP [application/pgp-keys]
+Strength =  50@3:  []
+Strength = 120@7: PGP key [application/pgp]
+Strength =  70@30: gzip [application/gzip]
+Text patterns:
+`, "magic")
+	assert.Equal(t, 340, index.strength(Result{Description: "PGP private key block, encrypted"}))
+	assert.Equal(t, 120, index.strength(Result{Description: "PGP key public"}))
+	assert.Equal(t, 90, index.strength(Result{Description: "PGP"}))
+	assert.Equal(t, 0, index.strength(Result{Description: "ASCII text"}))
+	assert.Equal(t, 70, index.strength(Result{Description: "gzipped data"}))
+	assert.Equal(t, 340, index.strength(Result{MIME: "application/pgp-keys"}))
+	assert.Equal(t, 0, index.strength(Result{MIME: "text/plain"}))
+}
+
+func (s *MagicTestSuite) TestDetectorWithStrength() {
+	t := s.T()
+	for _, flags := range []Flags{MagicNone, MagicMimeType} {
+		detector, err := NewDetector(WithPoolSize(1), WithFlags(flags),
+			WithDatabases("../testdata/magic.mgc"), WithStrength())
+		require.NoError(t, err)
+		defer detector.Close()
+		// The rules are listed by the first detection, not when loading.
+		assert.Nil(t, detector.set.Load().ruleIndex)
+
+		result, err := detector.DetectBufferResult(pngHeader)
+		require.NoError(t, err)
+		assert.Positive(t, result.Strength, flags.String())
+		assert.NotNil(t, detector.set.Load().ruleIndex)
+	}
+
+	detector, err := NewDetector(WithPoolSize(1), WithDatabases("../testdata/magic.mgc"),
+		WithStrength())
+	require.NoError(t, err)
+	defer detector.Close()
+	result, err := detector.DetectBufferResult([]byte("hello world\n"))
+	require.NoError(t, err)
+	assert.Zero(t, result.Strength)
+
+	database, err := os.ReadFile("../testdata/magic.mgc")
+	require.NoError(t, err)
+	detector, err = NewDetector(WithPoolSize(1), WithDatabaseBuffers(database), WithStrength())
+	require.NoError(t, err)
+	defer detector.Close()
+	result, err = detector.DetectBufferResult(pngHeader)
+	require.NoError(t, err)
+	assert.Zero(t, result.Strength)
+
+	detector, err = NewDetector(WithPoolSize(1), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+	result, err = detector.DetectBufferResult(pngHeader)
+	require.NoError(t, err)
+	assert.Zero(t, result.Strength)
+}
@@ -0,0 +1,69 @@
+package libmagic
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// mimeExtensions maps MIME types to their usual file name extension, for
+// types whose libmagic rules carry no extension list.
+var mimeExtensions = map[string]string{
+	"application/gzip":                  "gz",
+	"application/json":                  "json",
+	"application/msword":                "doc",
+	"application/pdf":                   "pdf",
+	"application/vnd.ms-cab-compressed": "cab",
+	"application/vnd.ms-excel":          "xls",
+	"application/x-bzip2":               "bz2",
+	"application/x-executable":          "bin",
+	"application/x-iso9660-image":       "iso",
+	"application/x-sharedlib":           "so",
+	"application/x-tar":                 "tar",
+	"application/x-xz":                  "xz",
+	"application/xml":                   "xml",
+	"application/zip":                   "zip",
+	"application/zstd":                  "zst",
+	"audio/mpeg":                        "mp3",
+	"audio/x-wav":                       "wav",
+	"image/gif":                         "gif",
+	"image/jpeg":                        "jpg",
+	"image/png":                         "png",
+	"image/svg+xml":                     "svg",
+	"image/webp":                        "webp",
+	"text/csv":                          "csv",
+	"text/html":                         "html",
+	"text/plain":                        "txt",
+	"text/x-c":                          "c",
+	"text/x-python":                     "py",
+	"text/x-shellscript":                "sh",
+	"video/mp4":                         "mp4",
+	"video/webm":                        "webm",
+}
+
+// SuggestExtension returns the preferred file name extension for the content
+// of r, without the leading dot: the first extension libmagic knows for it,
+// or else the usual extension of its MIME type. It returns "" when neither
+// is known, so callers storing uploads can fall back to a neutral name.
+func SuggestExtension(r Result) string {
+	if len(r.Extensions) > 0 {
+		return r.Extensions[0]
+	}
+	mimeType, _, _ := strings.Cut(r.MIME, ";")
+	return mimeExtensions[strings.ToLower(strings.TrimSpace(mimeType))]
+}
+
+// SuggestBufferExtension detects content and returns its preferred file name
+// extension, see SuggestExtension.
+func (d *MagicDetector) SuggestBufferExtension(content []byte) (string, error) {
+	var r Result
+	_, err := d.detect(context.Background(), slog.Int("len", len(content)), func(m *Magic) (string, error) {
+		var err error
+		r, err = m.detectAll(func() (string, error) { return m.magicBuffer(content) })
+		return r.Raw, err
+	})
+	if err != nil {
+		return "", err
+	}
+	return SuggestExtension(d.finishContent(r, content)), nil
+}
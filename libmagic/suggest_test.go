@@ -0,0 +1,57 @@
+package libmagic
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestSuggestExtension() {
+	t := s.T()
+	tests := []struct {
+		name   string
+		result Result
+		want   string
+	}{
+		{
+			name:   "libmagic extension",
+			result: Result{MIME: "image/jpeg", Extensions: []string{"jpeg", "jpg"}},
+			want:   "jpeg",
+		},
+		{
+			name:   "mime fallback",
+			result: Result{MIME: "Text/Plain"},
+			want:   "txt",
+		},
+		{
+			name:   "mime with parameters",
+			result: Result{MIME: "application/json; charset=utf-8"},
+			want:   "json",
+		},
+		{
+			name:   "unknown",
+			result: Result{MIME: "application/x-unknown"},
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			assert.Equal(t, tt.want, SuggestExtension(tt.result))
+		})
+	}
+}
+
+func (s *MagicTestSuite) TestDetectorSuggestBufferExtension() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	extension, err := detector.SuggestBufferExtension(pngHeader)
+	require.NoError(t, err)
+	assert.Equal(t, "png", extension)
+
+	extension, err = detector.SuggestBufferExtension([]byte("hello world\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "txt", extension)
+}
@@ -0,0 +1,32 @@
+package libmagic
+
+import (
+	"os"
+	"strings"
+)
+
+// symlinkMIME is the MIME type libmagic prints for symbolic links.
+const symlinkMIME = "inode/symlink"
+
+// symlinkPrefixes start the descriptions libmagic prints for symbolic links,
+// followed by the link target.
+var symlinkPrefixes = []string{"symbolic link to ", "broken symbolic link to "}
+
+// parseSymlink sets Kind and Target when r describes a symbolic link. The
+// target is taken from the description, or read from Path if only the MIME
+// type is known.
+func (r *Result) parseSymlink() {
+	for _, prefix := range symlinkPrefixes {
+		if target, ok := strings.CutPrefix(r.Description, prefix); ok {
+			r.Kind = KindSymlink
+			r.Target = target
+			return
+		}
+	}
+	if r.MIME == symlinkMIME {
+		r.Kind = KindSymlink
+		if r.Path != "" {
+			r.Target, _ = os.Readlink(r.Path)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package libmagic
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectorSymlinkResult() {
+	t := s.T()
+	dir := t.TempDir()
+	image := filepath.Join(dir, "image")
+	require.NoError(t, os.WriteFile(image, pngHeader, 0o600))
+	link := filepath.Join(dir, "link")
+	require.NoError(t, os.Symlink(image, link))
+	broken := filepath.Join(dir, "broken")
+	require.NoError(t, os.Symlink("missing", broken))
+
+	tests := []struct {
+		name   string
+		flags  Flags
+		path   string
+		kind   Kind
+		target string
+	}{
+		{name: "description", flags: MagicNone, path: link, kind: KindSymlink, target: image},
+		{name: "broken", flags: MagicNone, path: broken, kind: KindSymlink, target: "missing"},
+		{name: "mime type", flags: MagicMimeType, path: link, kind: KindSymlink, target: image},
+		{name: "followed", flags: MagicSymlink, path: link, kind: KindContent},
+		{name: "regular file", flags: MagicNone, path: image, kind: KindContent},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			detector, err := NewDetector(WithPoolSize(1), WithFlags(tt.flags),
+				WithDatabases("../testdata/magic.mgc"))
+			require.NoError(t, err)
+			defer detector.Close()
+
+			result, err := detector.DetectFileResult(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.kind, result.Kind)
+			assert.Equal(t, tt.target, result.Target)
+
+			result, err = detector.DetectAll(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.kind, result.Kind)
+			assert.Equal(t, tt.target, result.Target)
+		})
+	}
+
+	data, err := json.Marshal(Result{MIME: symlinkMIME, Kind: KindSymlink, Target: "image"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"mime":"inode/symlink","encoding":"","description":"","extensions":[],"matched_db":"","kind":"symlink","target":"image"}`, string(data))
+}
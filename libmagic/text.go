@@ -0,0 +1,118 @@
+package libmagic
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// maxTextLine is the longest line libmagic reports text with without saying
+// it has very long lines.
+const maxTextLine = 300
+
+// textFlags are the flags under which libmagic's text output is not
+// reproduced by WithTextFastPath.
+const textFlags = MagicContinue | MagicExtension | MagicApple | MagicNoCheckText | MagicNoCheckEncoding
+
+// IsProbablyText reports whether content looks like text: it is valid UTF-8,
+// apart from a rune cut off at its end, and holds no control characters other
+// than those found in text, such as tabs, line terminators and escape
+// sequences. It is a cheap test in Go that libmagic would mostly agree with;
+// empty content is not text.
+func IsProbablyText(content []byte) bool {
+	if len(content) == 0 {
+		return false
+	}
+	for i := 0; i < len(content); {
+		c := content[i]
+		if c < utf8.RuneSelf {
+			if !isTextByte(c) {
+				return false
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(content[i:])
+		if r == utf8.RuneError && size == 1 {
+			return !utf8.FullRune(content[i:])
+		}
+		i += size
+	}
+	return true
+}
+
+// isTextByte reports whether the ASCII byte c is found in text.
+func isTextByte(c byte) bool {
+	switch c {
+	case '\a', '\b', '\t', '\n', '\v', '\f', '\r', 0x1b:
+		return true
+	}
+	return c >= 0x20 && c != 0x7f
+}
+
+// WithTextFastPath makes DetectFileResult, DetectBufferResult and
+// DetectReaderResult report plain text without calling libmagic, for
+// workloads dominated by logs and source files. It applies to content
+// IsProbablyText accepts with lines of at most 300 bytes ending in "\n"
+// only, and no control characters other than tabs, which libmagic describes
+// as "ASCII text" or "Unicode text, UTF-8 text"; other content is left to
+// libmagic, as is output with extensions or Apple codes.
+//
+// The fast path skips the magic tests, so scripts, source code, JSON and
+// other text formats are all reported as plain text. It is tried before
+// the matchers of WithMatchersBefore.
+func WithTextFastPath() DetectorOption {
+	return func(d *MagicDetector) {
+		d.matchersBefore = append([]Matcher{d.matchText}, d.matchersBefore...)
+	}
+}
+
+// matchText is the Matcher of WithTextFastPath.
+func (d *MagicDetector) matchText(content []byte) (Result, bool) {
+	if d.flags&textFlags != 0 || !isPlainText(content) {
+		return Result{}, false
+	}
+	description, encoding := "ASCII text", "us-ascii"
+	if !isASCII(content) {
+		description, encoding = "Unicode text, UTF-8 text", "utf-8"
+	}
+	var raw string
+	switch {
+	case d.flags&MagicMime == MagicMime:
+		raw = "text/plain; charset=" + encoding
+	case d.flags&MagicMimeType != 0:
+		raw = "text/plain"
+	case d.flags&MagicMimeEncoding != 0:
+		raw = encoding
+	default:
+		raw = description
+	}
+	return newResult("", raw, d.flags, nil), true
+}
+
+// isPlainText reports whether libmagic describes content as text with no
+// further remarks, see WithTextFastPath.
+func isPlainText(content []byte) bool {
+	if len(content) < 2 || content[len(content)-1] != '\n' || bytes.HasPrefix(content, []byte("\xef\xbb\xbf")) {
+		return false
+	}
+	for _, c := range content {
+		if c < 0x20 && c != '\t' && c != '\n' {
+			return false
+		}
+	}
+	for line := range bytes.Lines(content) {
+		if len(line) > maxTextLine+1 {
+			return false
+		}
+	}
+	return IsProbablyText(content) && utf8.Valid(content)
+}
+
+func isASCII(content []byte) bool {
+	for _, c := range content {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
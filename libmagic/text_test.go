@@ -0,0 +1,72 @@
+package libmagic
+
+import (
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestIsProbablyText() {
+	t := s.T()
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"hello world\n", true},
+		{"héllo wörld\n", true},
+		{"a\r\nb\r\n", true},
+		{"x\x1b[0m\n", true},
+		{"cut \xc3", true},
+		{"", false},
+		{"a\x00b\n", false},
+		{"a\x7fb\n", false},
+		{"\xff\xfe", false},
+		{"bad \xc3 utf-8\n", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, IsProbablyText([]byte(tt.content)), "%q", tt.content)
+	}
+}
+
+func (s *MagicTestSuite) TestDetectorWithTextFastPath() {
+	t := s.T()
+	contents := []string{
+		"hello world\n",
+		"héllo wörld\n",
+		"a\tb\n2024-01-01 INFO started\n",
+		strings.Repeat("a", maxTextLine) + "\n",
+		strings.Repeat("a", maxTextLine+1) + "\n",
+		"a\r\nb\r\n",
+		"no newline",
+		"x\x1b[0m\n",
+		"\xef\xbb\xbfhi\n",
+	}
+	for _, flags := range []Flags{MagicNone, MagicMime, MagicMimeType, MagicMimeEncoding} {
+		plain, err := NewDetector(WithPoolSize(1), WithFlags(flags), WithDatabases("../testdata/magic.mgc"))
+		require.NoError(t, err)
+		defer plain.Close()
+		fast, err := NewDetector(WithPoolSize(1), WithFlags(flags), WithDatabases("../testdata/magic.mgc"),
+			WithTextFastPath())
+		require.NoError(t, err)
+		defer fast.Close()
+
+		for _, content := range contents {
+			want, err := plain.DetectBufferResult([]byte(content))
+			require.NoError(t, err)
+			got, err := fast.DetectBufferResult([]byte(content))
+			require.NoError(t, err)
+			assert.Equal(t, want, got, "%s %q", flags, content)
+		}
+	}
+
+	fast, err := NewDetector(WithPoolSize(1), WithDatabases("../testdata/magic.mgc"), WithTextFastPath())
+	require.NoError(t, err)
+	defer fast.Close()
+	result, err := fast.DetectBufferResult([]byte("#!/bin/sh\necho hi\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "ASCII text", result.Description)
+	result, err = fast.DetectBufferResult(pngHeader)
+	require.NoError(t, err)
+	assert.Contains(t, result.Description, "PNG")
+}
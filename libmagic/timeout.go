@@ -0,0 +1,35 @@
+package libmagic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTimeout is returned when a detection does not finish in time. It
+// matches context.DeadlineExceeded with errors.Is.
+var ErrTimeout = fmt.Errorf("detection timed out: %w", context.DeadlineExceeded)
+
+// DetectFileTimeout is like DetectFile but gives up after timeout, see
+// DetectFileCtx.
+func (d *MagicDetector) DetectFileTimeout(path string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return timeoutResult(d.DetectFileCtx(ctx, path))
+}
+
+// DetectBufferTimeout is like DetectFileTimeout but detects content. After a
+// timeout libmagic may still read content, so it must not be modified.
+func (d *MagicDetector) DetectBufferTimeout(content []byte, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return timeoutResult(d.DetectBufferCtx(ctx, content))
+}
+
+func timeoutResult(raw string, err error) (string, error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "", ErrTimeout
+	}
+	return raw, err
+}
@@ -0,0 +1,35 @@
+package libmagic
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectTimeout() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType|MagicError), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	result, err := detector.DetectBufferTimeout(pngHeader, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+
+	slow := bytes.Repeat([]byte("lorem ipsum dolor sit amet\n"), 1<<16)
+	_, err = detector.DetectBufferTimeout(slow, time.Nanosecond)
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The stuck handle was replaced, so the MagicDetector keeps working.
+	result, err = detector.DetectBufferTimeout(pngHeader, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result)
+	_, err = detector.DetectFileTimeout("../testdata/nonexist.mgc", time.Minute)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrTimeout)
+}
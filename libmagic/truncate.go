@@ -0,0 +1,60 @@
+package libmagic
+
+import (
+	"log/slog"
+)
+
+// WithTruncationReport makes DetectBufferResult and DetectReaderResult set
+// Examined to the number of bytes the Result is based on. Readers are
+// detected from their first DefaultHeadSize bytes; when they fill the head
+// the Result is Truncated, as the input may go on, and NeedsMore tells
+// whether libmagic tests reached past the head: a stronger rule may have
+// matched further in, or the matching one may have reported more details.
+// See DetectPrefixResult for content known to be a prefix.
+//
+// NeedsMore is traced from libmagic's debug output like the offset of
+// WithDebugMatchOffset, with the same side effects: while a truncated input
+// is detected, the process stderr is redirected into the tracer, so anything
+// else written to it is lost, and such detections of all MagicDetectors run
+// one at a time. The cost grows with large text heads as libmagic logs its
+// searches in full. Inputs that are not truncated are not traced, and
+// Results provided by matchers are not reported.
+func WithTruncationReport() DetectorOption {
+	return func(d *MagicDetector) {
+		d.truncation = true
+	}
+}
+
+// DetectPrefixResult is like DetectBufferResult for prefix, the start of an
+// input not available in full, such as the first packets of a stream. The
+// Result is Truncated; whether libmagic needed more data is only reported
+// with WithTruncationReport, see there for its cost. Matchers are not tried.
+func (d *MagicDetector) DetectPrefixResult(prefix []byte) (Result, error) {
+	return d.contentResult(prefix, true)
+}
+
+// contentResult returns the Result of content, a prefix of the input if
+// truncated, with Offset and the truncation report set as enabled.
+func (d *MagicDetector) contentResult(content []byte, truncated bool) (Result, error) {
+	var (
+		r   Result
+		err error
+	)
+	if d.matchOffset || truncated && d.truncation {
+		var tracer *matchTracer
+		r, tracer, err = d.tracedResult("", slog.Int("len", len(content)), func(m *Magic) (string, error) {
+			return m.magicBuffer(content)
+		})
+		if truncated && tracer != nil {
+			r.NeedsMore = tracer.beyond
+		}
+	} else {
+		var raw string
+		raw, err = d.DetectBuffer(content)
+		r = newResult("", raw, d.flags, err)
+	}
+	if d.truncation || truncated {
+		r.Examined, r.Truncated = len(content), truncated
+	}
+	return d.finishContent(r, content), err
+}
@@ -0,0 +1,66 @@
+package libmagic
+
+import (
+	"bytes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectorDetectPrefixResult() {
+	t := s.T()
+	iso := make([]byte, 0x9000)
+	copy(iso[0x8000:], "\x01CD001")
+
+	// Without the report, the prefix is detected without tracing.
+	detector, err := NewDetector(WithPoolSize(1), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+	result, err := detector.DetectPrefixResult(iso[:0x8000])
+	require.NoError(t, err)
+	assert.Equal(t, 0x8000, result.Examined)
+	assert.True(t, result.Truncated)
+	assert.False(t, result.NeedsMore)
+
+	detector, err = NewDetector(WithPoolSize(1), WithDatabases("../testdata/magic.mgc"),
+		WithTruncationReport())
+	require.NoError(t, err)
+	defer detector.Close()
+	result, err = detector.DetectPrefixResult(iso[:0x8000])
+	require.NoError(t, err)
+	assert.NotContains(t, result.Description, "ISO 9660")
+	assert.Equal(t, 0x8000, result.Examined)
+	assert.True(t, result.Truncated)
+	assert.True(t, result.NeedsMore)
+
+	result, err = detector.DetectPrefixResult(iso)
+	require.NoError(t, err)
+	assert.Contains(t, result.Description, "ISO 9660")
+	assert.True(t, result.Truncated)
+}
+
+func (s *MagicTestSuite) TestDetectorWithTruncationReport() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithDatabases("../testdata/magic.mgc"),
+		WithTruncationReport())
+	require.NoError(t, err)
+	defer detector.Close()
+
+	result, err := detector.DetectBufferResult(pngHeader)
+	require.NoError(t, err)
+	assert.Equal(t, len(pngHeader), result.Examined)
+	assert.False(t, result.Truncated)
+	assert.False(t, result.NeedsMore)
+
+	result, err = detector.DetectReaderResult(bytes.NewReader(pngHeader))
+	require.NoError(t, err)
+	assert.Equal(t, len(pngHeader), result.Examined)
+	assert.False(t, result.Truncated)
+
+	long := append(bytes.Clone(pngHeader), make([]byte, DefaultHeadSize)...)
+	result, err = detector.DetectReaderResult(bytes.NewReader(long))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultHeadSize, result.Examined)
+	assert.True(t, result.Truncated)
+	assert.Contains(t, result.Description, "PNG")
+}
@@ -0,0 +1,86 @@
+package libmagic
+
+import (
+	"context"
+	"sync"
+)
+
+// uringBatch is the number of files read together by detectFilesURing.
+const uringBatch = 32
+
+type uringJob struct {
+	index int
+	head  []byte
+	done  *sync.WaitGroup
+}
+
+// detectFilesURing implements DetectFiles with WithIOUring. Files are read a
+// batch at a time into one of two sets of buffers, so the next batch is read
+// while the previous one is being detected. It fails only when io_uring
+// cannot be used at all.
+func (d *MagicDetector) detectFilesURing(ctx context.Context, paths []string, o batchOptions) ([]Result, error) {
+	ring, err := newURing(uringBatch)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if ring != nil {
+			ring.close()
+		}
+	}()
+
+	results := make([]Result, len(paths))
+	jobs := make(chan uringJob)
+	var workers sync.WaitGroup
+	for i := 0; i < o.concurrency && i < len(paths); i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range jobs {
+				path := paths[job.index]
+				var raw string
+				var err error
+				if job.head != nil && ctx.Err() == nil {
+					// Not abandoned on cancellation: head is reused.
+					raw, err = d.DetectBuffer(job.head)
+				} else {
+					raw, err = d.DetectFileCtx(ctx, path)
+				}
+				results[job.index] = d.result(path, raw, err)
+				job.done.Done()
+			}
+		}()
+	}
+
+	var (
+		bufs    [2][][]byte
+		pending [2]sync.WaitGroup
+		follow  = d.flags&MagicSymlink != 0
+	)
+	for start, k := 0, 0; start < len(paths); start, k = start+uringBatch, 1-k {
+		batch := paths[start:min(start+uringBatch, len(paths))]
+		pending[k].Wait()
+		for len(bufs[k]) < len(batch) {
+			bufs[k] = append(bufs[k], make([]byte, o.headSize))
+		}
+		var n []int
+		if ring != nil {
+			if n, err = ring.readHeads(batch, bufs[k][:len(batch)], follow); err != nil {
+				// The ring may hold stale completions; read the rest as usual.
+				ring.close()
+				ring = nil
+			}
+		}
+		pending[k].Add(len(batch))
+		for i := range batch {
+			job := uringJob{index: start + i, done: &pending[k]}
+			if n != nil && n[i] >= 0 {
+				job.head = bufs[k][i][:n[i]]
+			}
+			jobs <- job
+		}
+	}
+	close(jobs)
+	workers.Wait()
+	return results, nil
+}
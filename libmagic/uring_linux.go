@@ -0,0 +1,306 @@
+//go:build linux
+
+package libmagic
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// io_uring ABI, see include/uapi/linux/io_uring.h.
+const (
+	uringOffSQRing = 0
+	uringOffSQEs   = 0x10000000
+
+	uringFeatSingleMmap = 1 << 0
+	// uringFeatRWCurPos was added in 5.6 together with the opcodes below.
+	uringFeatRWCurPos = 1 << 3
+
+	uringEnterGetEvents = 1 << 0
+
+	uringOpOpenat = 18
+	uringOpClose  = 19
+	uringOpStatx  = 21
+	uringOpRead   = 22
+
+	atFdCwd           = -100
+	atSymlinkNoFollow = 0x100
+	statxType         = 0x1
+	statxSize         = 0x200
+)
+
+type uringSQRingOffsets struct {
+	head, tail, ringMask, ringEntries, flags, dropped, array, resv1 uint32
+	userAddr                                                        uint64
+}
+
+type uringCQRingOffsets struct {
+	head, tail, ringMask, ringEntries, overflow, cqes, flags, resv1 uint32
+	userAddr                                                        uint64
+}
+
+type uringParams struct {
+	sqEntries, cqEntries, flags, sqThreadCPU, sqThreadIdle, features, wqFd uint32
+	resv                                                                   [3]uint32
+	sqOff                                                                  uringSQRingOffsets
+	cqOff                                                                  uringCQRingOffsets
+}
+
+type uringSQE struct {
+	opcode, flags uint8
+	ioprio        uint16
+	fd            int32
+	off, addr     uint64
+	len, opFlags  uint32
+	userData      uint64
+	bufIndex      uint16
+	personality   uint16
+	spliceFdIn    int32
+	addr3, pad    uint64
+}
+
+type uringCQE struct {
+	userData uint64
+	res      int32
+	flags    uint32
+}
+
+type uringStatx struct {
+	mask, blksize   uint32
+	attributes      uint64
+	nlink, uid, gid uint32
+	mode            uint16
+	_               uint16
+	ino, size       uint64
+	_               [208]byte
+}
+
+// uring is a minimal io_uring instance used by one goroutine at a time.
+type uring struct {
+	fd      int
+	entries uint32
+	ring    []byte
+	sqeMem  []byte
+	sqHead  *uint32
+	sqTail  *uint32
+	sqMask  uint32
+	sqArray []uint32
+	sqes    []uringSQE
+	cqHead  *uint32
+	cqTail  *uint32
+	cqMask  uint32
+	cqes    []uringCQE
+	queued  uint32
+}
+
+// newURing sets up a ring with room for entries requests. It fails with
+// ErrUnsupported when the kernel has no usable io_uring.
+func newURing(entries uint32) (*uring, error) {
+	var p uringParams
+	fd, _, errno := syscall.Syscall(unix.SYS_IO_URING_SETUP, uintptr(entries), uintptr(unsafe.Pointer(&p)), 0)
+	if errno == syscall.ENOSYS || errno == syscall.EPERM {
+		return nil, fmt.Errorf("%w: io_uring_setup: %v", ErrUnsupported, errno)
+	}
+	if errno != 0 {
+		return nil, os.NewSyscallError("io_uring_setup", errno)
+	}
+	r := &uring{fd: int(fd), entries: p.sqEntries}
+	if p.features&uringFeatSingleMmap == 0 || p.features&uringFeatRWCurPos == 0 {
+		r.close()
+		return nil, fmt.Errorf("%w: io_uring is too old", ErrUnsupported)
+	}
+	size := max(p.sqOff.array+p.sqEntries*4, p.cqOff.cqes+p.cqEntries*uint32(unsafe.Sizeof(uringCQE{})))
+	var err error
+	r.ring, err = syscall.Mmap(r.fd, uringOffSQRing, int(size), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED|syscall.MAP_POPULATE)
+	if err != nil {
+		r.close()
+		return nil, os.NewSyscallError("mmap", err)
+	}
+	r.sqeMem, err = syscall.Mmap(r.fd, uringOffSQEs, int(p.sqEntries)*int(unsafe.Sizeof(uringSQE{})), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED|syscall.MAP_POPULATE)
+	if err != nil {
+		r.close()
+		return nil, os.NewSyscallError("mmap", err)
+	}
+	r.sqHead = r.uint32At(p.sqOff.head)
+	r.sqTail = r.uint32At(p.sqOff.tail)
+	r.sqMask = *r.uint32At(p.sqOff.ringMask)
+	r.sqArray = unsafe.Slice(r.uint32At(p.sqOff.array), p.sqEntries)
+	r.sqes = unsafe.Slice((*uringSQE)(unsafe.Pointer(&r.sqeMem[0])), p.sqEntries)
+	r.cqHead = r.uint32At(p.cqOff.head)
+	r.cqTail = r.uint32At(p.cqOff.tail)
+	r.cqMask = *r.uint32At(p.cqOff.ringMask)
+	r.cqes = unsafe.Slice((*uringCQE)(unsafe.Pointer(&r.ring[p.cqOff.cqes])), p.cqEntries)
+	return r, nil
+}
+
+func (r *uring) uint32At(off uint32) *uint32 {
+	return (*uint32)(unsafe.Pointer(&r.ring[off]))
+}
+
+func (r *uring) close() {
+	if r.sqeMem != nil {
+		syscall.Munmap(r.sqeMem)
+	}
+	if r.ring != nil {
+		syscall.Munmap(r.ring)
+	}
+	syscall.Close(r.fd)
+}
+
+// push queues a request; at most entries requests may be queued at once.
+func (r *uring) push(sqe uringSQE) {
+	tail := *r.sqTail
+	idx := tail & r.sqMask
+	r.sqes[idx] = sqe
+	r.sqArray[idx] = idx
+	atomic.StoreUint32(r.sqTail, tail+1)
+	r.queued++
+}
+
+// wait submits the queued requests and passes each completion to fn.
+func (r *uring) wait(fn func(cqe uringCQE)) error {
+	submit, pending := r.queued, r.queued
+	r.queued = 0
+	for pending > 0 {
+		n, _, errno := syscall.Syscall6(unix.SYS_IO_URING_ENTER, uintptr(r.fd), uintptr(submit), uintptr(pending), uringEnterGetEvents, 0, 0)
+		if errno == syscall.EINTR {
+			continue
+		}
+		if errno != 0 {
+			return os.NewSyscallError("io_uring_enter", errno)
+		}
+		submit -= uint32(n)
+		head, tail := *r.cqHead, atomic.LoadUint32(r.cqTail)
+		for ; head != tail; head++ {
+			fn(r.cqes[head&r.cqMask])
+			pending--
+		}
+		atomic.StoreUint32(r.cqHead, head)
+	}
+	return nil
+}
+
+// readHeads reads the beginning of the regular files at paths into bufs and
+// returns the number of bytes read for each of them, or -1 for paths that
+// are not non-empty regular files or could not be read. Symlinks are only
+// followed with follow. At most entries paths can be read at once.
+func (r *uring) readHeads(paths []string, bufs [][]byte, follow bool) ([]int, error) {
+	n := make([]int, len(paths))
+	names := make([]*byte, len(paths))
+	stats := make([]uringStatx, len(paths))
+	fds := make([]int32, len(paths))
+	for i := range fds {
+		fds[i] = -1
+	}
+	defer func() {
+		// Only left over when a step failed.
+		for _, fd := range fds {
+			if fd >= 0 {
+				syscall.Close(int(fd))
+			}
+		}
+	}()
+	var pinner runtime.Pinner
+	defer pinner.Unpin()
+	pinner.Pin(&stats[0])
+
+	statFlags, openFlags := uint32(atSymlinkNoFollow), uint32(syscall.O_RDONLY|syscall.O_CLOEXEC|syscall.O_NONBLOCK|syscall.O_NOCTTY)
+	if follow {
+		statFlags = 0
+	} else {
+		openFlags |= syscall.O_NOFOLLOW
+	}
+	for i, path := range paths {
+		name, err := syscall.BytePtrFromString(path)
+		if err != nil {
+			n[i] = -1
+			continue
+		}
+		names[i] = name
+		pinner.Pin(name)
+		r.push(uringSQE{
+			opcode:   uringOpStatx,
+			fd:       atFdCwd,
+			addr:     uint64(uintptr(unsafe.Pointer(name))),
+			len:      statxType | statxSize,
+			off:      uint64(uintptr(unsafe.Pointer(&stats[i]))),
+			opFlags:  statFlags,
+			userData: uint64(i),
+		})
+	}
+	err := r.wait(func(cqe uringCQE) {
+		i := cqe.userData
+		if cqe.res < 0 || stats[i].mode&syscall.S_IFMT != syscall.S_IFREG || stats[i].size == 0 {
+			n[i] = -1
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range paths {
+		if n[i] < 0 {
+			continue
+		}
+		r.push(uringSQE{
+			opcode:   uringOpOpenat,
+			fd:       atFdCwd,
+			addr:     uint64(uintptr(unsafe.Pointer(names[i]))),
+			opFlags:  openFlags,
+			userData: uint64(i),
+		})
+	}
+	err = r.wait(func(cqe uringCQE) {
+		if cqe.res < 0 {
+			n[cqe.userData] = -1
+		} else {
+			fds[cqe.userData] = cqe.res
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range paths {
+		if n[i] < 0 {
+			continue
+		}
+		pinner.Pin(&bufs[i][0])
+		r.push(uringSQE{
+			opcode:   uringOpRead,
+			fd:       fds[i],
+			addr:     uint64(uintptr(unsafe.Pointer(&bufs[i][0]))),
+			len:      uint32(len(bufs[i])),
+			userData: uint64(i),
+		})
+	}
+	err = r.wait(func(cqe uringCQE) {
+		if cqe.res <= 0 {
+			n[cqe.userData] = -1
+		} else {
+			n[cqe.userData] = int(cqe.res)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range paths {
+		if fds[i] >= 0 {
+			r.push(uringSQE{opcode: uringOpClose, fd: fds[i], userData: uint64(i)})
+		}
+	}
+	if err := r.wait(func(uringCQE) {}); err != nil {
+		return nil, err
+	}
+	for i := range fds {
+		fds[i] = -1
+	}
+	return n, nil
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package libmagic
+
+type uring struct{}
+
+func newURing(entries uint32) (*uring, error) {
+	return nil, ErrUnsupported
+}
+
+func (r *uring) readHeads(paths []string, bufs [][]byte, follow bool) ([]int, error) {
+	return nil, ErrUnsupported
+}
+
+func (r *uring) close() {}
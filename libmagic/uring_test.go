@@ -0,0 +1,58 @@
+package libmagic
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectFilesIOUring() {
+	t := s.T()
+	t.Parallel()
+	detector, err := NewDetector(WithPoolSize(2), WithFlags(MagicMimeType|MagicError), WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	dir := t.TempDir()
+	var paths []string
+	for i := 0; i < 2*uringBatch+5; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("%d.png", i))
+		require.NoError(t, os.WriteFile(path, pngHeader, 0o644))
+		paths = append(paths, path)
+	}
+	text := filepath.Join(dir, "text")
+	require.NoError(t, os.WriteFile(text, []byte("hello world\n"), 0o644))
+	empty := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(empty, nil, 0o644))
+	link := filepath.Join(dir, "link")
+	require.NoError(t, os.Symlink(paths[0], link))
+	fifo := filepath.Join(dir, "fifo")
+	require.NoError(t, syscall.Mkfifo(fifo, 0o644))
+	paths = append(paths, text, empty, dir, link, fifo, filepath.Join(dir, "nonexist"))
+
+	expected, err := detector.DetectFiles(paths)
+	require.NoError(t, err)
+	results, err := detector.DetectFiles(paths, WithIOUring(64))
+	require.NoError(t, err)
+	assert.Equal(t, expected, results)
+
+	ring, err := newURing(4)
+	if errors.Is(err, ErrUnsupported) {
+		t.Skip("io_uring is not available")
+	}
+	require.NoError(t, err)
+	defer ring.close()
+	bufs := [][]byte{make([]byte, 4), make([]byte, 64), make([]byte, 64), make([]byte, 64)}
+	n, err := ring.readHeads([]string{paths[0], text, empty, link}, bufs, false)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4, 12, -1, -1}, n)
+	assert.Equal(t, pngHeader[:4], bufs[0])
+	n, err = ring.readHeads([]string{link}, bufs[1:2], true)
+	require.NoError(t, err)
+	assert.Equal(t, []int{len(pngHeader)}, n)
+}
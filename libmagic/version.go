@@ -0,0 +1,57 @@
+package libmagic
+
+// #include "magic_compat.h"
+import "C"
+
+// Minimum libmagic versions (as reported by magic_version) that provide the
+// optional parts of the API. magic_compat.h uses the same values to decide
+// what gets compiled in.
+const (
+	versionSetParam    = 521
+	versionLoadBuffers = 523
+	versionGetFlags    = 533
+)
+
+// flagVersions lists flags that only newer libmagic releases understand,
+// keyed by the first version honoring them. Older releases silently ignore
+// unknown bits, so the check cannot be left to magic_setflags.
+var flagVersions = map[Flags]int{
+	MagicNoCheckJSON: 535,
+	MagicNoCheckCsv:  538,
+	MagicNoCheckSimh: 545,
+}
+
+// Version returns the version of the linked libmagic, e.g. 544 for 5.44, or
+// 0 when the library is too old to report it.
+func Version() int {
+	return int(C.gomagic_version())
+}
+
+// SupportsGetFlags reports whether the linked libmagic implements
+// magic_getflags.
+func SupportsGetFlags() bool {
+	return C.GOMAGIC_HAVE_GETFLAGS != 0 && Version() >= versionGetFlags
+}
+
+// SupportsParams reports whether the linked libmagic implements
+// magic_setparam and magic_getparam.
+func SupportsParams() bool {
+	return C.GOMAGIC_HAVE_PARAMS != 0 && Version() >= versionSetParam
+}
+
+// SupportsLoadBuffers reports whether the linked libmagic implements
+// magic_load_buffers.
+func SupportsLoadBuffers() bool {
+	return C.GOMAGIC_HAVE_LOAD_BUFFERS != 0 && Version() >= versionLoadBuffers
+}
+
+// SupportsFlags reports whether the linked libmagic honors every bit in flags.
+func SupportsFlags(flags Flags) bool {
+	version := Version()
+	for flag, minVersion := range flagVersions {
+		if flags&flag != 0 && version < minVersion {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+package libmagic
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"iter"
+	"os"
+	"path/filepath"
+)
+
+// walkBatch is the most files DetectWalk detects at once.
+const walkBatch = 64
+
+// DetectWalk walks the file tree rooted at root with fs.WalkDir and calls fn
+// for each regular file, in lexical order, with its Result and the Result
+// error. Files are detected concurrently, in batches of consecutive files of
+// the same directory, as configured by opts; see DetectFiles.
+//
+// fn is also called with the error when a directory cannot be read, in which
+// case the Result only has Path and Err set. Like for fs.WalkDirFunc, fn
+// returning fs.SkipDir skips the remaining files and subdirectories of the
+// directory, fs.SkipAll stops the walk and any other error stops the walk
+// and is returned by DetectWalk.
+func (d *MagicDetector) DetectWalk(root string, fn func(path string, r Result, err error) error, opts ...BatchOption) error {
+	if o := d.batchOptions(opts); o.dedupCache != nil {
+		opts = append(opts[:len(opts):len(opts)], withDedupCache(o.dedupCache))
+	}
+	w := &walker{d: d, root: root, fn: fn, opts: opts, skipped: map[string]bool{}}
+	err := fs.WalkDir(os.DirFS(root), ".", w.visit)
+	if err == nil {
+		err = w.flush()
+	}
+	if err == fs.SkipAll {
+		return nil
+	}
+	return err
+}
+
+type walker struct {
+	d    *MagicDetector
+	root string
+	fn   func(path string, r Result, err error) error
+	opts []BatchOption
+	// pending are files of the same directory waiting to be detected.
+	pending []string
+	// skipped are directories fn returned fs.SkipDir for.
+	skipped map[string]bool
+}
+
+func (w *walker) visit(name string, entry fs.DirEntry, err error) error {
+	path := filepath.Join(w.root, filepath.FromSlash(name))
+	dir := filepath.Dir(path)
+	if len(w.pending) > 0 && (err != nil || entry.IsDir() ||
+		filepath.Dir(w.pending[0]) != dir || len(w.pending) == walkBatch) {
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+	if w.isSkipped(path) {
+		return fs.SkipDir
+	}
+	if err != nil {
+		return w.fn(path, Result{Path: path, Err: err}, err)
+	}
+	if entry.Type().IsRegular() {
+		w.pending = append(w.pending, path)
+	}
+	return nil
+}
+
+// flush detects the pending files and calls fn for them.
+func (w *walker) flush() error {
+	paths := w.pending
+	w.pending = w.pending[:0]
+	if len(paths) == 0 {
+		return nil
+	}
+	results, err := w.d.DetectFilesCtx(context.Background(), paths, w.opts...)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		err := w.fn(r.Path, r, r.Err)
+		if errors.Is(err, fs.SkipDir) {
+			w.skipped[filepath.Dir(r.Path)] = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isSkipped reports whether path is in a directory fn returned fs.SkipDir
+// for.
+func (w *walker) isSkipped(path string) bool {
+	for len(w.skipped) > 0 {
+		dir := filepath.Dir(path)
+		if dir == path {
+			return false
+		}
+		if w.skipped[dir] {
+			return true
+		}
+		path = dir
+	}
+	return false
+}
+
+// Scan returns an iterator over the regular files of the tree rooted at root
+// and their Result, detected like by DetectWalk:
+//
+//	for path, r := range detector.Scan(dir) {
+//		...
+//	}
+//
+// Files are detected lazily, at most one batch ahead of the loop. Errors,
+// including directories that cannot be read, are reported in Result.Err;
+// breaking out of the loop stops the walk.
+func (d *MagicDetector) Scan(root string, opts ...BatchOption) iter.Seq2[string, Result] {
+	return func(yield func(string, Result) bool) {
+		err := d.DetectWalk(root, func(path string, r Result, err error) error {
+			if !yield(path, r) {
+				return fs.SkipAll
+			}
+			return nil
+		}, opts...)
+		if err != nil {
+			yield(root, Result{Path: root, Err: err})
+		}
+	}
+}
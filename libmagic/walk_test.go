@@ -0,0 +1,133 @@
+package libmagic
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *MagicTestSuite) TestDetectorDetectWalk() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(2), WithFlags(MagicMimeType),
+		WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	root := t.TempDir()
+	files := map[string][]byte{
+		"a.png":     pngHeader,
+		"b/c.txt":   []byte("hello world\n"),
+		"b/d/e.png": pngHeader,
+		"b/f.txt":   []byte("hello world\n"),
+		"g/h.txt":   []byte("hello world\n"),
+		"g/i/j.txt": []byte("hello world\n"),
+		"k.txt":     []byte("hello world\n"),
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, content, 0o600))
+	}
+	require.NoError(t, os.Symlink("a.png", filepath.Join(root, "link")))
+	rel := func(path string) string {
+		rel, err := filepath.Rel(root, path)
+		require.NoError(t, err)
+		return filepath.ToSlash(rel)
+	}
+
+	got := map[string]string{}
+	var order []string
+	err = detector.DetectWalk(root, func(path string, r Result, err error) error {
+		assert.NoError(t, err)
+		assert.Equal(t, path, r.Path)
+		got[rel(path)] = r.MIME
+		order = append(order, rel(path))
+		return nil
+	}, WithConcurrency(2))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.png", "b/c.txt", "b/d/e.png", "b/f.txt", "g/h.txt", "g/i/j.txt", "k.txt"}, order)
+	assert.Equal(t, "image/png", got["b/d/e.png"])
+	assert.Equal(t, "text/plain", got["k.txt"])
+
+	order = nil
+	err = detector.DetectWalk(root, func(path string, r Result, err error) error {
+		order = append(order, rel(path))
+		if rel(path) == "b/c.txt" || rel(path) == "g/h.txt" {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.png", "b/c.txt", "g/h.txt", "k.txt"}, order)
+
+	order = nil
+	err = detector.DetectWalk(root, func(path string, r Result, err error) error {
+		order = append(order, rel(path))
+		return fs.SkipAll
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.png"}, order)
+
+	stop := errors.New("stop")
+	err = detector.DetectWalk(root, func(path string, r Result, err error) error {
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+
+	missing := filepath.Join(root, "missing")
+	err = detector.DetectWalk(missing, func(path string, r Result, err error) error {
+		assert.Equal(t, missing, path)
+		assert.ErrorIs(t, r.Err, fs.ErrNotExist)
+		return err
+	})
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func (s *MagicTestSuite) TestDetectorScan() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(2), WithFlags(MagicMimeType),
+		WithDatabases("../testdata/magic.mgc"))
+	require.NoError(t, err)
+	defer detector.Close()
+
+	root := t.TempDir()
+	for _, name := range []string{"a.png", "b/c.png", "d.png"} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, pngHeader, 0o600))
+	}
+
+	var paths []string
+	for path, r := range detector.Scan(root) {
+		assert.NoError(t, r.Err)
+		assert.Equal(t, "image/png", r.MIME)
+		paths = append(paths, path)
+	}
+	assert.Equal(t, []string{
+		filepath.Join(root, "a.png"),
+		filepath.Join(root, "b", "c.png"),
+		filepath.Join(root, "d.png"),
+	}, paths)
+
+	paths = nil
+	for path := range detector.Scan(root) {
+		paths = append(paths, path)
+		break
+	}
+	assert.Equal(t, []string{filepath.Join(root, "a.png")}, paths)
+
+	missing := filepath.Join(root, "missing")
+	for path, r := range detector.Scan(missing) {
+		assert.Equal(t, missing, path)
+		assert.ErrorIs(t, r.Err, fs.ErrNotExist)
+	}
+
+	detector.Close()
+	for _, r := range detector.Scan(root) {
+		assert.ErrorIs(t, r.Err, ErrClosed)
+	}
+}
@@ -0,0 +1,251 @@
+package libmagic
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// errXZDictTooLarge is returned for a block whose LZMA2 dictionary is larger
+// than the memory limit. The decompressor allocates the dictionary a block
+// declares before reading any of its data, so a few bytes could otherwise
+// claim gigabytes.
+var errXZDictTooLarge = errors.New("xz: dictionary too large")
+
+var errXZFormat = errors.New("xz: invalid container format")
+
+// xzState is the structure of the xz format xzGuard reads next.
+type xzState int
+
+const (
+	xzStreamHeader xzState = iota
+	// xzBlockHeader is a block header or the index indicator.
+	xzBlockHeader
+	xzChunk
+	xzIndex
+	xzRecord
+	// xzPadding is stream padding, another stream or the end.
+	xzPadding
+)
+
+// xzGuard passes an xz file through while walking its container format, see
+// https://tukaani.org/xz/xz-file-format.txt. It fails with errXZDictTooLarge
+// before the header of a block declaring a dictionary larger than maxDictCap
+// reaches the decompressor.
+type xzGuard struct {
+	r     *bufio.Reader
+	state xzState
+	// pass is the number of bytes read through before the next structure.
+	pass      int
+	checkSize int
+	// blockSize is the compressed size of the current block so far.
+	blockSize  int
+	records    uint64
+	indexSize  int
+	maxDictCap int64
+	err        error
+}
+
+func newXZGuard(r io.Reader, maxDictCap int64) *xzGuard {
+	return &xzGuard{r: bufio.NewReader(r), maxDictCap: maxDictCap}
+}
+
+func (g *xzGuard) Read(p []byte) (int, error) {
+	for g.pass == 0 && len(p) > 0 {
+		if g.err != nil {
+			return 0, g.err
+		}
+		g.err = g.next()
+	}
+	n, err := g.r.Read(p[:min(len(p), g.pass)])
+	g.pass -= n
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// next checks the structure starting at the current position and sets how
+// many bytes of it to pass through.
+func (g *xzGuard) next() error {
+	switch g.state {
+	case xzStreamHeader:
+		h, err := g.peek(12)
+		if err != nil {
+			return err
+		}
+		g.checkSize = 0
+		if id := h[7] & 0x0f; id > 0 {
+			g.checkSize = 4 << ((id - 1) / 3)
+		}
+		g.pass, g.state = len(h), xzBlockHeader
+	case xzBlockHeader:
+		b, err := g.peek(1)
+		if err != nil {
+			return err
+		}
+		if b[0] == 0 {
+			g.pass, g.state, g.indexSize = 1, xzIndex, 1
+			return nil
+		}
+		h, err := g.peek((int(b[0]) + 1) * 4)
+		if err != nil {
+			return err
+		}
+		if err := checkXZBlockHeader(h, g.maxDictCap); err != nil {
+			return err
+		}
+		g.pass, g.state, g.blockSize = len(h), xzChunk, 0
+	case xzChunk:
+		c, err := g.peek(1)
+		if err != nil {
+			return err
+		}
+		switch control := c[0]; {
+		case control == 0:
+			// The end of the block, its padding and its check.
+			g.blockSize++
+			g.pass = 1 + -g.blockSize&3 + g.checkSize
+			g.state = xzBlockHeader
+			return nil
+		case control == 1 || control == 2:
+			h, err := g.peek(3)
+			if err != nil {
+				return err
+			}
+			g.pass = len(h) + int(binary.BigEndian.Uint16(h[1:])) + 1
+		case control >= 0x80:
+			n := 5
+			if control >= 0xc0 {
+				n++
+			}
+			h, err := g.peek(n)
+			if err != nil {
+				return err
+			}
+			g.pass = len(h) + int(binary.BigEndian.Uint16(h[3:])) + 1
+		default:
+			return errXZFormat
+		}
+		g.blockSize += g.pass
+	case xzIndex:
+		records, n, err := g.uvarint(0)
+		if err != nil {
+			return err
+		}
+		g.records, g.pass, g.state = records, n, xzRecord
+		g.indexSize += n
+	case xzRecord:
+		if g.records == 0 {
+			// The index padding, its CRC32 and the stream footer.
+			g.pass = -g.indexSize&3 + 4 + 12
+			g.state = xzPadding
+			return nil
+		}
+		_, n, err := g.uvarint(0)
+		if err != nil {
+			return err
+		}
+		_, m, err := g.uvarint(n)
+		if err != nil {
+			return err
+		}
+		g.records--
+		g.pass = n + m
+		g.indexSize += g.pass
+	case xzPadding:
+		b, err := g.r.Peek(4)
+		if len(b) == 0 && errors.Is(err, io.EOF) {
+			return io.EOF
+		}
+		if len(b) == 4 && binary.LittleEndian.Uint32(b) == 0 {
+			g.pass = len(b)
+			return nil
+		}
+		g.state = xzStreamHeader
+	}
+	return nil
+}
+
+func (g *xzGuard) peek(n int) ([]byte, error) {
+	b, err := g.r.Peek(n)
+	if errors.Is(err, io.EOF) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return b, err
+}
+
+// uvarint decodes the xz variable length integer at offset off.
+func (g *xzGuard) uvarint(off int) (uint64, int, error) {
+	b, err := g.r.Peek(off + binary.MaxVarintLen64)
+	if len(b) <= off {
+		if err == nil || errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, 0, err
+	}
+	v, n := binary.Uvarint(b[off:])
+	if n <= 0 {
+		return 0, 0, errXZFormat
+	}
+	return v, n, nil
+}
+
+// checkXZBlockHeader checks the dictionary size of the LZMA2 filter of the
+// block header h against maxDictCap.
+func checkXZBlockHeader(h []byte, maxDictCap int64) error {
+	if len(h) < 8 {
+		return errXZFormat
+	}
+	flags, fields := h[1], h[2:len(h)-4]
+	uvarint := func() (uint64, error) {
+		v, n := binary.Uvarint(fields)
+		if n <= 0 {
+			return 0, errXZFormat
+		}
+		fields = fields[n:]
+		return v, nil
+	}
+	// The optional compressed and uncompressed sizes.
+	for _, present := range []bool{flags&0x40 != 0, flags&0x80 != 0} {
+		if present {
+			if _, err := uvarint(); err != nil {
+				return err
+			}
+		}
+	}
+	for range flags&0x03 + 1 {
+		id, err := uvarint()
+		if err != nil {
+			return err
+		}
+		size, err := uvarint()
+		if err != nil {
+			return err
+		}
+		if size > uint64(len(fields)) {
+			return errXZFormat
+		}
+		if id == 0x21 {
+			if size != 1 {
+				return errXZFormat
+			}
+			if dictCap := xzDictCap(fields[0]); dictCap > maxDictCap {
+				return fmt.Errorf("%w: %d bytes, limit %d", errXZDictTooLarge, dictCap, maxDictCap)
+			}
+		}
+		fields = fields[size:]
+	}
+	return nil
+}
+
+// xzDictCap decodes the dictionary size of the LZMA2 filter properties.
+func xzDictCap(props byte) int64 {
+	bits := int64(props & 0x3f)
+	if bits >= 40 {
+		return 1<<32 - 1
+	}
+	return (2 | bits&1) << (bits/2 + 11)
+}
@@ -0,0 +1,152 @@
+package libmagic
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"mime"
+	"strings"
+)
+
+// zipMIME is the MIME type libmagic prints for zip archives it cannot refine.
+const zipMIME = "application/zip"
+
+// zipDescription starts the descriptions libmagic prints for zip archives.
+const zipDescription = "Zip archive data"
+
+// Zip based formats recognized by RefineZip.
+const (
+	docxMIME = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	xlsxMIME = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	pptxMIME = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+	apkMIME  = "application/vnd.android.package-archive"
+	jarMIME  = "application/java-archive"
+)
+
+// ooxmlParts map the top directory of the main part of Office Open XML
+// documents to their MIME type.
+var ooxmlParts = map[string]string{
+	"word/": docxMIME,
+	"xl/":   xlsxMIME,
+	"ppt/":  pptxMIME,
+}
+
+// zipDescriptions describe well-known zip based formats.
+var zipDescriptions = map[string]string{
+	docxMIME:               "Microsoft Word 2007+",
+	xlsxMIME:               "Microsoft Excel 2007+",
+	pptxMIME:               "Microsoft PowerPoint 2007+",
+	apkMIME:                "Android package (APK)",
+	jarMIME:                "Java archive data (JAR)",
+	"application/epub+zip": "EPUB document",
+	"application/vnd.oasis.opendocument.presentation": "OpenDocument Presentation",
+	"application/vnd.oasis.opendocument.spreadsheet":  "OpenDocument Spreadsheet",
+	"application/vnd.oasis.opendocument.text":         "OpenDocument Text",
+}
+
+// maxZipMimetype bounds the size of the mimetype member RefineZip reads.
+const maxZipMimetype = 256
+
+// RefineZip returns the MIME type of the document format stored in the zip
+// archive r of size bytes, from its central directory: the content of the
+// mimetype member for ODF and EPUB, when it names one of them, the main part of Office Open XML
+// documents, AndroidManifest.xml for APKs and META-INF/MANIFEST.MF for JARs.
+// It returns false if r is not a zip archive or holds no known format.
+func RefineZip(r io.ReaderAt, size int64) (string, bool) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return "", false
+	}
+	return refineZip(zr)
+}
+
+func refineZip(zr *zip.Reader) (string, bool) {
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	if f, ok := files["mimetype"]; ok && f.UncompressedSize64 <= maxZipMimetype {
+		if content, err := readZipFile(f); err == nil && isZipMimetype(strings.TrimSpace(string(content))) {
+			return strings.TrimSpace(string(content)), true
+		}
+	}
+	if _, ok := files["[Content_Types].xml"]; ok {
+		for _, f := range zr.File {
+			for dir, mime := range ooxmlParts {
+				if strings.HasPrefix(f.Name, dir) {
+					return mime, true
+				}
+			}
+		}
+	}
+	if _, ok := files["AndroidManifest.xml"]; ok {
+		return apkMIME, true
+	}
+	if _, ok := files["META-INF/MANIFEST.MF"]; ok {
+		return jarMIME, true
+	}
+	return "", false
+}
+
+// isZipMimetype reports whether the content of a mimetype member names a zip
+// based format. Anything else, such as image/png, is ignored rather than
+// trusted: the member is arbitrary data of the archive.
+func isZipMimetype(s string) bool {
+	if _, _, err := mime.ParseMediaType(s); err != nil {
+		return false
+	}
+	return s == "application/epub+zip" || strings.HasPrefix(s, "application/vnd.oasis.opendocument.")
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, maxZipMimetype))
+}
+
+// WithZipRefinement makes the MagicDetector refine Results libmagic reports
+// as generic zip archives, see RefineZip, setting MIME and, for well-known
+// formats, Description; Raw keeps the libmagic output. Files are refined by
+// opening them again and content from the buffer itself. Readers are
+// refined from their first DefaultHeadSize bytes, so only archives shorter
+// than that, as the central directory is at the end.
+func WithZipRefinement() DetectorOption {
+	return func(d *MagicDetector) {
+		d.refineZip = true
+	}
+}
+
+// refineZipResult refines r if it is a zip archive. content is the detected
+// content, or nil for the file at r.Path.
+func refineZipResult(r *Result, content []byte) {
+	if r.MIME != zipMIME && !strings.HasPrefix(r.Description, zipDescription) {
+		return
+	}
+	var (
+		refined string
+		ok      bool
+	)
+	switch {
+	case content != nil:
+		refined, ok = RefineZip(bytes.NewReader(content), int64(len(content)))
+	case r.Path != "":
+		zr, err := zip.OpenReader(r.Path)
+		if err != nil {
+			return
+		}
+		defer zr.Close()
+		refined, ok = refineZip(&zr.Reader)
+	}
+	if !ok {
+		return
+	}
+	if r.MIME != "" {
+		r.MIME = refined
+	}
+	if description, ok := zipDescriptions[refined]; ok && r.Description != "" {
+		r.Description = description
+	}
+}
@@ -0,0 +1,142 @@
+package libmagic
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// zipContent returns a zip archive with a member per name, in order, the
+// first one stored uncompressed.
+func zipContent(t require.TestingT, names ...string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for i, name := range names {
+		method := zip.Deflate
+		if i == 0 {
+			method = zip.Store
+		}
+		f, err := w.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		require.NoError(t, err)
+		content := "content of " + name
+		if name == "mimetype" {
+			content = "application/vnd.oasis.opendocument.text"
+		}
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+// zipMimetype returns a zip archive holding a mimetype member with content,
+// after another member so libmagic sees a generic zip archive.
+func zipMimetype(t require.TestingT, content string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	_, err := w.Create("readme.txt")
+	require.NoError(t, err)
+	f, err := w.Create("mimetype")
+	require.NoError(t, err)
+	_, err = f.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func (s *MagicTestSuite) TestRefineZip() {
+	t := s.T()
+	tests := []struct {
+		name    string
+		members []string
+		want    string
+		ok      bool
+	}{
+		{name: "docx", members: []string{"docProps/app.xml", "[Content_Types].xml", "word/document.xml"}, want: docxMIME, ok: true},
+		{name: "xlsx", members: []string{"_rels/.rels", "[Content_Types].xml", "xl/workbook.xml"}, want: xlsxMIME, ok: true},
+		{name: "pptx", members: []string{"_rels/.rels", "ppt/presentation.xml", "[Content_Types].xml"}, want: pptxMIME, ok: true},
+		{name: "odt", members: []string{"mimetype", "content.xml"}, want: "application/vnd.oasis.opendocument.text", ok: true},
+		{name: "apk", members: []string{"classes.dex", "AndroidManifest.xml", "META-INF/MANIFEST.MF"}, want: apkMIME, ok: true},
+		{name: "jar", members: []string{"a/B.class", "META-INF/MANIFEST.MF"}, want: jarMIME, ok: true},
+		{name: "plain zip", members: []string{"a.txt", "b.txt"}},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			content := zipContent(t, tt.members...)
+			got, ok := RefineZip(bytes.NewReader(content), int64(len(content)))
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, ok := RefineZip(bytes.NewReader(pngHeader), int64(len(pngHeader)))
+	assert.False(t, ok)
+
+	// Only ODF and EPUB types are taken from the mimetype member.
+	for mimetype, want := range map[string]string{
+		"application/epub+zip": "application/epub+zip",
+		"image/png":            "",
+		"text/html":            "",
+		"application/x-foo":    "",
+	} {
+		content := zipMimetype(t, mimetype)
+		got, ok := RefineZip(bytes.NewReader(content), int64(len(content)))
+		assert.Equal(t, want != "", ok, mimetype)
+		assert.Equal(t, want, got, mimetype)
+	}
+}
+
+func (s *MagicTestSuite) TestDetectorWithZipRefinement() {
+	t := s.T()
+	content := zipContent(t, "res/layout.xml", "resources.arsc", "AndroidManifest.xml")
+	path := filepath.Join(t.TempDir(), "package")
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+
+	detector, err := NewDetector(WithPoolSize(1), WithFlags(MagicMimeType),
+		WithDatabases("../testdata/magic.mgc"), WithZipRefinement())
+	require.NoError(t, err)
+	defer detector.Close()
+
+	raw, err := detector.DetectBuffer(content)
+	require.NoError(t, err)
+	assert.Equal(t, zipMIME, raw)
+
+	result, err := detector.DetectBufferResult(content)
+	require.NoError(t, err)
+	assert.Equal(t, apkMIME, result.MIME)
+	assert.Equal(t, zipMIME, result.Raw)
+	result, err = detector.DetectFileResult(path)
+	require.NoError(t, err)
+	assert.Equal(t, apkMIME, result.MIME)
+	result, err = detector.DetectReaderResult(bytes.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, apkMIME, result.MIME)
+	results, err := detector.DetectFiles([]string{path})
+	require.NoError(t, err)
+	assert.Equal(t, apkMIME, results[0].MIME)
+
+	detector, err = NewDetector(WithPoolSize(1), WithDatabases("../testdata/magic.mgc"),
+		WithZipRefinement())
+	require.NoError(t, err)
+	defer detector.Close()
+	result, err = detector.DetectFileResult(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Android package (APK)", result.Description)
+	assert.Empty(t, result.MIME)
+}
+
+func (s *MagicTestSuite) TestDetectorZipRefinementAllowlist() {
+	t := s.T()
+	detector, err := NewDetector(WithPoolSize(1), WithDatabases("../testdata/magic.mgc"), WithZipRefinement())
+	require.NoError(t, err)
+	defer detector.Close()
+
+	ok, result, err := detector.IsOneOf(zipMimetype(t, "image/png"), []string{"image/png"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, zipMIME, result.MIME)
+}